@@ -0,0 +1,39 @@
+// Command metaid-decoderd serves the MetaID chain parsers as a REST API.
+//
+//	metaid-decoderd -addr :8080
+//	curl -d @tx.hex http://localhost:8080/v1/btc/decode-tx
+//	curl -H 'Content-Type: application/octet-stream' --data-binary @block.bin 'http://localhost:8080/v1/doge/decode-block?network=testnet'
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	maxTxSize := flag.Int64("max-tx-size", DefaultMaxTxSize, "decode-tx body limit in bytes")
+	maxBlockSize := flag.Int64("max-block-size", DefaultMaxBlockSize, "decode-block body limit in bytes")
+	decodeContent := flag.Bool("decode-content", false, "decompress PIN bodies carrying a content encoding")
+	flag.Parse()
+
+	parserConfig := decoder.DefaultConfig()
+	parserConfig.DecodeContent = *decodeContent
+	server := NewServer(&Config{
+		Parser:       parserConfig,
+		MaxTxSize:    *maxTxSize,
+		MaxBlockSize: *maxBlockSize,
+	})
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("metaid-decoderd listening on %s, chains %v", *addr, decoder.RegisteredChains())
+	log.Fatal(httpServer.ListenAndServe())
+}
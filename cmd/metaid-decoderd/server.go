@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	bsvchaincfg "github.com/bitcoinsv/bsvd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/bch"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/blockfile"
+	_ "github.com/metaid-developers/metaid-script-decoder/decoder/bsv"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/btc"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/doge"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/ltc"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/mvc"
+)
+
+// Default request body limits
+const (
+	DefaultMaxTxSize    = 4 << 20  // 4 MiB
+	DefaultMaxBlockSize = 64 << 20 // 64 MiB
+)
+
+// Error codes of the error responses
+const (
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeUnknownChain     = "unknown_chain"
+	codeUnknownNetwork   = "unknown_network"
+	codeInvalidBodyMode  = "invalid_body_mode"
+	codeEmptyBody        = "empty_body"
+	codeInvalidHex       = "invalid_hex"
+	codeBodyTooLarge     = "body_too_large"
+	codeInvalidTx        = "invalid_transaction"
+	codeInvalidBlock     = "invalid_block"
+	codeInvalidParams    = "invalid_chain_params"
+	codeUnsupportedChain = "unsupported_chain"
+	codeInternal         = "internal_error"
+)
+
+// contentTypeBinary selects raw bytes instead of hex request bodies
+const contentTypeBinary = "application/octet-stream"
+
+// Config is the server configuration
+type Config struct {
+	// Parser is the configuration of the chain parsers, nil for decoder.DefaultConfig()
+	Parser *decoder.ParserConfig
+	// MaxTxSize is the request body limit of decode-tx in bytes, 0 for DefaultMaxTxSize
+	MaxTxSize int64
+	// MaxBlockSize is the request body limit of decode-block in bytes, 0 for DefaultMaxBlockSize
+	MaxBlockSize int64
+}
+
+// chainNetworks describes the networks of a chain selectable with ?network=
+type chainNetworks struct {
+	auxPoW    bool                   // Blocks may carry merged mining data
+	noWitness bool                   // Transactions use the legacy format of the BSV family
+	params    map[string]interface{} // Network name -> chain params
+}
+
+// networks lists the selectable networks by canonical chain name
+// Chains missing here, or requests without ?network=, use the parser's default params.
+// Fractal is missing because its params only cover addresses: use the fractal-testnet chain instead.
+var networks = map[string]chainNetworks{
+	"btc": {params: map[string]interface{}{
+		"mainnet":  &chaincfg.MainNetParams,
+		"testnet":  &chaincfg.TestNet3Params,
+		"testnet3": &chaincfg.TestNet3Params,
+		"testnet4": &btc.TestNet4Params,
+		"signet":   &chaincfg.SigNetParams,
+		"regtest":  &chaincfg.RegressionNetParams,
+	}},
+	"ltc": {params: map[string]interface{}{
+		"mainnet": &ltc.LTCMainNetParams,
+		"testnet": &ltc.LTCTestNetParams,
+		"regtest": &ltc.LTCRegTestParams,
+	}},
+	"doge": {auxPoW: true, params: map[string]interface{}{
+		"mainnet": &doge.DogeMainNetParams,
+		"testnet": &doge.DogeTestNetParams,
+		"regtest": &doge.DogeRegTestParams,
+	}},
+	"mvc": {noWitness: true, params: map[string]interface{}{
+		"mainnet": &mvc.MVCMainNetParams,
+		"testnet": &mvc.MVCTestNetParams,
+		"regtest": &mvc.MVCRegTestParams,
+	}},
+	"bsv": {noWitness: true, params: map[string]interface{}{
+		"mainnet": &bsvchaincfg.MainNetParams,
+		"testnet": &bsvchaincfg.TestNet3Params,
+		"regtest": &bsvchaincfg.RegressionNetParams,
+	}},
+	"bch": {noWitness: true, params: map[string]interface{}{
+		"mainnet": &bch.BCHMainNetParams,
+		"testnet": &bch.BCHTestNetParams,
+		"regtest": &bch.BCHRegTestParams,
+	}},
+}
+
+// Server serves the registered chain parsers as a REST API:
+//
+//	GET  /v1/health
+//	POST /v1/{chain}/decode-tx?network=mainnet&body=auto
+//	POST /v1/{chain}/decode-block?network=mainnet&body=auto
+//
+// Request bodies are hex, or raw bytes with Content-Type application/octet-stream.
+// PIN bodies are base64 unless body=auto, see decoder.BodyModeAuto.
+type Server struct {
+	config       *decoder.ParserConfig
+	maxTxSize    int64
+	maxBlockSize int64
+}
+
+// NewServer creates a server
+func NewServer(config *Config) *Server {
+	if config == nil {
+		config = &Config{}
+	}
+	s := &Server{
+		config:       config.Parser,
+		maxTxSize:    config.MaxTxSize,
+		maxBlockSize: config.MaxBlockSize,
+	}
+	if s.config == nil {
+		s.config = decoder.DefaultConfig()
+	}
+	if s.maxTxSize <= 0 {
+		s.maxTxSize = DefaultMaxTxSize
+	}
+	if s.maxBlockSize <= 0 {
+		s.maxBlockSize = DefaultMaxBlockSize
+	}
+	return s
+}
+
+// Handler returns the HTTP handler of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/health", s.health)
+	mux.HandleFunc("/v1/", s.decode)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, codeNotFound, "no such endpoint")
+	})
+	return mux
+}
+
+// apiError is the body of error responses
+type apiError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// healthResponse is the body of health responses
+type healthResponse struct {
+	Status string   `json:"status"`
+	Chains []string `json:"chains"`
+}
+
+// txResponse is the body of decode-tx responses
+type txResponse struct {
+	Chain   string  `json:"chain"`
+	Network string  `json:"network"`
+	Pins    pinList `json:"pins"`
+}
+
+// blockResponse is the body of decode-block responses
+type blockResponse struct {
+	Chain     string    `json:"chain"`
+	Network   string    `json:"network"`
+	BlockHash string    `json:"blockHash"`
+	Timestamp int64     `json:"timestamp"`
+	TxCount   int       `json:"txCount"`
+	Pins      pinList   `json:"pins"`
+	Errors    []txError `json:"errors"`
+}
+
+// txError is a transaction of a block the parser rejected
+type txError struct {
+	TxIndex int    `json:"txIndex"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// health reports the server status and the registered chains
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, codeMethodNotAllowed, "use GET")
+		return
+	}
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok", Chains: decoder.RegisteredChains()})
+}
+
+// decode routes /v1/{chain}/decode-tx and /v1/{chain}/decode-block
+func (s *Server) decode(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+	if len(parts) != 2 || parts[0] == "" || (parts[1] != "decode-tx" && parts[1] != "decode-block") {
+		writeError(w, http.StatusNotFound, codeNotFound, "no such endpoint")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, codeMethodNotAllowed, "use POST")
+		return
+	}
+
+	chain, ok := decoder.CanonicalChainName(parts[0])
+	if !ok {
+		writeError(w, http.StatusNotFound, codeUnknownChain, fmt.Sprintf("%v: %q", decoder.ErrUnknownChain, parts[0]))
+		return
+	}
+	name := r.URL.Query().Get("network")
+	network, err := selectNetwork(chain, name)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeUnknownNetwork, err.Error())
+		return
+	}
+	if parts[1] == "decode-block" {
+		if err := network.Supported(); err != nil {
+			writeError(w, http.StatusBadRequest, codeUnsupportedChain, fmt.Sprintf("decode-block: %v, use decode-tx", err))
+			return
+		}
+	}
+	var mode decoder.BodyMode
+	switch body := r.URL.Query().Get("body"); body {
+	case "", "base64":
+		mode = decoder.BodyModeBase64
+	case "auto":
+		mode = decoder.BodyModeAuto
+	default:
+		writeError(w, http.StatusBadRequest, codeInvalidBodyMode, fmt.Sprintf("unknown body mode %q, expected base64 or auto", body))
+		return
+	}
+	parser, err := decoder.NewParser(chain, s.config)
+	if err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	limit := s.maxTxSize
+	if parts[1] == "decode-block" {
+		limit = s.maxBlockSize
+	}
+	data, ok := readBody(w, r, limit)
+	if !ok {
+		return
+	}
+
+	if name == "" {
+		name = "default"
+	}
+	if parts[1] == "decode-tx" {
+		pins, err := parser.ParseTransaction(data, network.Params)
+		if err != nil {
+			writeDecodeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, txResponse{Chain: chain, Network: name, Pins: pinList{items: pins, mode: mode}})
+		return
+	}
+
+	block, err := blockfile.ParseBlock(data, network)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, codeInvalidBlock, err.Error())
+		return
+	}
+	resp := blockResponse{
+		Chain:     chain,
+		Network:   name,
+		BlockHash: block.Hash,
+		Timestamp: block.Header.Timestamp.Unix(),
+		TxCount:   len(block.Txs),
+		Pins:      pinList{mode: mode},
+		Errors:    []txError{},
+	}
+	// A rejected transaction does not hide the PINs of the rest of the block
+	for _, tx := range block.Txs {
+		pins, err := parser.ParseTransaction(tx.Raw, network.Params)
+		if errors.Is(err, decoder.ErrInvalidChainParams) {
+			writeDecodeError(w, err)
+			return
+		}
+		if err != nil {
+			_, code := decodeErrorStatus(err)
+			resp.Errors = append(resp.Errors, txError{TxIndex: tx.Index, Code: code, Message: err.Error()})
+			continue
+		}
+		for _, pin := range pins {
+			pin.Timestamp = resp.Timestamp
+			resp.Pins.items = append(resp.Pins.items, pin)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// selectNetwork returns the block file network of a chain network
+// An empty name selects the parser's default params
+func selectNetwork(chain, name string) (blockfile.Network, error) {
+	chainNets := networks[chain]
+	network := blockfile.Network{ChainName: chain, AuxPoW: chainNets.auxPoW, NoWitness: chainNets.noWitness}
+	if name == "" {
+		return network, nil
+	}
+	params, ok := chainNets.params[strings.ToLower(name)]
+	if !ok {
+		return network, fmt.Errorf("unknown network %q for chain %s", name, chain)
+	}
+	network.Params = params
+	return network, nil
+}
+
+// readBody reads a hex or binary request body of at most limit bytes
+// It writes the error response and returns false on failure
+func readBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, bool) {
+	binary := false
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil {
+		binary = mediaType == contentTypeBinary
+	}
+	// Hex bodies take two characters per byte
+	max := limit
+	if !binary {
+		max = 2*limit + 2
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, max))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, codeBodyTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit))
+			return nil, false
+		}
+		writeError(w, http.StatusBadRequest, codeInvalidHex, fmt.Sprintf("failed to read request body: %v", err))
+		return nil, false
+	}
+	if !binary {
+		data, err = hex.DecodeString(string(bytes.TrimSpace(data)))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidHex, fmt.Sprintf("invalid hex body: %v", err))
+			return nil, false
+		}
+	}
+	if len(data) == 0 {
+		writeError(w, http.StatusBadRequest, codeEmptyBody, "empty request body")
+		return nil, false
+	}
+	return data, true
+}
+
+// writeDecodeError writes the response of a decoder error
+func writeDecodeError(w http.ResponseWriter, err error) {
+	status, code := decodeErrorStatus(err)
+	writeError(w, status, code, err.Error())
+}
+
+// decodeErrorStatus returns the HTTP status and error code of a parser error
+func decodeErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, decoder.ErrUnknownChain):
+		return http.StatusNotFound, codeUnknownChain
+	case errors.Is(err, decoder.ErrInvalidTransaction):
+		return http.StatusUnprocessableEntity, codeInvalidTx
+	case errors.Is(err, decoder.ErrInvalidChainParams):
+		return http.StatusInternalServerError, codeInvalidParams
+	default:
+		return http.StatusInternalServerError, codeInternal
+	}
+}
+
+// writeError writes an error response
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	var body apiError
+	body.Error.Code = code
+	body.Error.Message = message
+	writeJSON(w, status, body)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	// Escaping would change the bytes of raw JSON bodies
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(body); err != nil {
+		// The status is already sent, the client only gets a truncated body
+		log.Printf("failed to write %d response: %v", status, err)
+	}
+}
+
+// pinList is a JSON array of PINs with their bodies rendered in a body mode
+type pinList struct {
+	items []*decoder.Pin
+	mode  decoder.BodyMode
+}
+
+// MarshalJSON implements json.Marshaler, an empty list encodes as []
+func (l pinList) MarshalJSON() ([]byte, error) {
+	buf := []byte{'['}
+	for i, pin := range l.items {
+		data, err := decoder.MarshalPin(pin, l.mode)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, data...)
+	}
+	return append(buf, ']'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for PINs in any body mode
+func (l *pinList) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	l.items = make([]*decoder.Pin, 0, len(items))
+	for _, item := range items {
+		pin, err := decoder.UnmarshalPin(item)
+		if err != nil {
+			return err
+		}
+		l.items = append(l.items, pin)
+	}
+	return nil
+}
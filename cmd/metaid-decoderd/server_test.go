@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/btc"
+	"github.com/metaid-developers/metaid-script-decoder/internal/testtx"
+)
+
+// post sends a request to the server and decodes the JSON response into v
+func post(t *testing.T, handler http.Handler, method, target, contentType string, body []byte, v interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("%s %s: Content-Type = %q, expected application/json", method, target, got)
+	}
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s: invalid JSON response %q: %v", method, target, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestServer_Health(t *testing.T) {
+	handler := NewServer(nil).Handler()
+	var resp healthResponse
+	if code := post(t, handler, http.MethodGet, "/v1/health", "", nil, &resp); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if resp.Status != "ok" {
+		t.Errorf("Expected status ok, got %q", resp.Status)
+	}
+	for _, chain := range []string{"bch", "bsv", "btc", "doge", "ltc", "mvc"} {
+		if !strings.Contains(strings.Join(resp.Chains, ","), chain) {
+			t.Errorf("Expected chain %s in %v", chain, resp.Chains)
+		}
+	}
+}
+
+func TestServer_DecodeTx(t *testing.T) {
+	handler := NewServer(nil).Handler()
+	tx := testtx.RevealTx(t, testtx.Content)
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        []byte
+		owner       string
+	}{
+		{"hex", "/v1/btc/decode-tx", "text/plain", []byte(hex.EncodeToString(tx) + "\n"), "bc1q"},
+		{"binary", "/v1/bitcoin/decode-tx", "application/octet-stream", tx, "bc1q"},
+		{"regtest", "/v1/btc/decode-tx?network=regtest", "", []byte(hex.EncodeToString(tx)), "bcrt1q"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp txResponse
+			if code := post(t, handler, http.MethodPost, tt.target, tt.contentType, tt.body, &resp); code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", code)
+			}
+			if resp.Chain != "btc" {
+				t.Errorf("Expected chain btc, got %q", resp.Chain)
+			}
+			if len(resp.Pins.items) != 1 {
+				t.Fatalf("Expected 1 PIN, got %d", len(resp.Pins.items))
+			}
+			pin := resp.Pins.items[0]
+			if pin.Path != "/protocols/simplebuzz" || string(pin.ContentBody) != testtx.Content {
+				t.Errorf("Unexpected PIN %s %q", pin.Path, pin.ContentBody)
+			}
+			if !strings.HasPrefix(pin.OwnerAddress, tt.owner) {
+				t.Errorf("Expected owner address starting with %s, got %s", tt.owner, pin.OwnerAddress)
+			}
+		})
+	}
+}
+
+func TestServer_DecodeTx_BodyMode(t *testing.T) {
+	tx := hex.EncodeToString(testtx.RevealTx(t, testtx.Content))
+	for _, tt := range []struct {
+		target   string
+		rendered string
+	}{
+		{"/v1/btc/decode-tx", `"contentBody":"eyJjb250ZW50IjoiaGVsbG8ifQ==","contentBodyEncoding":"base64"`},
+		{"/v1/btc/decode-tx?body=auto", `"contentBody":{"content":"hello"},"contentBodyEncoding":"json"`},
+	} {
+		rec := httptest.NewRecorder()
+		NewServer(nil).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tx)))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), tt.rendered) {
+			t.Errorf("%s: expected %s, got %d %s", tt.target, tt.rendered, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestServer_DecodeTx_NoPins(t *testing.T) {
+	msgTx := wire.NewMsgTx(1)
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 0), []byte{txscript.OP_TRUE}, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_TRUE}))
+
+	rec := httptest.NewRecorder()
+	NewServer(nil).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/btc/decode-tx", strings.NewReader(hex.EncodeToString(testtx.Serialize(t, msgTx)))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"pins":[]`) {
+		t.Errorf("Expected an empty pins array, got %s", rec.Body.String())
+	}
+}
+
+func TestServer_DecodeBlock(t *testing.T) {
+	handler := NewServer(nil).Handler()
+	data := testtx.Block(t, testtx.CoinbaseTx(t, 0x01), testtx.RevealTx(t, testtx.Content))
+
+	var resp blockResponse
+	if code := post(t, handler, http.MethodPost, "/v1/btc/decode-block", "application/octet-stream", data, &resp); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	var header wire.BlockHeader
+	header.Deserialize(bytes.NewReader(data))
+	if resp.BlockHash != header.BlockHash().String() {
+		t.Errorf("Expected block hash %s, got %s", header.BlockHash(), resp.BlockHash)
+	}
+	if resp.TxCount != 2 || len(resp.Pins.items) != 1 {
+		t.Fatalf("Expected 2 transactions and 1 PIN, got %d and %d", resp.TxCount, len(resp.Pins.items))
+	}
+	if resp.Pins.items[0].Timestamp != testtx.BlockTime.Unix() || resp.Timestamp != testtx.BlockTime.Unix() {
+		t.Errorf("Expected the block time on the PIN, got %d", resp.Pins.items[0].Timestamp)
+	}
+	if resp.Errors == nil || len(resp.Errors) != 0 {
+		t.Errorf("Expected an empty errors array, got %v", resp.Errors)
+	}
+}
+
+func init() {
+	decoder.RegisterChain("decoderd-rejecting", func(config *decoder.ParserConfig) decoder.ChainParser {
+		return testtx.RejectingParser{ChainParser: btc.NewBTCParser(config)}
+	})
+}
+
+func TestServer_DecodeBlock_TxErrors(t *testing.T) {
+	var resp blockResponse
+	status := post(t, NewServer(nil).Handler(), http.MethodPost, "/v1/decoderd-rejecting/decode-block", "application/octet-stream", testtx.Block(t, testtx.CoinbaseTx(t, testtx.RejectTag), testtx.RevealTx(t, testtx.Content)), &resp)
+	if status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	// The rejected transaction does not hide the PINs of the rest of the block
+	if len(resp.Pins.items) != 1 {
+		t.Fatalf("Expected 1 PIN, got %d", len(resp.Pins.items))
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].TxIndex != 0 || resp.Errors[0].Code != codeInvalidTx || resp.Errors[0].Message == "" {
+		t.Errorf("Unexpected errors %+v", resp.Errors)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	handler := NewServer(&Config{MaxTxSize: 64}).Handler()
+	tx := hex.EncodeToString(testtx.RevealTx(t, testtx.Content))
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		status      int
+		code        string
+	}{
+		{"unknown endpoint", http.MethodPost, "/v1/btc/decode", "", tx, http.StatusNotFound, codeNotFound},
+		{"root", http.MethodGet, "/", "", "", http.StatusNotFound, codeNotFound},
+		{"method", http.MethodGet, "/v1/btc/decode-tx", "", "", http.StatusMethodNotAllowed, codeMethodNotAllowed},
+		{"health method", http.MethodPost, "/v1/health", "", "", http.StatusMethodNotAllowed, codeMethodNotAllowed},
+		{"unknown chain", http.MethodPost, "/v1/eth/decode-tx", "", tx, http.StatusNotFound, codeUnknownChain},
+		{"unknown network", http.MethodPost, "/v1/btc/decode-tx?network=moonnet", "", tx, http.StatusBadRequest, codeUnknownNetwork},
+		{"network of unlisted chain", http.MethodPost, "/v1/btc-signet/decode-tx?network=mainnet", "", tx, http.StatusBadRequest, codeUnknownNetwork},
+		{"fractal network", http.MethodPost, "/v1/fractal/decode-tx?network=testnet", "", tx, http.StatusBadRequest, codeUnknownNetwork},
+		{"invalid body mode", http.MethodPost, "/v1/btc/decode-tx?body=hex", "", tx, http.StatusBadRequest, codeInvalidBodyMode},
+		{"invalid hex", http.MethodPost, "/v1/btc/decode-tx", "", "zz", http.StatusBadRequest, codeInvalidHex},
+		{"empty body", http.MethodPost, "/v1/btc/decode-tx", "", " \n", http.StatusBadRequest, codeEmptyBody},
+		{"too large", http.MethodPost, "/v1/btc/decode-tx", "application/octet-stream", strings.Repeat("x", 65), http.StatusRequestEntityTooLarge, codeBodyTooLarge},
+		{"too large hex", http.MethodPost, "/v1/btc/decode-tx", "", strings.Repeat("00", 70), http.StatusRequestEntityTooLarge, codeBodyTooLarge},
+		{"invalid transaction", http.MethodPost, "/v1/btc/decode-tx", "", "0100", http.StatusUnprocessableEntity, codeInvalidTx},
+		{"invalid block", http.MethodPost, "/v1/btc/decode-block", "", "0100", http.StatusUnprocessableEntity, codeInvalidBlock},
+		{"invalid tx count", http.MethodPost, "/v1/btc/decode-block", "", hex.EncodeToString(testtx.Block(t)[:80]) + "fd", http.StatusUnprocessableEntity, codeInvalidBlock},
+		{"litecoin block", http.MethodPost, "/v1/litecoin/decode-block?network=mainnet", "", hex.EncodeToString(testtx.Block(t, testtx.RevealTx(t, testtx.Content))), http.StatusBadRequest, codeUnsupportedChain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp apiError
+			status := post(t, handler, tt.method, tt.target, tt.contentType, []byte(tt.body), &resp)
+			if status != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, status)
+			}
+			if resp.Error.Code != tt.code {
+				t.Errorf("Expected error code %s, got %s (%s)", tt.code, resp.Error.Code, resp.Error.Message)
+			}
+			if resp.Error.Message == "" {
+				t.Error("Expected an error message")
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package bch
+
+import (
+	"github.com/bitcoinsv/bsvd/chaincfg"
+)
+
+// Bitcoin Cash shares the BSV-family serialization and legacy version bytes,
+// the networks only differ from bsvd's in their CashAddr prefixes.
+
+// BCHMainNetParams defines the network parameters for the main Bitcoin Cash network.
+var BCHMainNetParams = withCashAddrPrefix(chaincfg.MainNetParams, "bitcoincash")
+
+// BCHTestNetParams defines the network parameters for the test Bitcoin Cash network.
+var BCHTestNetParams = withCashAddrPrefix(chaincfg.TestNet3Params, "bchtest")
+
+// BCHRegTestParams defines the network parameters for the regression test Bitcoin Cash network.
+var BCHRegTestParams = withCashAddrPrefix(chaincfg.RegressionNetParams, "bchreg")
+
+// withCashAddrPrefix returns a copy of params using a CashAddr prefix
+func withCashAddrPrefix(params chaincfg.Params, prefix string) chaincfg.Params {
+	params.CashAddressPrefix = prefix
+	return params
+}
@@ -0,0 +1,101 @@
+package bch
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bitcoinsv/bsvd/chaincfg"
+	"github.com/bitcoinsv/bsvd/txscript"
+	"github.com/bitcoinsv/bsvd/wire"
+	"github.com/bitcoinsv/bsvutil"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/envelope"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/mvc"
+)
+
+func init() {
+	decoder.RegisterChain("bch", func(config *decoder.ParserConfig) decoder.ChainParser {
+		return NewBCHParser(config)
+	}, "bitcoincash")
+}
+
+// BCHParser is the BCH chain parser
+type BCHParser struct {
+	config *decoder.ParserConfig
+}
+
+// NewBCHParser creates a BCH parser
+func NewBCHParser(config *decoder.ParserConfig) *BCHParser {
+	if config == nil {
+		config = decoder.DefaultConfig()
+	}
+	return &BCHParser{
+		config: config,
+	}
+}
+
+// GetChainName returns the chain name
+func (p *BCHParser) GetChainName() string {
+	return "bch"
+}
+
+// ParseTransaction parses a BCH transaction
+func (p *BCHParser) ParseTransaction(txBytes []byte, chainParams interface{}) ([]*decoder.Pin, error) {
+	// Parse chainParams
+	params, ok := chainParams.(*chaincfg.Params)
+	if !ok && chainParams != nil {
+		return nil, fmt.Errorf("%w for BCH, expected *chaincfg.Params", decoder.ErrInvalidChainParams)
+	}
+	if params == nil {
+		params = &BCHMainNetParams
+	}
+
+	// Deserialize transaction
+	msgTx := wire.NewMsgTx(1)
+	if err := msgTx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return nil, fmt.Errorf("%w: %w", decoder.ErrInvalidTransaction, err)
+	}
+
+	txHash := msgTx.TxHash().String()
+
+	// BCH uses the same OP_RETURN format as MVC, a transaction may carry several metaid outputs
+	pins := envelope.MVC.DecodeOpReturns(p.config, "bch", mvc.OpReturnTx(msgTx, txHash, func() []envelope.Output {
+		return outputs(msgTx, params)
+	}))
+
+	return decoder.ProcessPins(p.config, pins), nil
+}
+
+// outputs lists the outputs of a transaction with their addresses and classes
+func outputs(tx *wire.MsgTx, params *chaincfg.Params) []envelope.Output {
+	outs := make([]envelope.Output, len(tx.TxOut))
+	for i, out := range tx.TxOut {
+		outs[i] = envelope.Output{Index: i, Value: out.Value, Address: CashAddress(out.PkScript, params), Class: txscript.GetScriptClass(out.PkScript).String()}
+	}
+	return outs
+}
+
+// CashAddress extracts the CashAddr address of a P2PKH, P2PK or P2SH script
+// The address includes the network prefix, e.g. "bitcoincash:qr..."
+// Returns "" if the script has no address
+func CashAddress(pkScript []byte, params *chaincfg.Params) string {
+	class, addresses, _, err := txscript.ExtractPkScriptAddrs(pkScript, params)
+	if err != nil || len(addresses) == 0 {
+		return ""
+	}
+
+	var address bsvutil.Address
+	switch class {
+	case txscript.PubKeyHashTy, txscript.ScriptHashTy:
+		address = addresses[0]
+	case txscript.PubKeyTy:
+		address, err = bsvutil.NewAddressPubKeyHash(bsvutil.Hash160(addresses[0].ScriptAddress()), params)
+		if err != nil {
+			return ""
+		}
+	default:
+		return ""
+	}
+	return params.CashAddressPrefix + ":" + address.EncodeAddress()
+}
@@ -0,0 +1,139 @@
+package bch
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bitcoinsv/bsvd/chaincfg/chainhash"
+	"github.com/bitcoinsv/bsvd/txscript"
+	"github.com/bitcoinsv/bsvd/wire"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/bsv"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/mvc"
+	"github.com/metaid-developers/metaid-script-decoder/internal/testtx"
+)
+
+func TestNewBCHParser(t *testing.T) {
+	// Test creating parser with default configuration
+	parser := NewBCHParser(nil)
+	if parser == nil {
+		t.Fatal("NewBCHParser returned nil")
+	}
+
+	if parser.config.ProtocolID != "6d6574616964" {
+		t.Errorf("Expected default protocol ID '6d6574616964', got '%s'", parser.config.ProtocolID)
+	}
+}
+
+func TestGetChainName(t *testing.T) {
+	parser := NewBCHParser(nil)
+	if parser.GetChainName() != "bch" {
+		t.Errorf("Expected chain name 'bch', got '%s'", parser.GetChainName())
+	}
+}
+
+func TestParseTransaction_InvalidData(t *testing.T) {
+	parser := NewBCHParser(nil)
+
+	// Test empty data
+	_, err := parser.ParseTransaction([]byte{}, nil)
+	if err == nil {
+		t.Error("Expected error for empty transaction data, got nil")
+	}
+
+	// Test invalid chainParams
+	_, err = parser.ParseTransaction(testtx.OpReturnTx(t, `{"content":"hello from bch"}`), "mainnet")
+	if err == nil {
+		t.Error("Expected error for invalid chainParams, got nil")
+	}
+}
+
+func TestParseTransaction_OpReturn(t *testing.T) {
+	raw := testtx.OpReturnTx(t, `{"content":"hello from bch"}`)
+	parser := NewBCHParser(nil)
+
+	pins, err := parser.ParseTransaction(raw, nil)
+	if err != nil {
+		t.Fatalf("ParseTransaction failed: %v", err)
+	}
+	if len(pins) != 1 {
+		t.Fatalf("Expected 1 pin, got %d", len(pins))
+	}
+
+	pin := pins[0]
+	if pin.ChainName != "bch" {
+		t.Errorf("Expected chain name 'bch', got '%s'", pin.ChainName)
+	}
+	if pin.Id != pin.TxID+"i0" || pin.Vout != 0 || pin.InscriptionTxIndex != 1 {
+		t.Errorf("Unexpected pin id %s / vout %d / index %d", pin.Id, pin.Vout, pin.InscriptionTxIndex)
+	}
+	if pin.Operation != "create" || pin.Path != "/protocols/simplebuzz" {
+		t.Errorf("Unexpected pin fields: %+v", pin)
+	}
+	if pin.OwnerAddress != "bitcoincash:qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqfnhks603" {
+		t.Errorf("Expected CashAddr owner address, got '%s'", pin.OwnerAddress)
+	}
+	if pin.OwnerMetaId == "" || pin.OutputValue != 1 {
+		t.Errorf("Unexpected owner metaid %q / output value %d", pin.OwnerMetaId, pin.OutputValue)
+	}
+}
+
+func TestCashAddress(t *testing.T) {
+	p2pkh := append([]byte{txscript.OP_DUP, txscript.OP_HASH160, 0x14}, append(make([]byte, 20), txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)...)
+	if got := CashAddress(p2pkh, &BCHTestNetParams); got != "bchtest:qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqdpn3jdgd" {
+		t.Errorf("CashAddress(testnet) = %s", got)
+	}
+	if got := CashAddress([]byte{txscript.OP_RETURN}, &BCHMainNetParams); got != "" {
+		t.Errorf("CashAddress(op_return) = %s, want empty", got)
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	parser, err := decoder.NewParser("bitcoincash", nil)
+	if err != nil {
+		t.Fatalf("NewParser failed: %v", err)
+	}
+	if parser.GetChainName() != "bch" {
+		t.Errorf("Expected chain name 'bch', got '%s'", parser.GetChainName())
+	}
+}
+
+func TestParseTransaction_SameOutputsOnEveryOpReturnChain(t *testing.T) {
+	pinScript := func(prefix ...byte) []byte {
+		script, _ := txscript.NewScriptBuilder().
+			AddData([]byte("metaid")).
+			AddData([]byte("create")).
+			AddData([]byte("/a")).
+			AddData([]byte("0")).
+			AddData([]byte("1.0.0")).
+			AddData([]byte("text/plain")).
+			AddData([]byte("x")).
+			Script()
+		return append(prefix, script...)
+	}
+	markerOnly, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_RETURN).AddData([]byte("metaid")).Script()
+
+	msgTx := wire.NewMsgTx(1)
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 0), []byte{txscript.OP_TRUE}))
+	msgTx.AddTxOut(wire.NewTxOut(1, append([]byte{txscript.OP_DUP, txscript.OP_HASH160, 0x14}, append(make([]byte, 20), txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)...)))
+	msgTx.AddTxOut(wire.NewTxOut(0, pinScript(txscript.OP_RETURN)))                    // nonstandard, carries a PIN
+	msgTx.AddTxOut(wire.NewTxOut(0, markerOnly))                                       // nulldata, too short for a PIN
+	msgTx.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))                       // nulldata
+	msgTx.AddTxOut(wire.NewTxOut(0, pinScript(txscript.OP_FALSE, txscript.OP_RETURN))) // nonstandard, carries a PIN
+	msgTx.AddTxOut(wire.NewTxOut(0, pinScript()))                                      // nonstandard, no OP_RETURN
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	for _, parser := range []decoder.ChainParser{mvc.NewMVCParser(nil), bsv.NewBSVParser(nil), NewBCHParser(nil)} {
+		pins, err := parser.ParseTransaction(buf.Bytes(), nil)
+		if err != nil {
+			t.Fatalf("%s: ParseTransaction failed: %v", parser.GetChainName(), err)
+		}
+		if len(pins) != 2 || pins[0].InscriptionTxIndex != 1 || pins[1].InscriptionTxIndex != 4 {
+			t.Errorf("%s: expected PINs in outputs 1 and 4, got %d PINs", parser.GetChainName(), len(pins))
+		}
+	}
+}
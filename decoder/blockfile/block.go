@@ -0,0 +1,119 @@
+package blockfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	bsvwire "github.com/bitcoinsv/bsvd/wire"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// auxPoWVersion is the block version bit flagging merged mining data
+const auxPoWVersion = 1 << 8
+
+// Tx is a transaction of a block
+type Tx struct {
+	Index  int    // Position in the block
+	Offset int64  // Offset of the transaction in the block file
+	Raw    []byte // Serialized transaction
+}
+
+// Block is a block read from a block file
+type Block struct {
+	File   string           // Block file name, e.g. blk00042.dat
+	Offset int64            // Offset of the block record, at its magic, in the file
+	Next   Checkpoint       // Position right after the block, to resume from
+	Header wire.BlockHeader // Block header
+	Hash   string           // Block hash
+	Txs    []Tx             // Transactions
+	Errors []*TxError       // Transactions the parser rejected, set by Scanner.Pins
+}
+
+// ParseBlock splits a serialized block, such as the result of getblock with verbosity 0,
+// into its header and transactions. Tx offsets are relative to the start of data.
+// It returns ErrUnsupportedChain for Litecoin.
+func ParseBlock(data []byte, network Network) (*Block, error) {
+	if err := network.Supported(); err != nil {
+		return nil, err
+	}
+	return parseBlock(data, 0, network)
+}
+
+// parseBlock splits a block into its header and transactions
+// offset is the position of the block data in the file
+func parseBlock(data []byte, offset int64, network Network) (*Block, error) {
+	r := bytes.NewReader(data)
+	block := &Block{}
+	if err := block.Header.Deserialize(r); err != nil {
+		return nil, fmt.Errorf("failed to read block header: %w", err)
+	}
+	block.Hash = block.Header.BlockHash().String()
+
+	if network.AuxPoW && block.Header.Version&auxPoWVersion != 0 {
+		if err := skipAuxPoW(r); err != nil {
+			return nil, fmt.Errorf("failed to read merged mining data: %w", err)
+		}
+	}
+
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction count: %w", err)
+	}
+	// Every transaction takes at least 10 bytes
+	if count > uint64(r.Len()/10) {
+		return nil, fmt.Errorf("invalid transaction count %d", count)
+	}
+
+	block.Txs = make([]Tx, 0, count)
+	for i := 0; i < int(count); i++ {
+		start := len(data) - r.Len()
+		if err := readTx(r, network); err != nil {
+			return nil, fmt.Errorf("failed to read transaction %d: %w", i, err)
+		}
+		end := len(data) - r.Len()
+		block.Txs = append(block.Txs, Tx{Index: i, Offset: offset + int64(start), Raw: data[start:end]})
+	}
+	return block, nil
+}
+
+// readTx reads one transaction in the wire format of the network
+func readTx(r io.Reader, network Network) error {
+	if network.NoWitness {
+		return new(bsvwire.MsgTx).Deserialize(r)
+	}
+	return new(wire.MsgTx).Deserialize(r)
+}
+
+// skipAuxPoW skips the merged mining data following a block header:
+// the parent coinbase transaction, the parent block hash, the coinbase and
+// chain merkle branches and the parent block header
+func skipAuxPoW(r *bytes.Reader) error {
+	if err := new(wire.MsgTx).DeserializeNoWitness(r); err != nil {
+		return err
+	}
+	if _, err := r.Seek(32, io.SeekCurrent); err != nil {
+		return err
+	}
+	for i := 0; i < 2; i++ {
+		n, err := wire.ReadVarInt(r, 0)
+		if err != nil {
+			return err
+		}
+		if n > uint64(r.Len()/32) {
+			return fmt.Errorf("invalid merkle branch length %d", n)
+		}
+		// Branch hashes and the side mask
+		if _, err := r.Seek(int64(n)*32+4, io.SeekCurrent); err != nil {
+			return err
+		}
+	}
+	var parent wire.BlockHeader
+	return parent.Deserialize(r)
+}
+
+// readUint32 reads a little endian uint32
+func readUint32(b []byte) uint32 {
+	return binary.LittleEndian.Uint32(b)
+}
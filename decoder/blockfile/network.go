@@ -0,0 +1,68 @@
+// Package blockfile reads the raw block files (blk*.dat) of Bitcoin Core, Dogecoin Core
+// and MVC nodes and decodes the PINs of their transactions, for offline backfills.
+//
+// A block file is a sequence of records:
+//
+//	<network magic (4)> <block size (4, little endian)> <block>
+//
+// Records are in the order the node received the blocks, not in height order.
+//
+// Litecoin is not supported: its blocks may carry MWEB (MimbleWimble Extension Block) data,
+// which the btcd block format cannot read. Litecoin transactions can still be decoded one by
+// one with the ltc parser.
+package blockfile
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder/btc"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/doge"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/mvc"
+)
+
+// Network describes the block files of a chain network
+type Network struct {
+	ChainName string      // Registered chain name of the parser, see decoder.NewParser
+	Params    interface{} // Chain params passed to the parser
+	Magic     uint32      // Network magic as a little endian uint32, 0 to take it from the first record
+	AuxPoW    bool        // Block headers may be followed by merged mining data, as on Dogecoin
+	NoWitness bool        // Transactions use the legacy format of the BSV family, as on MVC
+}
+
+// Network presets, the magic comes from the chain params
+var (
+	BTCMainNet  = Network{ChainName: "btc", Params: &chaincfg.MainNetParams, Magic: uint32(chaincfg.MainNetParams.Net)}
+	BTCTestNet3 = Network{ChainName: "btc", Params: &chaincfg.TestNet3Params, Magic: uint32(chaincfg.TestNet3Params.Net)}
+	BTCTestNet4 = Network{ChainName: "btc-testnet4", Params: &btc.TestNet4Params, Magic: uint32(btc.TestNet4Params.Net)}
+	BTCSigNet   = Network{ChainName: "btc-signet", Params: &chaincfg.SigNetParams, Magic: uint32(chaincfg.SigNetParams.Net)}
+	BTCRegTest  = Network{ChainName: "btc", Params: &chaincfg.RegressionNetParams, Magic: uint32(chaincfg.RegressionNetParams.Net)}
+
+	DOGEMainNet = Network{ChainName: "doge", Params: &doge.DogeMainNetParams, Magic: uint32(doge.DogeMainNetParams.Net), AuxPoW: true}
+	DOGETestNet = Network{ChainName: "doge", Params: &doge.DogeTestNetParams, Magic: uint32(doge.DogeTestNetParams.Net), AuxPoW: true}
+	DOGERegTest = Network{ChainName: "doge", Params: &doge.DogeRegTestParams, Magic: uint32(doge.DogeRegTestParams.Net), AuxPoW: true}
+
+	// The MVC params only carry address fields, so the magic is taken from the first record
+	MVCMainNet = Network{ChainName: "mvc", Params: &mvc.MVCMainNetParams, NoWitness: true}
+	MVCTestNet = Network{ChainName: "mvc", Params: &mvc.MVCTestNetParams, NoWitness: true}
+)
+
+// ErrUnsupportedChain is returned for a network whose blocks cannot be read
+var ErrUnsupportedChain = errors.New("blockfile: unsupported chain")
+
+// unsupportedChains are the chain names and aliases of networks whose blocks cannot be read
+var unsupportedChains = map[string]string{
+	"ltc":      "Litecoin MWEB blocks cannot be read",
+	"litecoin": "Litecoin MWEB blocks cannot be read",
+}
+
+// Supported returns ErrUnsupportedChain if the blocks of the network cannot be read
+func (n Network) Supported() error {
+	if reason, ok := unsupportedChains[strings.ToLower(n.ChainName)]; ok {
+		return fmt.Errorf("%w %q: %s", ErrUnsupportedChain, n.ChainName, reason)
+	}
+	return nil
+}
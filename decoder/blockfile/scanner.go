@@ -0,0 +1,193 @@
+package blockfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+// Checkpoint is a position in the block files to resume scanning from
+type Checkpoint struct {
+	File   string `json:"file"`   // Block file name, empty to start with the first file
+	Offset int64  `json:"offset"` // Offset in the file
+}
+
+// Record is a PIN found in a block file
+type Record struct {
+	Pin         *decoder.Pin
+	File        string // Block file name
+	Offset      int64  // Offset of the transaction in the file
+	BlockOffset int64  // Offset of the block record in the file
+	BlockHash   string
+	TxIndex     int // Position of the transaction in the block
+}
+
+// TxError is a transaction of a block the parser rejected
+type TxError struct {
+	File    string // Block file name
+	Offset  int64  // Offset of the transaction in the file
+	TxIndex int    // Position of the transaction in the block
+	Err     error
+}
+
+func (e *TxError) Error() string {
+	return fmt.Sprintf("%s: transaction %d at offset %d: %v", e.File, e.TxIndex, e.Offset, e.Err)
+}
+
+func (e *TxError) Unwrap() error {
+	return e.Err
+}
+
+// Scanner reads the block files of a node directory
+type Scanner struct {
+	dir     string
+	network Network
+	parser  decoder.ChainParser
+	xorKey  []byte
+}
+
+// NewScanner creates a scanner of the block files in dir, such as ~/.bitcoin/blocks
+// PINs are decoded by the parser registered for network.ChainName, created with config.
+// If dir holds the xor.dat obfuscation key of Bitcoin Core 28+, the files are deobfuscated with it.
+func NewScanner(dir string, network Network, config *decoder.ParserConfig) (*Scanner, error) {
+	if err := network.Supported(); err != nil {
+		return nil, err
+	}
+	parser, err := decoder.NewParser(network.ChainName, config)
+	if err != nil {
+		return nil, err
+	}
+	key, err := os.ReadFile(filepath.Join(dir, "xor.dat"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read obfuscation key: %w", err)
+	}
+	if len(bytes.Trim(key, "\x00")) == 0 {
+		key = nil
+	}
+	return &Scanner{
+		dir:     dir,
+		network: network,
+		parser:  parser,
+		xorKey:  key,
+	}, nil
+}
+
+// Files lists the block file names in order
+func (s *Scanner) Files() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "blk*.dat"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = filepath.Base(path)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Blocks calls fn for each block from the checkpoint on, in file order
+// A file ends at its zero filled preallocated tail or at a truncated record, such as a block being written
+func (s *Scanner) Blocks(from Checkpoint, fn func(*Block) error) error {
+	files, err := s.Files()
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		if name < from.File {
+			continue
+		}
+		var offset int64
+		if name == from.File {
+			offset = from.Offset
+		}
+		if err := s.scanFile(name, offset, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pins calls fn with the PINs of each block from the checkpoint on
+// fn is called for blocks without PINs too, so block.Next can always be saved as the checkpoint.
+// Pin.Timestamp is set to the block time. A transaction the parser rejects does not stop the
+// scan, it is added to block.Errors instead.
+func (s *Scanner) Pins(from Checkpoint, fn func(block *Block, records []*Record) error) error {
+	return s.Blocks(from, func(block *Block) error {
+		var records []*Record
+		for _, tx := range block.Txs {
+			pins, err := s.parser.ParseTransaction(tx.Raw, s.network.Params)
+			if err != nil {
+				block.Errors = append(block.Errors, &TxError{File: block.File, Offset: tx.Offset, TxIndex: tx.Index, Err: err})
+				continue
+			}
+			for _, pin := range pins {
+				pin.Timestamp = block.Header.Timestamp.Unix()
+				records = append(records, &Record{
+					Pin:         pin,
+					File:        block.File,
+					Offset:      tx.Offset,
+					BlockOffset: block.Offset,
+					BlockHash:   block.Hash,
+					TxIndex:     tx.Index,
+				})
+			}
+		}
+		return fn(block, records)
+	})
+}
+
+// scanFile calls fn for each block of a file from offset on
+func (s *Scanner) scanFile(name string, offset int64, fn func(*Block) error) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return err
+	}
+	s.deobfuscate(data)
+
+	for offset+8 <= int64(len(data)) {
+		magic := readUint32(data[offset:])
+		if magic == 0 {
+			break
+		}
+		if s.network.Magic == 0 {
+			s.network.Magic = magic
+		}
+		if magic != s.network.Magic {
+			return fmt.Errorf("%s: unexpected magic %08x at offset %d", name, magic, offset)
+		}
+
+		start := offset + 8
+		end := start + int64(readUint32(data[offset+4:]))
+		if end > int64(len(data)) {
+			break
+		}
+		block, err := parseBlock(data[start:end], start, s.network)
+		if err != nil {
+			return fmt.Errorf("%s: block at offset %d: %w", name, offset, err)
+		}
+		block.File = name
+		block.Offset = offset
+		block.Next = Checkpoint{File: name, Offset: end}
+		if err := fn(block); err != nil {
+			return err
+		}
+		offset = end
+	}
+	return nil
+}
+
+// deobfuscate reverts the xor obfuscation of a whole block file
+func (s *Scanner) deobfuscate(data []byte) {
+	if s.xorKey == nil {
+		return
+	}
+	for i := range data {
+		data[i] ^= s.xorKey[i%len(s.xorKey)]
+	}
+}
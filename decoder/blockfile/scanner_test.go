@@ -0,0 +1,273 @@
+package blockfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bsvchainhash "github.com/bitcoinsv/bsvd/chaincfg/chainhash"
+	bsvtxscript "github.com/bitcoinsv/bsvd/txscript"
+	bsvwire "github.com/bitcoinsv/bsvd/wire"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/btc"
+	"github.com/metaid-developers/metaid-script-decoder/internal/testtx"
+)
+
+// dogeTxHex is a DOGE transaction carrying a direct ScriptSig PIN
+const dogeTxHex = "02000000039c76656bafa0fb8ecb08c2628ab0602e58d5c41f3f676c80461405c4c976aa2800000000be066d6574616964066372656174650a746578742f706c61696e013005302e302e31106170706c69636174696f6e2f6a736f6e17446f6765206d657461696420696e736372697074696f6e47304402203f685bd7a2062f7726623381246af3f4d40ef268d571ed067d476c54250770ad022043a9d79b216cdf1b54885fcaa9b0eb8073e8eab0cf3d180f09e2361355233c9c012b2102dc3647d7dbeaf9223800276a924c9d4a07c886417e0c65d9d2c92eb080356afcad7575757575757551ffffffffd512c8c144c46d4124682f31ac7961af52a78db4c85bd44be985d437c54eee98010000006a4730440220294d502896262b31a3ed29c21a4e32f54319c858e5f610060c3b98823661d23a02203b45a72495b8108c0fdc5549f38b2eecb377c8bf8bbc7145009e545e2c09a6970121029276cc28460500aae93fa8fa619e25ca98b5689b381ba1d730e9441b04fb6ceeffffffffbac3198cfb90f5650c201cb7c51cb0c49cf30cf8177295e58752413675e7e915010000006b483045022100fecb40bfb3059d6597b93630f9a292092b7ad8331d7465aef719e6525e70ef6802205fda5e8ca7c825ef2ab6f3e710aea07c2bfe4e835a897c9c6a07b092e68ebac00121029276cc28460500aae93fa8fa619e25ca98b5689b381ba1d730e9441b04fb6ceeffffffff02a0860100000000001976a9147748321f517ae351be891b6fe702563293672b4f88ac200c8201000000001976a9147748321f517ae351be891b6fe702563293672b4f88ac00000000"
+
+// mvcTx builds an MVC transaction with a metaid OP_RETURN output
+func mvcTx(t *testing.T) []byte {
+	t.Helper()
+	script, err := bsvtxscript.NewScriptBuilder().
+		AddOp(bsvtxscript.OP_FALSE).
+		AddOp(bsvtxscript.OP_RETURN).
+		AddData([]byte("metaid")).
+		AddData([]byte("create")).
+		AddData([]byte("/protocols/simplebuzz")).
+		AddData([]byte("0")).
+		AddData([]byte("1.0.0")).
+		AddData([]byte("application/json")).
+		AddData([]byte(`{"content":"mvc"}`)).
+		Script()
+	if err != nil {
+		t.Fatalf("Failed to build script: %v", err)
+	}
+	msgTx := bsvwire.NewMsgTx(2)
+	msgTx.AddTxIn(bsvwire.NewTxIn(bsvwire.NewOutPoint(&bsvchainhash.Hash{0x01}, 0), []byte{bsvtxscript.OP_TRUE}))
+	msgTx.AddTxOut(bsvwire.NewTxOut(0, script))
+	return testtx.Serialize(t, msgTx)
+}
+
+// block builds a block of txs, with merged mining data if auxPoW is set
+// Unlike testtx.Block, the nonce sets the block time so that blocks have distinct hashes.
+func block(t *testing.T, nonce uint32, auxPoW bool, txs ...[]byte) []byte {
+	t.Helper()
+	header := wire.NewBlockHeader(1, &chainhash.Hash{}, &chainhash.Hash{}, 0x1d00ffff, nonce)
+	header.Timestamp = time.Unix(1700000000+int64(nonce), 0)
+	if auxPoW {
+		header.Version |= auxPoWVersion
+	}
+	var buf bytes.Buffer
+	if err := header.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize header: %v", err)
+	}
+	if auxPoW {
+		buf.Write(testtx.CoinbaseTx(t, 0xaa))
+		buf.Write(make([]byte, 32)) // parent block hash
+		for i := 0; i < 2; i++ {
+			wire.WriteVarInt(&buf, 0, 1)
+			buf.Write(bytes.Repeat([]byte{0x11}, 32))
+			buf.Write(make([]byte, 4))
+		}
+		parent := wire.NewBlockHeader(2, &chainhash.Hash{}, &chainhash.Hash{}, 0x1d00ffff, 7)
+		parent.Serialize(&buf)
+	}
+	wire.WriteVarInt(&buf, 0, uint64(len(txs)))
+	for _, tx := range txs {
+		buf.Write(tx)
+	}
+	return buf.Bytes()
+}
+
+// record frames a block as a block file record
+func record(magic uint32, blockData []byte) []byte {
+	out := binary.LittleEndian.AppendUint32(nil, magic)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(blockData)))
+	return append(out, blockData...)
+}
+
+// writeFile writes a block file
+func writeFile(t *testing.T, dir, name string, parts ...[]byte) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), bytes.Join(parts, nil), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanner_BTC(t *testing.T) {
+	dir := t.TempDir()
+	magic := uint32(chaincfg.MainNetParams.Net)
+	first := block(t, 1, false, testtx.CoinbaseTx(t, 1))
+	second := block(t, 2, false, testtx.CoinbaseTx(t, 2), testtx.RevealTx(t, testtx.Content))
+	third := block(t, 3, false, testtx.CoinbaseTx(t, 3), testtx.RevealTx(t, testtx.Content))
+	// The second file ends with a truncated record and a zero filled tail
+	writeFile(t, dir, "blk00000.dat", record(magic, first), record(magic, second))
+	writeFile(t, dir, "blk00001.dat", record(magic, third), record(magic, second)[:50])
+	writeFile(t, dir, "blk00002.dat", make([]byte, 64))
+
+	scanner, err := NewScanner(dir, BTCMainNet, nil)
+	if err != nil {
+		t.Fatalf("NewScanner failed: %v", err)
+	}
+	var blocks []*Block
+	var records []*Record
+	err = scanner.Pins(Checkpoint{}, func(block *Block, found []*Record) error {
+		blocks = append(blocks, block)
+		records = append(records, found...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Pins failed: %v", err)
+	}
+	if len(blocks) != 3 || len(records) != 2 {
+		t.Fatalf("Expected 3 blocks and 2 PINs, got %d and %d", len(blocks), len(records))
+	}
+
+	// Provenance points at the transaction in the file
+	rec := records[0]
+	data, _ := os.ReadFile(filepath.Join(dir, rec.File))
+	reveal := testtx.RevealTx(t, testtx.Content)
+	if rec.File != "blk00000.dat" || rec.TxIndex != 1 || rec.BlockHash != blocks[1].Hash {
+		t.Errorf("Unexpected provenance %+v", rec)
+	}
+	if !bytes.Equal(data[rec.Offset:rec.Offset+int64(len(reveal))], reveal) {
+		t.Errorf("Offset %d does not point at the transaction", rec.Offset)
+	}
+	if rec.BlockOffset != int64(len(record(magic, first))) || rec.Pin.Timestamp != 1700000002 || rec.Pin.Path != "/protocols/simplebuzz" {
+		t.Errorf("Unexpected block offset %d, timestamp %d or pin %+v", rec.BlockOffset, rec.Pin.Timestamp, rec.Pin)
+	}
+
+	// Resuming from a checkpoint skips the blocks before it
+	var resumed []string
+	err = scanner.Blocks(blocks[0].Next, func(block *Block) error {
+		resumed = append(resumed, block.Hash)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Blocks failed: %v", err)
+	}
+	if len(resumed) != 2 || resumed[0] != blocks[1].Hash || resumed[1] != blocks[2].Hash {
+		t.Errorf("Unexpected resumed blocks %v", resumed)
+	}
+	if blocks[2].Next != (Checkpoint{File: "blk00001.dat", Offset: int64(len(record(magic, third)))}) {
+		t.Errorf("Unexpected checkpoint %+v", blocks[2].Next)
+	}
+}
+
+func init() {
+	decoder.RegisterChain("blockfile-rejecting", func(config *decoder.ParserConfig) decoder.ChainParser {
+		return testtx.RejectingParser{ChainParser: btc.NewBTCParser(config)}
+	})
+}
+
+func TestScanner_TxErrors(t *testing.T) {
+	dir := t.TempDir()
+	magic := uint32(chaincfg.MainNetParams.Net)
+	bad := block(t, 1, false, testtx.CoinbaseTx(t, testtx.RejectTag), testtx.RevealTx(t, testtx.Content))
+	good := block(t, 2, false, testtx.CoinbaseTx(t, 2), testtx.RevealTx(t, testtx.Content))
+	writeFile(t, dir, "blk00000.dat", record(magic, bad), record(magic, good))
+
+	network := BTCMainNet
+	network.ChainName = "blockfile-rejecting"
+	scanner, err := NewScanner(dir, network, nil)
+	if err != nil {
+		t.Fatalf("NewScanner failed: %v", err)
+	}
+	var blocks []*Block
+	var records []*Record
+	err = scanner.Pins(Checkpoint{}, func(block *Block, found []*Record) error {
+		blocks = append(blocks, block)
+		records = append(records, found...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Pins failed: %v", err)
+	}
+	// The rejected transaction does not hide the PINs of its block or of the next one
+	if len(blocks) != 2 || len(records) != 2 {
+		t.Fatalf("Expected 2 blocks and 2 PINs, got %d and %d", len(blocks), len(records))
+	}
+	if len(blocks[0].Errors) != 1 || len(blocks[1].Errors) != 0 {
+		t.Fatalf("Unexpected errors %v and %v", blocks[0].Errors, blocks[1].Errors)
+	}
+	txErr := blocks[0].Errors[0]
+	if txErr.File != "blk00000.dat" || txErr.TxIndex != 0 || txErr.Offset != blocks[0].Txs[0].Offset || !errors.Is(txErr, decoder.ErrInvalidTransaction) {
+		t.Errorf("Unexpected error %+v", txErr)
+	}
+}
+
+func TestScanner_Litecoin(t *testing.T) {
+	network := Network{ChainName: "litecoin"}
+	if _, err := NewScanner(t.TempDir(), network, nil); !errors.Is(err, ErrUnsupportedChain) {
+		t.Errorf("NewScanner: expected ErrUnsupportedChain, got %v", err)
+	}
+	if _, err := ParseBlock(block(t, 1, false, testtx.CoinbaseTx(t, 1)), Network{ChainName: "LTC"}); !errors.Is(err, ErrUnsupportedChain) {
+		t.Errorf("ParseBlock: expected ErrUnsupportedChain, got %v", err)
+	}
+}
+
+func TestScanner_WrongMagic(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "blk00000.dat", record(uint32(chaincfg.TestNet3Params.Net), block(t, 1, false, testtx.CoinbaseTx(t, 1))))
+	scanner, err := NewScanner(dir, BTCMainNet, nil)
+	if err != nil {
+		t.Fatalf("NewScanner failed: %v", err)
+	}
+	if err := scanner.Blocks(Checkpoint{}, func(*Block) error { return nil }); err == nil {
+		t.Error("Expected an error for a record of another network")
+	}
+}
+
+func TestScanner_DOGEAuxPoW(t *testing.T) {
+	dir := t.TempDir()
+	dogeTx, _ := hex.DecodeString(dogeTxHex)
+	magic := DOGEMainNet.Magic
+	if !bytes.Equal(binary.LittleEndian.AppendUint32(nil, magic), []byte{0xc0, 0xc0, 0xc0, 0xc0}) {
+		t.Fatalf("Unexpected DOGE magic %08x", magic)
+	}
+	writeFile(t, dir, "blk00000.dat", record(magic, block(t, 1, true, testtx.CoinbaseTx(t, 1), dogeTx)))
+
+	scanner, err := NewScanner(dir, DOGEMainNet, nil)
+	if err != nil {
+		t.Fatalf("NewScanner failed: %v", err)
+	}
+	var records []*Record
+	err = scanner.Pins(Checkpoint{}, func(_ *Block, found []*Record) error {
+		records = append(records, found...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Pins failed: %v", err)
+	}
+	if len(records) != 1 || records[0].Pin.ChainName != "doge" || records[0].TxIndex != 1 {
+		t.Fatalf("Expected 1 DOGE PIN in the second transaction, got %d", len(records))
+	}
+}
+
+func TestScanner_MVCObfuscated(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+	data := record(0xe8f3e1e3, block(t, 1, false, testtx.CoinbaseTx(t, 1), mvcTx(t)))
+	for i := range data {
+		data[i] ^= key[i%len(key)]
+	}
+	writeFile(t, dir, "blk00000.dat", data)
+	writeFile(t, dir, "xor.dat", key)
+
+	// The MVC magic is taken from the first record
+	scanner, err := NewScanner(dir, MVCMainNet, nil)
+	if err != nil {
+		t.Fatalf("NewScanner failed: %v", err)
+	}
+	var records []*Record
+	err = scanner.Pins(Checkpoint{}, func(_ *Block, found []*Record) error {
+		records = append(records, found...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Pins failed: %v", err)
+	}
+	if len(records) != 1 || string(records[0].Pin.ContentBody) != `{"content":"mvc"}` {
+		t.Fatalf("Expected the MVC PIN, got %d records", len(records))
+	}
+}
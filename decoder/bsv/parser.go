@@ -0,0 +1,77 @@
+package bsv
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bitcoinsv/bsvd/chaincfg"
+	"github.com/bitcoinsv/bsvd/txscript"
+	"github.com/bitcoinsv/bsvd/wire"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/envelope"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/mvc"
+)
+
+func init() {
+	decoder.RegisterChain("bsv", func(config *decoder.ParserConfig) decoder.ChainParser {
+		return NewBSVParser(config)
+	}, "bitcoinsv")
+}
+
+// BSVParser is the BSV chain parser
+type BSVParser struct {
+	config *decoder.ParserConfig
+}
+
+// NewBSVParser creates a BSV parser
+func NewBSVParser(config *decoder.ParserConfig) *BSVParser {
+	if config == nil {
+		config = decoder.DefaultConfig()
+	}
+	return &BSVParser{
+		config: config,
+	}
+}
+
+// GetChainName returns the chain name
+func (p *BSVParser) GetChainName() string {
+	return "bsv"
+}
+
+// ParseTransaction parses a BSV transaction
+func (p *BSVParser) ParseTransaction(txBytes []byte, chainParams interface{}) ([]*decoder.Pin, error) {
+	// Parse chainParams
+	params, ok := chainParams.(*chaincfg.Params)
+	if !ok && chainParams != nil {
+		return nil, fmt.Errorf("%w for BSV, expected *chaincfg.Params", decoder.ErrInvalidChainParams)
+	}
+	if params == nil {
+		params = &chaincfg.MainNetParams
+	}
+
+	// Deserialize transaction
+	msgTx := wire.NewMsgTx(1)
+	if err := msgTx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return nil, fmt.Errorf("%w: %w", decoder.ErrInvalidTransaction, err)
+	}
+
+	txHash := msgTx.TxHash().String()
+
+	// BSV uses the same OP_RETURN format as MVC, a transaction may carry several metaid outputs
+	pins := envelope.MVC.DecodeOpReturns(p.config, "bsv", mvc.OpReturnTx(msgTx, txHash, func() []envelope.Output {
+		return outputs(msgTx, params)
+	}))
+
+	return decoder.ProcessPins(p.config, pins), nil
+}
+
+// outputs lists the outputs of a transaction with their addresses and classes
+// BSV dropped CashAddr, so addresses use the legacy version bytes of params
+func outputs(tx *wire.MsgTx, params *chaincfg.Params) []envelope.Output {
+	outs := make([]envelope.Output, len(tx.TxOut))
+	for i, out := range tx.TxOut {
+		outs[i] = envelope.Output{Index: i, Value: out.Value, Address: mvc.LegacyAddress(out.PkScript, params), Class: txscript.GetScriptClass(out.PkScript).String()}
+	}
+	return outs
+}
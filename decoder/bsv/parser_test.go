@@ -0,0 +1,83 @@
+package bsv
+
+import (
+	"testing"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/internal/testtx"
+)
+
+func TestNewBSVParser(t *testing.T) {
+	// Test creating parser with default configuration
+	parser := NewBSVParser(nil)
+	if parser == nil {
+		t.Fatal("NewBSVParser returned nil")
+	}
+
+	if parser.config.ProtocolID != "6d6574616964" {
+		t.Errorf("Expected default protocol ID '6d6574616964', got '%s'", parser.config.ProtocolID)
+	}
+}
+
+func TestGetChainName(t *testing.T) {
+	parser := NewBSVParser(nil)
+	if parser.GetChainName() != "bsv" {
+		t.Errorf("Expected chain name 'bsv', got '%s'", parser.GetChainName())
+	}
+}
+
+func TestParseTransaction_InvalidData(t *testing.T) {
+	parser := NewBSVParser(nil)
+
+	// Test empty data
+	_, err := parser.ParseTransaction([]byte{}, nil)
+	if err == nil {
+		t.Error("Expected error for empty transaction data, got nil")
+	}
+
+	// Test invalid chainParams
+	_, err = parser.ParseTransaction(testtx.OpReturnTx(t, `{"content":"hello from bsv"}`), "mainnet")
+	if err == nil {
+		t.Error("Expected error for invalid chainParams, got nil")
+	}
+}
+
+func TestParseTransaction_OpReturn(t *testing.T) {
+	raw := testtx.OpReturnTx(t, `{"content":"hello from bsv"}`)
+	parser := NewBSVParser(nil)
+
+	pins, err := parser.ParseTransaction(raw, nil)
+	if err != nil {
+		t.Fatalf("ParseTransaction failed: %v", err)
+	}
+	if len(pins) != 1 {
+		t.Fatalf("Expected 1 pin, got %d", len(pins))
+	}
+
+	pin := pins[0]
+	if pin.ChainName != "bsv" {
+		t.Errorf("Expected chain name 'bsv', got '%s'", pin.ChainName)
+	}
+	if pin.Id != pin.TxID+"i0" || pin.Vout != 0 || pin.InscriptionTxIndex != 1 {
+		t.Errorf("Unexpected pin id %s / vout %d / index %d", pin.Id, pin.Vout, pin.InscriptionTxIndex)
+	}
+	if pin.Operation != "create" || pin.Path != "/protocols/simplebuzz" {
+		t.Errorf("Unexpected pin fields: %+v", pin)
+	}
+	if pin.OwnerAddress != "1111111111111111111114oLvT2" {
+		t.Errorf("Expected legacy owner address, got '%s'", pin.OwnerAddress)
+	}
+	if pin.OwnerMetaId == "" || pin.OutputValue != 1 {
+		t.Errorf("Unexpected owner metaid %q / output value %d", pin.OwnerMetaId, pin.OutputValue)
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	parser, err := decoder.NewParser("bitcoinsv", nil)
+	if err != nil {
+		t.Fatalf("NewParser failed: %v", err)
+	}
+	if parser.GetChainName() != "bsv" {
+		t.Errorf("Expected chain name 'bsv', got '%s'", parser.GetChainName())
+	}
+}
@@ -0,0 +1,95 @@
+package btc
+
+import (
+	"math/big"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TestNet4Params defines the network parameters for the BIP94 test network (testnet4).
+var TestNet4Params = chaincfg.Params{
+	Name:        "testnet4",
+	Net:         wire.BitcoinNet(0x283f161c), // TestNet4 magic
+	DefaultPort: "48333",
+	DNSSeeds: []chaincfg.DNSSeed{
+		{Host: "seed.testnet4.bitcoin.sprovoost.nl", HasFiltering: true},
+		{Host: "seed.testnet4.wiz.biz", HasFiltering: true},
+	},
+	GenesisHash:      newHashFromStr("00000000da84f2bafbbc53dee25a72ae507ff4914b867c565be350b0da8bf043"),
+	PowLimit:         newBigIntFromHex("00000000ffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+	CoinbaseMaturity: 100,
+	PubKeyHashAddrID: 0x6f, // starts with m or n
+	ScriptHashAddrID: 0xc4, // starts with 2
+	PrivateKeyID:     0xef, // starts with 9 or c
+	Bech32HRPSegwit:  "tb", // starts with tb1
+	HDCoinType:       1,
+}
+
+// FractalMainNetParams defines the network parameters for the Fractal Bitcoin main network.
+// Fractal keeps Bitcoin's address encodings, so only the address fields are set; the params
+// cover owner addresses only. Net is 0, so they cannot match the magic of block files or node
+// messages, and no blockfile or decoderd network preset uses them.
+var FractalMainNetParams = chaincfg.Params{
+	Name:             "fractal-mainnet",
+	CoinbaseMaturity: 100,
+	PubKeyHashAddrID: 0x00, // starts with 1
+	ScriptHashAddrID: 0x05, // starts with 3
+	PrivateKeyID:     0x80, // starts with 5 (uncompressed) or K (compressed)
+	Bech32HRPSegwit:  "bc", // starts with bc1
+	HDCoinType:       0,
+}
+
+// FractalTestNetParams defines the network parameters for the Fractal Bitcoin test network.
+// Like FractalMainNetParams it only covers the address encodings, which are Bitcoin mainnet's.
+var FractalTestNetParams = chaincfg.Params{
+	Name:             "fractal-testnet",
+	CoinbaseMaturity: 100,
+	PubKeyHashAddrID: 0x00, // starts with 1
+	ScriptHashAddrID: 0x05, // starts with 3
+	PrivateKeyID:     0x80, // starts with 5 (uncompressed) or K (compressed)
+	Bech32HRPSegwit:  "bc", // starts with bc1
+	HDCoinType:       0,
+}
+
+// Network is a named BTC-compatible network preset
+type Network struct {
+	ChainName string           // Pin.ChainName of PINs parsed on the network
+	Params    *chaincfg.Params // Params used for owner addresses
+}
+
+// Network presets
+// Mainnet, testnet3 and regtest keep the "btc" chain name they have always used
+var (
+	MainNet        = &Network{ChainName: "btc", Params: &chaincfg.MainNetParams}
+	TestNet3       = &Network{ChainName: "btc", Params: &chaincfg.TestNet3Params}
+	RegTest        = &Network{ChainName: "btc", Params: &chaincfg.RegressionNetParams}
+	SigNet         = &Network{ChainName: "btc-signet", Params: &chaincfg.SigNetParams}
+	TestNet4       = &Network{ChainName: "btc-testnet4", Params: &TestNet4Params}
+	FractalMainNet = &Network{ChainName: "fractal", Params: &FractalMainNetParams}
+	FractalTestNet = &Network{ChainName: "fractal-testnet", Params: &FractalTestNetParams}
+)
+
+// Networks lists the network presets
+var Networks = []*Network{MainNet, TestNet3, RegTest, SigNet, TestNet4, FractalMainNet, FractalTestNet}
+
+// networkOf returns the preset whose params are params, or nil
+func networkOf(params *chaincfg.Params) *Network {
+	for _, network := range Networks {
+		if network.Params == params {
+			return network
+		}
+	}
+	return nil
+}
+
+func newHashFromStr(str string) *chainhash.Hash {
+	hash, _ := chainhash.NewHashFromStr(str)
+	return hash
+}
+
+func newBigIntFromHex(str string) *big.Int {
+	i, _ := new(big.Int).SetString(str, 16)
+	return i
+}
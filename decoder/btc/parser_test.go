@@ -1,11 +1,59 @@
 package btc
 
 import (
+	"bytes"
+	"errors"
+	"strings"
 	"testing"
 
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
 	"github.com/metaid-developers/metaid-script-decoder/decoder"
 )
 
+// buildRevealTx builds a taproot reveal transaction carrying a metaid envelope, paying to a P2WPKH output
+func buildRevealTx(t *testing.T) []byte {
+	t.Helper()
+	script, err := txscript.NewScriptBuilder().
+		AddData(bytes.Repeat([]byte{0x02}, 32)).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_IF).
+		AddData([]byte("metaid")).
+		AddData([]byte("create")).
+		AddData([]byte("/protocols/simplebuzz")).
+		AddData([]byte("0")).
+		AddData([]byte("1.0.0")).
+		AddData([]byte("application/json")).
+		AddData([]byte(`{"content":"hello"}`)).
+		AddOp(txscript.OP_ENDIF).
+		Script()
+	if err != nil {
+		t.Fatalf("Failed to build script: %v", err)
+	}
+
+	msgTx := wire.NewMsgTx(2)
+	prevHash := chainhash.Hash{0x01}
+	txIn := wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), nil, nil)
+	txIn.Witness = wire.TxWitness{
+		bytes.Repeat([]byte{0x30}, 64),
+		script,
+		append([]byte{0xc0}, bytes.Repeat([]byte{0x03}, 32)...),
+	}
+	msgTx.AddTxIn(txIn)
+	p2wpkh := append([]byte{txscript.OP_0, 0x14}, bytes.Repeat([]byte{0xab}, 20)...)
+	msgTx.AddTxOut(wire.NewTxOut(546, p2wpkh))
+
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+	return buf.Bytes()
+}
+
 func TestNewBTCParser(t *testing.T) {
 	// Test creating parser with default configuration
 	parser := NewBTCParser(nil)
@@ -45,7 +93,103 @@ func TestParseTransaction_InvalidData(t *testing.T) {
 
 	// Test invalid data
 	_, err = parser.ParseTransaction([]byte{0x01, 0x02, 0x03}, nil)
-	if err == nil {
-		t.Error("Expected error for invalid transaction data, got nil")
+	if !errors.Is(err, decoder.ErrInvalidTransaction) {
+		t.Errorf("Expected ErrInvalidTransaction for invalid transaction data, got %v", err)
+	}
+
+	// Test invalid chainParams
+	_, err = parser.ParseTransaction(buildRevealTx(t), "mainnet")
+	if !errors.Is(err, decoder.ErrInvalidChainParams) {
+		t.Errorf("Expected ErrInvalidChainParams for invalid chainParams, got %v", err)
+	}
+}
+
+func TestParseTransaction_Networks(t *testing.T) {
+	raw := buildRevealTx(t)
+
+	tests := []struct {
+		name        string
+		parser      *BTCParser
+		chainParams interface{}
+		chainName   string
+		hrp         string
+	}{
+		{"mainnet", NewBTCParser(nil), nil, "btc", "bc1"},
+		{"testnet3", NewBTCParser(nil), &chaincfg.TestNet3Params, "btc", "tb1"},
+		{"signet", NewNetworkParser(nil, SigNet), nil, "btc-signet", "tb1"},
+		{"testnet4", NewNetworkParser(nil, TestNet4), nil, "btc-testnet4", "tb1"},
+		{"testnet4 params", NewBTCParser(nil), &TestNet4Params, "btc-testnet4", "tb1"},
+		{"fractal", NewFractalParser(nil), nil, "fractal", "bc1"},
+		{"fractal testnet", NewNetworkParser(nil, FractalTestNet), nil, "fractal-testnet", "bc1"},
+		{"regtest", NewBTCParser(nil), &chaincfg.RegressionNetParams, "btc", "bcrt1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pins, err := tt.parser.ParseTransaction(raw, tt.chainParams)
+			if err != nil {
+				t.Fatalf("ParseTransaction failed: %v", err)
+			}
+			if len(pins) != 1 {
+				t.Fatalf("Expected 1 pin, got %d", len(pins))
+			}
+			if pins[0].ChainName != tt.chainName {
+				t.Errorf("Expected chain name '%s', got '%s'", tt.chainName, pins[0].ChainName)
+			}
+			if !strings.HasPrefix(pins[0].OwnerAddress, tt.hrp) {
+				t.Errorf("Expected %s owner address, got '%s'", tt.hrp, pins[0].OwnerAddress)
+			}
+		})
+	}
+}
+
+func TestRegisteredNetworks(t *testing.T) {
+	for name, chainName := range map[string]string{
+		"bitcoin":         "btc",
+		"signet":          "btc-signet",
+		"testnet4":        "btc-testnet4",
+		"fractalbitcoin":  "fractal",
+		"fractal-testnet": "fractal-testnet",
+	} {
+		parser, err := decoder.NewParser(name, nil)
+		if err != nil {
+			t.Fatalf("NewParser(%s) failed: %v", name, err)
+		}
+		if parser.GetChainName() != chainName {
+			t.Errorf("NewParser(%s) chain name = '%s', want '%s'", name, parser.GetChainName(), chainName)
+		}
+	}
+}
+
+func TestParseTransaction_CreatorInput(t *testing.T) {
+	msgTx := wire.NewMsgTx(2)
+	if err := msgTx.Deserialize(bytes.NewReader(buildRevealTx(t))); err != nil {
+		t.Fatalf("Failed to deserialize: %v", err)
+	}
+	// Move the envelope to the second input, spending output 3 of its previous transaction
+	envelopeIn := msgTx.TxIn[0]
+	envelopeIn.PreviousOutPoint.Index = 3
+	fundingHash := chainhash.Hash{0x02}
+	msgTx.TxIn = []*wire.TxIn{wire.NewTxIn(wire.NewOutPoint(&fundingHash, 0), nil, nil), envelopeIn}
+
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+	pins, err := NewBTCParser(nil).ParseTransaction(buf.Bytes(), &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("ParseTransaction failed: %v", err)
+	}
+	if len(pins) != 1 {
+		t.Fatalf("Expected 1 pin, got %d", len(pins))
+	}
+	// The creator input is input 1, spending output 3 of its previous transaction
+	want := envelopeIn.PreviousOutPoint.Hash.String() + ":3"
+	wantVin := envelopeIn.PreviousOutPoint.Hash.String() + ":1"
+	if pins[0].CreatorInputLocation != want || pins[0].CreatorInputTxVinLocation != wantVin {
+		t.Errorf("Expected creator input %s / %s, got %s / %s", want, wantVin, pins[0].CreatorInputLocation, pins[0].CreatorInputTxVinLocation)
+	}
+	if pins[0].InscriptionTxIndex != 1 {
+		t.Errorf("Expected inscription input 1, got %d", pins[0].InscriptionTxIndex)
 	}
 }
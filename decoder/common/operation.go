@@ -0,0 +1,71 @@
+package common
+
+import "strings"
+
+// OperationSpec defines a PIN operation and the envelope fields it requires
+// MinFields counts the fields after the protocol ID, the operation included:
+// <operation> <path> <encryption> <version> <content-type> <body...>
+type OperationSpec struct {
+	Name      string
+	MinFields int
+	// Root operations always apply to the root path "/", any path field is ignored
+	Root bool
+}
+
+// Operation specs of the MetaID protocol
+var (
+	// OpInit creates the root PIN of a MetaID, it may consist of the operation alone
+	OpInit = OperationSpec{Name: "init", MinFields: 1, Root: true}
+	// OpCreate creates a PIN
+	OpCreate = OperationSpec{Name: "create", MinFields: 6}
+	// OpModify replaces the content of a PIN
+	OpModify = OperationSpec{Name: "modify", MinFields: 6}
+	// OpRevoke revokes a PIN, it needs no body
+	OpRevoke = OperationSpec{Name: "revoke", MinFields: 5}
+)
+
+// OperationSet is a set of accepted operations keyed by lower-case name
+type OperationSet map[string]OperationSpec
+
+// NewOperationSet creates an operation set from specs
+// Deployments can accept further operations, e.g.
+//
+//	common.NewOperationSet(append(common.DefaultOperations(), common.OperationSpec{Name: "hide", MinFields: 5})...)
+func NewOperationSet(specs ...OperationSpec) OperationSet {
+	set := make(OperationSet, len(specs))
+	for _, spec := range specs {
+		spec.Name = strings.ToLower(spec.Name)
+		set[spec.Name] = spec
+	}
+	return set
+}
+
+// DefaultOperations returns the specs of the standard operations: init, create, modify and revoke
+func DefaultOperations() []OperationSpec {
+	return []OperationSpec{OpInit, OpCreate, OpModify, OpRevoke}
+}
+
+// defaultOperationSet is the set used when none is configured
+var defaultOperationSet = NewOperationSet(DefaultOperations()...)
+
+// DefaultOperationSet returns a new set of the standard operations
+// The set is a copy, so callers may add operations to it without affecting other parsers
+func DefaultOperationSet() OperationSet {
+	return NewOperationSet(DefaultOperations()...)
+}
+
+// Lookup returns the spec of an operation, case-insensitively
+// A nil set falls back to the standard operations
+func (s OperationSet) Lookup(operation string) (OperationSpec, bool) {
+	if s == nil {
+		s = defaultOperationSet
+	}
+	spec, ok := s[strings.ToLower(operation)]
+	return spec, ok
+}
+
+// Accepts checks whether an operation with a number of fields is valid in the set
+func (s OperationSet) Accepts(operation string, fields int) bool {
+	spec, ok := s.Lookup(operation)
+	return ok && fields >= spec.MinFields
+}
@@ -0,0 +1,177 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// activeContentTypes are media types a browser may execute, after mediaTypeAliases
+var activeContentTypes = map[string]bool{
+	"text/html":             true,
+	"application/xhtml+xml": true,
+	"image/svg+xml":         true,
+	"application/xml":       true,
+	"text/javascript":       true,
+}
+
+// mediaTypeAliases maps common non-standard media types to the type SniffContentType reports
+var mediaTypeAliases = map[string]string{
+	"image/jpg":                "image/jpeg",
+	"image/pjpeg":              "image/jpeg",
+	"image/x-png":              "image/png",
+	"image/svg":                "image/svg+xml",
+	"image/vnd.microsoft.icon": "image/x-icon",
+	"audio/mp3":                "audio/mpeg",
+	"audio/wav":                "audio/wave",
+	"audio/x-wav":              "audio/wave",
+	"application/x-pdf":        "application/pdf",
+	"text/xml":                 "application/xml",
+	"application/x-javascript": "text/javascript",
+	"application/javascript":   "text/javascript",
+}
+
+// canonicalMediaType returns the media type of a content-type with known aliases resolved
+// Example: "image/jpg" -> "image/jpeg"
+func canonicalMediaType(contentType string) string {
+	mediaType := MediaType(contentType)
+	if canonical, ok := mediaTypeAliases[mediaType]; ok {
+		return canonical
+	}
+	return mediaType
+}
+
+// SniffContentType detects the content-type of a body from its leading bytes
+// It extends http.DetectContentType with JSON and SVG detection
+// Returns "" for an empty body
+func SniffContentType(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	// An SVG may start with a bare <svg> tag, which http.DetectContentType reports as text
+	if isSVG(trimmed) {
+		return "image/svg+xml"
+	}
+
+	detected := MediaType(http.DetectContentType(body))
+	if detected == "text/plain" && len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return "application/json"
+	}
+	return detected
+}
+
+// isSVG checks whether a document has an <svg> root element
+// A leading BOM, whitespace, XML declaration, processing instructions, comments and DOCTYPE are skipped.
+func isSVG(body []byte) bool {
+	head := bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	for {
+		head = bytes.TrimLeft(head, " \t\r\n")
+		var end int
+		switch {
+		case bytes.HasPrefix(head, []byte("<?")):
+			end = skipPast(head, "?>")
+		case bytes.HasPrefix(head, []byte("<!--")):
+			end = skipPast(head, "-->")
+		case bytes.HasPrefix(head, []byte("<!")):
+			// A DOCTYPE may have an internal subset holding '>'
+			if i, j := bytes.IndexByte(head, '['), bytes.IndexByte(head, '>'); i >= 0 && (j < 0 || i < j) {
+				end = skipPast(head, "]")
+				if end >= 0 {
+					if k := skipPast(head[end:], ">"); k >= 0 {
+						end += k
+					} else {
+						end = -1
+					}
+				}
+			} else {
+				end = skipPast(head, ">")
+			}
+		default:
+			if len(head) < 4 || !bytes.EqualFold(head[:4], []byte("<svg")) {
+				return false
+			}
+			return len(head) == 4 || bytes.IndexByte([]byte(" \t\r\n/>"), head[4]) >= 0
+		}
+		if end < 0 {
+			return false
+		}
+		head = head[end:]
+	}
+}
+
+// skipPast returns the offset just past the first occurrence of sep, or -1
+func skipPast(b []byte, sep string) int {
+	i := bytes.Index(b, []byte(sep))
+	if i < 0 {
+		return -1
+	}
+	return i + len(sep)
+}
+
+// MediaType returns the normalized media type of a content-type, without parameters
+// Example: "Text/Plain; charset=utf-8" -> "text/plain"
+func MediaType(contentType string) string {
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// IsJSONContentType checks whether a content-type is application/json or a +json type
+// Parameters such as ";utf-8" are ignored, and an empty content-type defaults to application/json
+// as in NormalizeContentType
+func IsJSONContentType(contentType string) bool {
+	mediaType := MediaType(NormalizeContentType(contentType))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// IsActiveContentType checks whether a content-type may be executed by a browser
+func IsActiveContentType(contentType string) bool {
+	return activeContentTypes[canonicalMediaType(contentType)]
+}
+
+// ContentTypeMismatch checks whether a detected content-type contradicts the declared one
+// Undetectable bodies (application/octet-stream), aliases such as image/jpg for image/jpeg and
+// compatible text types are not mismatches
+func ContentTypeMismatch(declared, detected string) bool {
+	declared = canonicalMediaType(NormalizeContentType(declared))
+	detected = canonicalMediaType(detected)
+	if detected == "" || detected == "application/octet-stream" || declared == detected {
+		return false
+	}
+
+	// Plain text is compatible with any declared text or text-based application type
+	if detected == "text/plain" && (strings.HasPrefix(declared, "text/") || isTextApplication(declared)) {
+		return false
+	}
+	// JSON is also valid text
+	if detected == "application/json" && (declared == "text/plain" || strings.HasSuffix(declared, "+json")) {
+		return false
+	}
+	// An SVG document is also XML
+	if declared == "application/xml" && detected == "image/svg+xml" {
+		return false
+	}
+	return true
+}
+
+// IsDangerousMismatch checks whether a body detected as active content is declared as something else
+// Example: HTML declared as image/png
+func IsDangerousMismatch(declared, detected string) bool {
+	if !IsActiveContentType(detected) {
+		return false
+	}
+	return ContentTypeMismatch(declared, detected) && !IsActiveContentType(declared)
+}
+
+// isTextApplication checks whether an application/* media type is text-based
+func isTextApplication(mediaType string) bool {
+	switch mediaType {
+	case "application/json", "application/xml":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
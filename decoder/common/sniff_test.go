@@ -0,0 +1,143 @@
+package common
+
+import "testing"
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestSniffContentType(t *testing.T) {
+	tests := []struct {
+		body     []byte
+		expected string
+	}{
+		{nil, ""},
+		{pngHeader, "image/png"},
+		{[]byte("GIF89a......"), "image/gif"},
+		{[]byte("<!DOCTYPE html><html><body>hi</body></html>"), "text/html"},
+		{[]byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`), "image/svg+xml"},
+		{[]byte(`<?xml version="1.0"?><note></note>`), "text/xml"},
+		{[]byte(`<svg onload="alert(1)"><script>alert(2)</script></svg>`), "image/svg+xml"},
+		{[]byte("\xef\xbb\xbf <!-- logo -->\n<!DOCTYPE svg [<!ENTITY a \"b\">]><SVG/>"), "image/svg+xml"},
+		{[]byte(`<svgfoo></svgfoo>`), "text/plain"},
+		{[]byte(`  {"content": "hello"}`), "application/json"},
+		{[]byte(`{"content": `), "text/plain"},
+		{[]byte("hello world"), "text/plain"},
+		{[]byte{0x00, 0x01, 0x02, 0xff}, "application/octet-stream"},
+	}
+	for _, test := range tests {
+		result := SniffContentType(test.body)
+		if result != test.expected {
+			t.Errorf("SniffContentType(%q) = %q, expected %q", test.body, result, test.expected)
+		}
+	}
+}
+
+func TestSniffContentType_DangerousSVG(t *testing.T) {
+	// An SVG without XML prolog declared as an image must be caught
+	body := []byte(`<svg xmlns="http://www.w3.org/2000/svg" onload="alert(document.domain)"><script>alert(1)</script></svg>`)
+	if detected := SniffContentType(body); !IsDangerousMismatch("image/png", detected) {
+		t.Errorf("Expected a dangerous mismatch for an SVG declared as image/png, detected %q", detected)
+	}
+}
+
+func TestMediaType(t *testing.T) {
+	tests := map[string]string{
+		"Text/Plain; charset=utf-8": "text/plain",
+		"application/json;utf-8":    "application/json",
+		" image/png ":               "image/png",
+		"":                          "",
+	}
+	for input, expected := range tests {
+		if result := MediaType(input); result != expected {
+			t.Errorf("MediaType(%q) = %q, expected %q", input, result, expected)
+		}
+	}
+}
+
+func TestIsJSONContentType(t *testing.T) {
+	tests := map[string]bool{
+		"application/json":          true,
+		"Application/JSON; utf-8":   true,
+		"application/ld+json":       true,
+		"":                          true,
+		"text/plain":                false,
+		"application/jsonp":         false,
+		"text/plain; format=json":   false,
+		"application/vnd.api+json ": true,
+	}
+	for input, expected := range tests {
+		if result := IsJSONContentType(input); result != expected {
+			t.Errorf("IsJSONContentType(%q) = %v, expected %v", input, result, expected)
+		}
+	}
+}
+
+func TestContentTypeMismatch(t *testing.T) {
+	tests := []struct {
+		declared string
+		detected string
+		expected bool
+	}{
+		{"image/png", "image/png", false},
+		{"text/plain;utf-8", "text/plain", false},
+		{"text/markdown", "text/plain", false},
+		{"application/json", "text/plain", false},
+		{"", "application/json", false},
+		{"text/plain", "application/json", false},
+		{"application/xml", "text/xml", false},
+		{"image/png", "application/octet-stream", false},
+		{"image/png", "", false},
+		{"text/plain", "image/png", true},
+		{"image/png", "text/html", true},
+		{"image/jpeg", "image/png", true},
+		// Aliases of the detected type
+		{"image/jpg", "image/jpeg", false},
+		{"IMAGE/JPG; q=1", "image/jpeg", false},
+		{"image/pjpeg", "image/jpeg", false},
+		{"image/x-png", "image/png", false},
+		{"image/svg", "image/svg+xml", false},
+		{"image/vnd.microsoft.icon", "image/x-icon", false},
+		{"audio/mp3", "audio/mpeg", false},
+		{"audio/x-wav", "audio/wave", false},
+		{"application/x-pdf", "application/pdf", false},
+		{"text/xml", "application/xml", false},
+		{"text/xml", "image/svg+xml", false},
+		{"application/xml", "image/svg+xml", false},
+		{"application/x-javascript", "text/javascript", false},
+		{"image/jpg", "image/png", true},
+		{"image/svg", "text/html", true},
+	}
+	for _, test := range tests {
+		result := ContentTypeMismatch(test.declared, test.detected)
+		if result != test.expected {
+			t.Errorf("ContentTypeMismatch(%q, %q) = %v, expected %v", test.declared, test.detected, result, test.expected)
+		}
+	}
+}
+
+func TestIsDangerousMismatch(t *testing.T) {
+	tests := []struct {
+		declared string
+		detected string
+		expected bool
+	}{
+		{"image/png", "text/html", true},
+		{"text/plain", "text/html", true},
+		{"image/png", "image/svg+xml", true},
+		{"text/html", "text/html", false},
+		{"image/svg+xml", "text/xml", false},
+		// Aliases of active types are active too
+		{"image/svg", "image/svg+xml", false},
+		{"text/xml", "image/svg+xml", false},
+		{"application/xml", "image/svg+xml", false},
+		{"image/jpg", "image/svg+xml", true},
+		{"application/x-javascript", "text/html", false},
+		{"text/plain", "image/png", false},
+		{"image/png", "image/png", false},
+	}
+	for _, test := range tests {
+		result := IsDangerousMismatch(test.declared, test.detected)
+		if result != test.expected {
+			t.Errorf("IsDangerousMismatch(%q, %q) = %v, expected %v", test.declared, test.detected, result, test.expected)
+		}
+	}
+}
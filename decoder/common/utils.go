@@ -16,14 +16,10 @@ func GetParentPath(path string) string {
 	return strings.Join(arr[0:len(arr)-1], "/")
 }
 
-// ValidateOperation validates if the operation type is valid
+// ValidateOperation validates if the operation type is one of the standard operations
 func ValidateOperation(operation string) bool {
-	validOps := map[string]bool{
-		"create": true,
-		"modify": true,
-		"revoke": true,
-	}
-	return validOps[strings.ToLower(operation)]
+	_, ok := defaultOperationSet.Lookup(operation)
+	return ok
 }
 
 // NormalizeContentType normalizes the content-type
@@ -24,14 +24,14 @@ func TestGetParentPath(t *testing.T) {
 }
 
 func TestValidateOperation(t *testing.T) {
-	validOps := []string{"create", "modify", "revoke", "CREATE", "MODIFY", "REVOKE"}
+	validOps := []string{"init", "create", "modify", "revoke", "INIT", "CREATE", "MODIFY", "REVOKE"}
 	for _, op := range validOps {
 		if !ValidateOperation(op) {
 			t.Errorf("ValidateOperation(%q) = false, expected true", op)
 		}
 	}
 
-	invalidOps := []string{"init", "delete", "update", "remove", ""}
+	invalidOps := []string{"delete", "update", "remove", "hide", ""}
 	for _, op := range invalidOps {
 		if ValidateOperation(op) {
 			t.Errorf("ValidateOperation(%q) = true, expected false", op)
@@ -39,6 +39,52 @@ func TestValidateOperation(t *testing.T) {
 	}
 }
 
+func TestOperationSet(t *testing.T) {
+	set := DefaultOperationSet()
+	tests := []struct {
+		operation string
+		fields    int
+		expected  bool
+	}{
+		{"init", 1, true},
+		{"create", 6, true},
+		{"create", 5, false},
+		{"Modify", 6, true},
+		{"revoke", 5, true},
+		{"revoke", 4, false},
+		{"hide", 5, false},
+	}
+	for _, test := range tests {
+		if got := set.Accepts(test.operation, test.fields); got != test.expected {
+			t.Errorf("Accepts(%q, %d) = %v, expected %v", test.operation, test.fields, got, test.expected)
+		}
+	}
+
+	// Changing the returned set does not change the standard operations
+	set["hide"] = OperationSpec{Name: "hide", MinFields: 5}
+	delete(set, "create")
+	if _, ok := DefaultOperationSet()["hide"]; ok || !ValidateOperation("create") || ValidateOperation("hide") {
+		t.Error("Expected DefaultOperationSet to return a copy")
+	}
+	if _, ok := OperationSet(nil).Lookup("hide"); ok {
+		t.Error("Expected nil set to ignore operations added to a copy")
+	}
+
+	// A nil set falls back to the standard operations
+	if _, ok := OperationSet(nil).Lookup("create"); !ok {
+		t.Error("Expected nil set to accept create")
+	}
+
+	// Deployments can add operations
+	custom := NewOperationSet(append(DefaultOperations(), OperationSpec{Name: "Hide", MinFields: 5})...)
+	if !custom.Accepts("hide", 5) || !custom.Accepts("create", 6) {
+		t.Error("Expected custom set to accept hide and create")
+	}
+	if NewOperationSet(OpCreate).Accepts("init", 1) {
+		t.Error("Expected set without init to reject it")
+	}
+}
+
 func TestNormalizeContentType(t *testing.T) {
 	tests := []struct {
 		input    string
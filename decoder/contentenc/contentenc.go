@@ -0,0 +1,141 @@
+package contentenc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported content encodings
+const (
+	Identity = ""     // Not encoded
+	Gzip     = "gzip" // RFC 1952
+	Zlib     = "zlib" // RFC 1950, also accepted as "deflate"
+	Brotli   = "br"   // RFC 7932
+	Zstd     = "zstd" // RFC 8878
+)
+
+// DefaultMaxDecodedSize is the default cap on the size of a decoded body
+const DefaultMaxDecodedSize = 16 << 20 // 16 MiB
+
+var (
+	// ErrUnsupportedEncoding is returned for unknown content encodings
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+	// ErrTooLarge is returned when a decoded body exceeds the size cap
+	ErrTooLarge = errors.New("decoded content exceeds size limit")
+)
+
+// aliases maps encoding markers to the canonical encoding names
+var aliases = map[string]string{
+	"gzip":      Gzip,
+	"x-gzip":    Gzip,
+	"zlib":      Zlib,
+	"deflate":   Zlib,
+	"br":        Brotli,
+	"brotli":    Brotli,
+	"zstd":      Zstd,
+	"zstandard": Zstd,
+}
+
+// hintKeys are the parameter names that carry an encoding hint, e.g. "encoding=gzip"
+var hintKeys = map[string]bool{
+	"encoding":         true,
+	"content-encoding": true,
+	"compress":         true,
+	"compression":      true,
+}
+
+// Canonical returns the canonical name of an encoding marker
+func Canonical(marker string) (string, bool) {
+	encoding, ok := aliases[strings.ToLower(strings.TrimSpace(marker))]
+	return encoding, ok
+}
+
+// Detect finds a content encoding marker in the content-type or version of a PIN
+// Markers are ";"-separated parameters, either bare ("application/json;gzip")
+// or as a hint ("application/json; content-encoding=br")
+// Returns Identity if no known marker is present
+func Detect(contentType, version string) string {
+	for _, field := range []string{contentType, version} {
+		params := strings.Split(field, ";")
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if key, value, ok := strings.Cut(param, "="); ok {
+				if !hintKeys[strings.ToLower(strings.TrimSpace(key))] {
+					continue
+				}
+				param = strings.Trim(strings.TrimSpace(value), `"`)
+			}
+			if encoding, ok := Canonical(param); ok {
+				return encoding
+			}
+		}
+	}
+	return Identity
+}
+
+// Decode decompresses body with the given encoding
+// The decoded size is capped at maxSize bytes, 0 means DefaultMaxDecodedSize
+func Decode(encoding string, body []byte, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDecodedSize
+	}
+
+	var r io.Reader
+	switch encoding {
+	case Identity:
+		if int64(len(body)) > maxSize {
+			return nil, ErrTooLarge
+		}
+		return body, nil
+	case Gzip:
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case Zlib:
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("zlib: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case Brotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	case Zstd:
+		zr, err := zstd.NewReader(bytes.NewReader(body),
+			zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderMaxMemory(uint64(maxSize)),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("zstd: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+
+	// Read one byte past the cap to detect oversized bodies without buffering them
+	decoded, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		// The zstd decoder refuses frames whose declared size or window exceeds its memory cap
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+			return nil, ErrTooLarge
+		}
+		return nil, fmt.Errorf("%s: %w", encoding, err)
+	}
+	if int64(len(decoded)) > maxSize {
+		return nil, ErrTooLarge
+	}
+	return decoded, nil
+}
@@ -0,0 +1,107 @@
+package contentenc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compress encodes data with the given encoding for test fixtures
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	switch encoding {
+	case Gzip:
+		w := gzip.NewWriter(&buf)
+		w.Write(data)
+		w.Close()
+	case Zlib:
+		w := zlib.NewWriter(&buf)
+		w.Write(data)
+		w.Close()
+	case Brotli:
+		w := brotli.NewWriter(&buf)
+		w.Write(data)
+		w.Close()
+	case Zstd:
+		w, err := zstd.NewWriter(&buf)
+		if err != nil {
+			t.Fatalf("zstd.NewWriter failed: %v", err)
+		}
+		w.Write(data)
+		w.Close()
+	}
+	return buf.Bytes()
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		contentType string
+		version     string
+		expected    string
+	}{
+		{"application/json", "1.0.0", Identity},
+		{"application/json;gzip", "1.0.0", Gzip},
+		{"application/json; charset=utf-8; GZIP", "", Gzip},
+		{"text/plain;utf-8", "", Identity},
+		{"application/json; content-encoding=br", "", Brotli},
+		{"application/json; encoding=\"zstd\"", "", Zstd},
+		{"application/json", "1.0.0;deflate", Zlib},
+		{"application/json", "1.0.0;compression=zstandard", Zstd},
+		{"application/json; charset=gzip", "", Identity},
+		{"gzip", "", Identity},
+	}
+	for _, test := range tests {
+		result := Detect(test.contentType, test.version)
+		if result != test.expected {
+			t.Errorf("Detect(%q, %q) = %q, expected %q", test.contentType, test.version, result, test.expected)
+		}
+	}
+}
+
+func TestDecode_RoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"content":"hello metaid"}`), 50)
+	for _, encoding := range []string{Gzip, Zlib, Brotli, Zstd} {
+		decoded, err := Decode(encoding, compress(t, encoding, data), 0)
+		if err != nil {
+			t.Errorf("Decode(%s) failed: %v", encoding, err)
+			continue
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("Decode(%s) returned different data", encoding)
+		}
+	}
+}
+
+func TestDecode_SizeLimit(t *testing.T) {
+	// A small body that expands far beyond the cap
+	bomb := bytes.Repeat([]byte{0}, 1<<20)
+	for _, encoding := range []string{Gzip, Zlib, Brotli, Zstd} {
+		_, err := Decode(encoding, compress(t, encoding, bomb), 1024)
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Decode(%s) with cap = %v, expected ErrTooLarge", encoding, err)
+		}
+	}
+
+	// Exactly at the cap is allowed
+	data := bytes.Repeat([]byte("a"), 1024)
+	if _, err := Decode(Gzip, compress(t, Gzip, data), 1024); err != nil {
+		t.Errorf("Decode at cap failed: %v", err)
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	if _, err := Decode("lzma", []byte("x"), 0); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("Expected ErrUnsupportedEncoding, got %v", err)
+	}
+	for _, encoding := range []string{Gzip, Zlib, Brotli, Zstd} {
+		if _, err := Decode(encoding, []byte("not compressed"), 0); err == nil {
+			t.Errorf("Decode(%s) of garbage expected error, got nil", encoding)
+		}
+	}
+}
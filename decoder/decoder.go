@@ -1,7 +1,7 @@
 package decoder
 
-// Note: Factory methods have been removed to avoid circular imports
-// Please use each chain's parser directly:
+// Chain parsers live in their own packages and register themselves with the
+// decoder when imported. Use each chain's parser directly:
 //
 // For BTC:
 //   import "github.com/metaid-developers/metaid-script-decoder/decoder/btc"
@@ -11,4 +11,8 @@ package decoder
 //   import "github.com/metaid-developers/metaid-script-decoder/decoder/mvc"
 //   parser := mvc.NewMVCParser(config)
 //
+// Or look a parser up by chain name after importing the chain package:
+//   import _ "github.com/metaid-developers/metaid-script-decoder/decoder/btc"
+//   parser, err := decoder.NewParser("btc", config)
+//
 // For example usage, see examples/main.go
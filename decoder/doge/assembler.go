@@ -0,0 +1,239 @@
+package doge
+
+import (
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/envelope"
+)
+
+// DefaultPartTimeout is how long a pending multi-transaction inscription waits for its next part
+const DefaultPartTimeout = 24 * time.Hour
+
+// headerFields is the number of envelope fields in the first part:
+// operation, content type, encryption, version and path
+const headerFields = 5
+
+// Assembler decodes DOGE inscriptions split across a chain of transactions, Doginals style
+//
+// The first part pushes the number of body pieces in place of the operation, followed by the
+// envelope fields and the pieces, each preceded by a countdown:
+//
+//	<protocolID> <pieces> <operation> <contentType> <encryption> <version> <address:path> <pieces-1> <piece> <pieces-2> <piece> ... <signature> <redeemScript>
+//
+// Every following part sits in a transaction spending an output of the previous part and
+// continues the countdown until piece 0:
+//
+//	<countdown> <piece> ... <signature> <redeemScript>
+//
+// A transaction may carry parts of several inscriptions, in input order. The k-th of them is
+// continued by spending output k, so Doginals chains with one part per transaction continue
+// through output 0.
+//
+// The complete PIN is emitted with the transaction carrying the last piece, which gives its Id and owner.
+// Transactions without a multi-transaction inscription are decoded like DOGEParser.ParseTransaction.
+type Assembler struct {
+	parser  *DOGEParser
+	store   PartStore
+	timeout time.Duration
+	now     func() time.Time
+}
+
+// NewAssembler creates a DOGE assembler backed by store
+// If store is nil, an in-memory store is used; if timeout is 0, DefaultPartTimeout is used
+func NewAssembler(config *decoder.ParserConfig, store PartStore, timeout time.Duration) *Assembler {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	if timeout <= 0 {
+		timeout = DefaultPartTimeout
+	}
+	return &Assembler{
+		parser:  NewDOGEParser(config),
+		store:   store,
+		timeout: timeout,
+		now:     time.Now,
+	}
+}
+
+// AddTransaction feeds a transaction to the assembler, transactions must be added in spending order
+// Returns the PINs completed by this transaction, including single transaction PINs
+func (a *Assembler) AddTransaction(txBytes []byte, chainParams interface{}) ([]*decoder.Pin, error) {
+	params, msgTx, err := a.parser.deserialize(txBytes, chainParams)
+	if err != nil {
+		return nil, err
+	}
+	txHash := msgTx.TxHash().String()
+
+	var pins []*decoder.Pin
+	continued := 0 // Pending parts of this transaction, each continued through its own output
+	for i, input := range msgTx.TxIn {
+		if len(input.SignatureScript) == 0 {
+			continue
+		}
+
+		part, err := a.nextPart(input, i)
+		if err != nil {
+			return nil, err
+		}
+		if part == nil {
+			// Not part of a multi-transaction inscription
+			if pin := a.parser.parseScriptSig(input.SignatureScript); pin != nil {
+				assign(pin, msgTx, i, params)
+				envelope.SetCreatorInput(pin, input.PreviousOutPoint.Hash.String(), input.PreviousOutPoint.Index, i)
+				pins = append(pins, pin)
+			}
+			continue
+		}
+
+		if part.Remaining > 0 {
+			vout := continued
+			continued++
+			if vout >= len(msgTx.TxOut) {
+				// No output left to continue the inscription
+				continue
+			}
+			if part.FirstTxID == "" {
+				part.FirstTxID = txHash
+			}
+			part.TxID = txHash
+			part.Vout = uint32(vout)
+			part.Updated = a.now()
+			if err := a.store.PutPart(part); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		fields := append(append([][]byte{}, part.Header...), part.Body)
+		pin := envelope.DOGEDirect.Parse(a.parser.config.Operations, fields)
+		if pin == nil {
+			continue
+		}
+		assign(pin, msgTx, i, params)
+		envelope.SetCreatorInput(pin, part.CreatorTxID, part.CreatorVout, part.CreatorVin)
+		pins = append(pins, pin)
+	}
+
+	return decoder.ProcessPins(a.parser.config, pins), nil
+}
+
+// Expire removes the pending inscriptions that were not continued within the timeout
+// Returns the abandoned parts
+func (a *Assembler) Expire() ([]*Part, error) {
+	outpoints, err := a.store.StaleParts(a.now().Add(-a.timeout))
+	if err != nil {
+		return nil, err
+	}
+	var parts []*Part
+	for _, outpoint := range outpoints {
+		part, ok, err := a.store.GetPart(outpoint)
+		if err != nil {
+			return parts, err
+		}
+		if err := a.store.DeletePart(outpoint); err != nil {
+			return parts, err
+		}
+		if ok {
+			parts = append(parts, part)
+		}
+	}
+	return parts, nil
+}
+
+// nextPart returns the inscription state after the part carried by input
+// Returns nil if the input carries neither a first part nor the continuation of a pending inscription
+func (a *Assembler) nextPart(input *wire.TxIn, vin int) (*Part, error) {
+	pushes := envelope.DirectScriptSigPushes(input.SignatureScript)
+
+	prevOut := input.PreviousOutPoint.String()
+	prev, ok, err := a.store.GetPart(prevOut)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		// The pending inscription ends here, continued or not
+		if err := a.store.DeletePart(prevOut); err != nil {
+			return nil, err
+		}
+		if prev.Updated.After(a.now().Add(-a.timeout)) {
+			part := *prev
+			part.Body = append([]byte(nil), prev.Body...)
+			if part.addPieces(pushes) {
+				return &part, nil
+			}
+		}
+	}
+
+	return a.firstPart(pushes, input.PreviousOutPoint, vin), nil
+}
+
+// firstPart parses the first part of a multi-transaction inscription carried by input vin
+func (a *Assembler) firstPart(pushes [][]byte, prevOut wire.OutPoint, vin int) *Part {
+	config := a.parser.config
+	if len(pushes) < 2+headerFields || !envelope.DOGEDirect.MatchProtocol(pushes[0], config.ProtocolID) {
+		return nil
+	}
+	// A single transaction envelope has the operation in place of the piece count
+	if _, ok := config.Operations.Lookup(strings.ToLower(string(pushes[1]))); ok {
+		return nil
+	}
+	pieces, ok := scriptNum(pushes[1])
+	if !ok || pieces < 1 {
+		return nil
+	}
+	header := pushes[2 : 2+headerFields]
+	if _, ok := config.Operations.Lookup(strings.ToLower(string(header[0]))); !ok {
+		return nil
+	}
+
+	part := &Part{
+		CreatorTxID: prevOut.Hash.String(),
+		CreatorVout: prevOut.Index,
+		CreatorVin:  vin,
+		Header:      header,
+		Remaining:   pieces,
+	}
+	if !part.addPieces(pushes[2+headerFields:]) {
+		return nil
+	}
+	return part
+}
+
+// addPieces appends <countdown> <piece> pairs to the body
+// Returns false if the pairs do not continue the countdown
+func (p *Part) addPieces(pushes [][]byte) bool {
+	if len(pushes) == 0 || len(pushes)%2 != 0 {
+		return false
+	}
+	for k := 0; k < len(pushes); k += 2 {
+		countdown, ok := scriptNum(pushes[k])
+		if !ok || countdown != p.Remaining-1 {
+			return false
+		}
+		p.Body = append(p.Body, pushes[k+1]...)
+		p.Remaining--
+	}
+	return true
+}
+
+// scriptNum decodes a non-negative script number of up to 4 bytes
+func scriptNum(data []byte) (int, bool) {
+	if len(data) > 4 {
+		return 0, false
+	}
+	if len(data) == 0 {
+		return 0, true
+	}
+	if data[len(data)-1]&0x80 != 0 {
+		return 0, false
+	}
+	n := 0
+	for i := len(data) - 1; i >= 0; i-- {
+		n = n<<8 | int(data[i])
+	}
+	return n, true
+}
@@ -0,0 +1,207 @@
+package doge
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// partTx builds a transaction spending prevOut with a ScriptSig carrying pushes,
+// a signature and a redeem script dropping the pushes
+func partTx(t *testing.T, prevOut wire.OutPoint, pushes ...[]byte) *wire.MsgTx {
+	t.Helper()
+	privKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	signature := append(ecdsa.Sign(privKey, bytes.Repeat([]byte{0x02}, 32)).Serialize(), byte(txscript.SigHashAll))
+
+	redeem := txscript.NewScriptBuilder().AddData(privKey.PubKey().SerializeCompressed()).AddOp(txscript.OP_CHECKSIGVERIFY)
+	builder := txscript.NewScriptBuilder()
+	for _, push := range pushes {
+		builder.AddData(push)
+		redeem.AddOp(txscript.OP_DROP)
+	}
+	redeemScript, err := redeem.AddOp(txscript.OP_TRUE).Script()
+	if err != nil {
+		t.Fatalf("Failed to build redeem script: %v", err)
+	}
+	scriptSig, err := builder.AddData(signature).AddData(redeemScript).Script()
+	if err != nil {
+		t.Fatalf("Failed to build ScriptSig: %v", err)
+	}
+
+	msgTx := wire.NewMsgTx(1)
+	msgTx.AddTxIn(wire.NewTxIn(&prevOut, scriptSig, nil))
+	msgTx.AddTxOut(wire.NewTxOut(100000, append([]byte{txscript.OP_DUP, txscript.OP_HASH160, 0x14}, append(bytes.Repeat([]byte{0x11}, 20), txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)...)))
+	return msgTx
+}
+
+// txBytes serializes a transaction
+func txBytes(t *testing.T, msgTx *wire.MsgTx) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// spending returns the outpoint of output 0 of msgTx
+func spending(msgTx *wire.MsgTx) wire.OutPoint {
+	hash := msgTx.TxHash()
+	return *wire.NewOutPoint(&hash, 0)
+}
+
+// inscriptionChain builds a three part inscription
+func inscriptionChain(t *testing.T) []*wire.MsgTx {
+	first := partTx(t, *wire.NewOutPoint(&chainhash.Hash{0x01}, 2),
+		[]byte("metaid"), []byte{3}, []byte("create"), []byte("text/plain"), []byte("0"), []byte("1.0.0"), []byte("DAddr:/file/big"),
+		[]byte{2}, []byte("aaa"))
+	second := partTx(t, spending(first), []byte{1}, []byte("bbb"))
+	last := partTx(t, spending(second), []byte{}, []byte("ccc"))
+	return []*wire.MsgTx{first, second, last}
+}
+
+func TestAssembler_MultiTransaction(t *testing.T) {
+	chain := inscriptionChain(t)
+	assembler := NewAssembler(nil, nil, 0)
+
+	for _, msgTx := range chain[:2] {
+		pins, err := assembler.AddTransaction(txBytes(t, msgTx), nil)
+		if err != nil {
+			t.Fatalf("AddTransaction failed: %v", err)
+		}
+		if len(pins) != 0 {
+			t.Fatalf("Expected no pin before the last part, got %d", len(pins))
+		}
+	}
+	pins, err := assembler.AddTransaction(txBytes(t, chain[2]), nil)
+	if err != nil {
+		t.Fatalf("AddTransaction failed: %v", err)
+	}
+	if len(pins) != 1 {
+		t.Fatalf("Expected 1 pin, got %d", len(pins))
+	}
+
+	pin := pins[0]
+	lastHash := chain[2].TxHash().String()
+	if string(pin.ContentBody) != "aaabbbccc" || pin.Path != "/file/big" || pin.ContentType != "text/plain" {
+		t.Errorf("Unexpected pin: path %q, type %q, body %q", pin.Path, pin.ContentType, pin.ContentBody)
+	}
+	if pin.Id != lastHash+"i0" || pin.OwnerAddress == "" {
+		t.Errorf("Expected the last transaction to give the id and owner, got %s / %q", pin.Id, pin.OwnerAddress)
+	}
+	if want := (chainhash.Hash{0x01}).String() + ":2"; pin.CreatorInputLocation != want {
+		t.Errorf("Expected creator input %s, got %s", want, pin.CreatorInputLocation)
+	}
+	if want := (chainhash.Hash{0x01}).String() + ":0"; pin.CreatorInputTxVinLocation != want {
+		t.Errorf("Expected creator input vin location %s, got %s", want, pin.CreatorInputTxVinLocation)
+	}
+
+	// The parts alone do not carry a PIN
+	for _, msgTx := range chain {
+		pins, err := NewDOGEParser(nil).ParseTransaction(txBytes(t, msgTx), nil)
+		if err != nil || len(pins) != 0 {
+			t.Errorf("Expected no pin from a single part, got %d (%v)", len(pins), err)
+		}
+	}
+}
+
+// join merges the inputs and outputs of txs into one transaction
+func join(txs ...*wire.MsgTx) *wire.MsgTx {
+	msgTx := wire.NewMsgTx(1)
+	for _, tx := range txs {
+		msgTx.TxIn = append(msgTx.TxIn, tx.TxIn...)
+		msgTx.TxOut = append(msgTx.TxOut, tx.TxOut...)
+	}
+	return msgTx
+}
+
+func TestAssembler_SharedTransactions(t *testing.T) {
+	// Two inscriptions start in the same transaction and continue through outputs 0 and 1
+	first := join(
+		partTx(t, *wire.NewOutPoint(&chainhash.Hash{0x01}, 0),
+			[]byte("metaid"), []byte{3}, []byte("create"), []byte("text/plain"), []byte("0"), []byte("1.0.0"), []byte("DAddr:/file/x"),
+			[]byte{2}, []byte("x1")),
+		partTx(t, *wire.NewOutPoint(&chainhash.Hash{0x02}, 0),
+			[]byte("metaid"), []byte{3}, []byte("create"), []byte("text/plain"), []byte("0"), []byte("1.0.0"), []byte("DAddr:/file/y"),
+			[]byte{2}, []byte("y1")),
+	)
+	firstHash := first.TxHash()
+	// Their middle parts share a transaction too, in the other order
+	middle := join(
+		partTx(t, *wire.NewOutPoint(&firstHash, 1), []byte{1}, []byte("y2")),
+		partTx(t, *wire.NewOutPoint(&firstHash, 0), []byte{1}, []byte("x2")),
+	)
+	middleHash := middle.TxHash()
+	lastX := partTx(t, *wire.NewOutPoint(&middleHash, 1), []byte{}, []byte("x3"))
+	lastY := partTx(t, *wire.NewOutPoint(&middleHash, 0), []byte{}, []byte("y3"))
+
+	assembler := NewAssembler(nil, nil, 0)
+	var bodies []string
+	for _, msgTx := range []*wire.MsgTx{first, middle, lastX, lastY} {
+		pins, err := assembler.AddTransaction(txBytes(t, msgTx), nil)
+		if err != nil {
+			t.Fatalf("AddTransaction failed: %v", err)
+		}
+		for _, pin := range pins {
+			bodies = append(bodies, pin.Path+" "+string(pin.ContentBody))
+		}
+	}
+	if len(bodies) != 2 || bodies[0] != "/file/x x1x2x3" || bodies[1] != "/file/y y1y2y3" {
+		t.Errorf("Unexpected pins %q", bodies)
+	}
+}
+
+func TestAssembler_BrokenCountdown(t *testing.T) {
+	chain := inscriptionChain(t)
+	assembler := NewAssembler(nil, nil, 0)
+	if _, err := assembler.AddTransaction(txBytes(t, chain[0]), nil); err != nil {
+		t.Fatalf("AddTransaction failed: %v", err)
+	}
+
+	// Piece 0 where piece 1 is expected ends the sequence
+	skipped := partTx(t, spending(chain[0]), []byte{}, []byte("ccc"))
+	pins, err := assembler.AddTransaction(txBytes(t, skipped), nil)
+	if err != nil || len(pins) != 0 {
+		t.Fatalf("Expected no pin, got %d (%v)", len(pins), err)
+	}
+	if _, ok, _ := assembler.store.GetPart(spending(chain[0]).String()); ok {
+		t.Error("Expected the broken sequence to be dropped")
+	}
+}
+
+func TestAssembler_Timeout(t *testing.T) {
+	chain := inscriptionChain(t)
+	now := time.Unix(1700000000, 0)
+	assembler := NewAssembler(nil, NewMemoryStore(), time.Hour)
+	assembler.now = func() time.Time { return now }
+
+	if _, err := assembler.AddTransaction(txBytes(t, chain[0]), nil); err != nil {
+		t.Fatalf("AddTransaction failed: %v", err)
+	}
+	if parts, _ := assembler.Expire(); len(parts) != 0 {
+		t.Fatalf("Expected nothing to expire yet, got %d", len(parts))
+	}
+
+	now = now.Add(2 * time.Hour)
+	parts, err := assembler.Expire()
+	if err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if len(parts) != 1 || parts[0].FirstTxID != chain[0].TxHash().String() || parts[0].Remaining != 2 {
+		t.Fatalf("Expected the first part to be abandoned, got %+v", parts)
+	}
+
+	// The continuation of an abandoned sequence is ignored
+	for _, msgTx := range chain[1:] {
+		pins, err := assembler.AddTransaction(txBytes(t, msgTx), nil)
+		if err != nil || len(pins) != 0 {
+			t.Errorf("Expected no pin, got %d (%v)", len(pins), err)
+		}
+	}
+}
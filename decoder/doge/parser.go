@@ -2,20 +2,24 @@ package doge
 
 import (
 	"bytes"
-	"encoding/hex"
 	"fmt"
 	"math/big"
-	"strings"
 
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
-	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 
 	"github.com/metaid-developers/metaid-script-decoder/decoder"
-	"github.com/metaid-developers/metaid-script-decoder/decoder/common"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/btc"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/envelope"
 )
 
+func init() {
+	decoder.RegisterChain("doge", func(config *decoder.ParserConfig) decoder.ChainParser {
+		return NewDOGEParser(config)
+	}, "dogecoin")
+}
+
 // DOGEParser is the DOGE chain parser
 type DOGEParser struct {
 	config *decoder.ParserConfig
@@ -37,11 +41,28 @@ func (p *DOGEParser) GetChainName() string {
 }
 
 // ParseTransaction parses a DOGE transaction
+// Inscriptions split across several transactions are only decoded by an Assembler
 func (p *DOGEParser) ParseTransaction(txBytes []byte, chainParams interface{}) ([]*decoder.Pin, error) {
+	params, msgTx, err := p.deserialize(txBytes, chainParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var pins []*decoder.Pin
+
+	// DOGE uses ScriptSig format (P2SH redeem script), not Witness
+	scriptSigPins := p.parseScriptSigPins(msgTx, params)
+	pins = append(pins, scriptSigPins...)
+
+	return decoder.ProcessPins(p.config, pins), nil
+}
+
+// deserialize checks the chain params and deserializes a transaction
+func (p *DOGEParser) deserialize(txBytes []byte, chainParams interface{}) (*chaincfg.Params, *wire.MsgTx, error) {
 	// Parse chainParams
 	params, ok := chainParams.(*chaincfg.Params)
 	if !ok && chainParams != nil {
-		return nil, fmt.Errorf("invalid chainParams type for DOGE, expected *chaincfg.Params")
+		return nil, nil, fmt.Errorf("%w for DOGE, expected *chaincfg.Params", decoder.ErrInvalidChainParams)
 	}
 	if params == nil {
 		params = &DogeMainNetParams
@@ -50,22 +71,14 @@ func (p *DOGEParser) ParseTransaction(txBytes []byte, chainParams interface{}) (
 	// Deserialize transaction
 	msgTx := wire.NewMsgTx(wire.TxVersion)
 	if err := msgTx.Deserialize(bytes.NewReader(txBytes)); err != nil {
-		return nil, fmt.Errorf("failed to deserialize transaction: %w", err)
+		return nil, nil, fmt.Errorf("%w: %w", decoder.ErrInvalidTransaction, err)
 	}
-
-	var pins []*decoder.Pin
-
-	// DOGE uses ScriptSig format (P2SH redeem script), not Witness
-	scriptSigPins := p.parseScriptSigPins(msgTx, params)
-	pins = append(pins, scriptSigPins...)
-
-	return pins, nil
+	return params, msgTx, nil
 }
 
 // parseScriptSigPins parses ScriptSig format PINs
 func (p *DOGEParser) parseScriptSigPins(msgTx *wire.MsgTx, params *chaincfg.Params) []*decoder.Pin {
 	var pins []*decoder.Pin
-	txHash := msgTx.TxHash().String()
 
 	// Dogecoin: Parse inscriptions from ScriptSig (P2SH redeem script)
 	// Unlike Bitcoin's SegWit which uses witness data, Dogecoin uses legacy P2SH
@@ -75,335 +88,45 @@ func (p *DOGEParser) parseScriptSigPins(msgTx *wire.MsgTx, params *chaincfg.Para
 			continue
 		}
 
-		// Try parsing direct format first (metaid data at the beginning of ScriptSig)
-		pin := p.parsePinFromDirectScriptSig(input.SignatureScript)
-		if pin == nil {
-			// Parse ScriptSig to extract the redeem script
-			// ScriptSig format for P2SH: <signature> <redeemScript>
-			tokenizer := txscript.MakeScriptTokenizer(0, input.SignatureScript)
-			var redeemScript []byte
-			var lastData []byte
-
-			// Iterate through ScriptSig to find the redeem script (last push data)
-			for tokenizer.Next() {
-				if len(tokenizer.Data()) > 0 {
-					lastData = tokenizer.Data()
-				}
-			}
-
-			// The last pushed data in ScriptSig should be the redeem script
-			if len(lastData) > 0 {
-				redeemScript = lastData
-			} else {
-				continue
-			}
-
-			// Parse the redeem script for inscription data
-			pin = p.parsePinFromRedeemScript(redeemScript)
-		}
-
+		pin := p.parseScriptSig(input.SignatureScript)
 		if pin == nil {
 			continue
 		}
-
-		// Get PIN owner address
-		address, vout, outValue, locationIdx := p.getScriptSigOwner(msgTx, i, params)
-		if address == "" {
-			address = ""
-			vout = 0
-		}
-
-		pin.Id = fmt.Sprintf("%si%d", txHash, vout)
-		pin.TxID = txHash
-		pin.Vout = uint32(vout)
-		pin.OwnerAddress = address
-		pin.OwnerMetaId = common.CalculateMetaId(address)
-		pin.ChainName = "doge"
-		pin.InscriptionTxIndex = i
-		pin.CreatorInputTxVinLocation = fmt.Sprintf("%s:%d", input.PreviousOutPoint.Hash.String(), input.PreviousOutPoint.Index)
-
-		// PIN location
-		pin.Location = fmt.Sprintf("%s:%d:%d", txHash, vout, locationIdx)
-		pin.Offset = uint64(vout)
-		pin.Output = fmt.Sprintf("%s:%d", txHash, vout)
-		pin.OutputValue = outValue
-
+		assign(pin, msgTx, i, params)
+		// The creator input is the input carrying the envelope
+		envelope.SetCreatorInput(pin, input.PreviousOutPoint.Hash.String(), input.PreviousOutPoint.Index, i)
 		pins = append(pins, pin)
 	}
 
 	return pins
 }
 
-// parsePinFromRedeemScript parses Dogecoin inscription data from P2SH redeem script
-// Dogecoin inscription format in redeem script:
-// <pubkey> OP_CHECKSIGVERIFY OP_FALSE OP_IF "metaid" <operation> <path> <encryption> <version> <contentType> <content> [more content...] OP_ENDIF
-func (p *DOGEParser) parsePinFromRedeemScript(redeemScript []byte) *decoder.Pin {
-	tokenizer := txscript.MakeScriptTokenizer(0, redeemScript)
-
-	// Skip the pubkey and OP_CHECKSIGVERIFY at the beginning
-	if !tokenizer.Next() {
-		return nil
-	}
-	if !tokenizer.Next() || tokenizer.Opcode() != txscript.OP_CHECKSIGVERIFY {
-		return nil
-	}
-
-	// Look for inscription envelope: OP_FALSE OP_IF
-	if !tokenizer.Next() || tokenizer.Opcode() != txscript.OP_FALSE {
-		return nil
-	}
-	if !tokenizer.Next() || tokenizer.Opcode() != txscript.OP_IF {
-		return nil
-	}
-
-	// Check for protocol ID marker
-	// Can be either string "metaid" or hex encoded protocol ID
-	if !tokenizer.Next() {
-		return nil
-	}
-	protocolMarker := string(tokenizer.Data())
-	protocolIDHex := hex.EncodeToString(tokenizer.Data())
-	// Check both string format and hex format
-	if protocolMarker != "metaid" && protocolIDHex != p.config.ProtocolID {
-		return nil
-	}
-
-	// Parse inscription data following the standard metaid protocol format
-	// Format: protocolID <operation> <path> <encryption> <version> <contentType> <content> [more content...]
-	// Collect all data fields until OP_ENDIF
-	var infoList [][]byte
-	for tokenizer.Next() {
-		if tokenizer.Opcode() == txscript.OP_ENDIF {
-			break
-		}
-		infoList = append(infoList, tokenizer.Data())
-		if len(tokenizer.Data()) > 520 {
-			return nil
-		}
-	}
-
-	// Check for errors
-	if err := tokenizer.Err(); err != nil {
-		return nil
-	}
-
-	return p.parseOnePin(infoList)
+// assign sets the owner and location of a PIN carried by input i of msgTx
+// The owner is the first output
+func assign(pin *decoder.Pin, msgTx *wire.MsgTx, i int, params *chaincfg.Params) {
+	owner, _ := envelope.FirstOutput(btc.Outputs(msgTx, params))
+	envelope.Assign(pin, "doge", msgTx.TxHash().String(), owner)
+	pin.InscriptionTxIndex = i
 }
 
-// parsePinFromDirectScriptSig parses Dogecoin inscription data directly from ScriptSig
-// This format has metaid protocol data at the beginning of ScriptSig without OP_IF/OP_ENDIF wrapper
-// Format: <pushdata protocolID> <pushdata operation> <pushdata contentType> <pushdata encryption> <pushdata version> <pushdata address:path> <pushdata content> <signature> <pubkey> ...
-func (p *DOGEParser) parsePinFromDirectScriptSig(scriptSig []byte) *decoder.Pin {
-	if len(scriptSig) < 7 {
-		return nil
-	}
-
-	tokenizer := txscript.MakeScriptTokenizer(0, scriptSig)
-	var infoList [][]byte
-
-	// Collect all push data from ScriptSig
-	for tokenizer.Next() {
-		if len(tokenizer.Data()) > 0 {
-			infoList = append(infoList, tokenizer.Data())
-		}
-	}
-
-	if err := tokenizer.Err(); err != nil {
-		return nil
-	}
-
-	if len(infoList) < 6 {
-		return nil
-	}
-
-	// Check if first field is "metaid" protocol marker (as string, not hex)
-	protocolMarker := string(infoList[0])
-	// Convert to hex for comparison with config
-	protocolIDHex := hex.EncodeToString(infoList[0])
-	if protocolMarker != "metaid" && protocolIDHex != p.config.ProtocolID {
-		return nil
-	}
-
-	pin := &decoder.Pin{}
-	pin.Operation = strings.ToLower(string(infoList[1]))
-
-	// // Special case: init operation
-	// if pin.Operation == "init" {
-	// 	pin.Path = "/"
-	// 	pin.ParentPath = ""
-	// 	pin.Encryption = "0"
-	// 	pin.Version = "0"
-	// 	pin.ContentType = "application/json"
-	// 	return pin
-	// }
-
-	// Validate operation
-	if pin.Operation != "create" && pin.Operation != "modify" && pin.Operation != "revoke" {
-		return nil
-	}
-
-	// For revoke, we need at least 6 fields; for others, at least 7
-	if pin.Operation == "revoke" && len(infoList) < 6 {
-		return nil
-	}
-	if pin.Operation != "revoke" && len(infoList) < 7 {
-		return nil
-	}
-
-	// Parse content type (field 2)
-	contentType := "application/json"
-	if len(infoList) > 2 && len(infoList[2]) > 0 {
-		contentType = common.NormalizeContentType(string(infoList[2]))
-	}
-	pin.ContentType = contentType
-
-	// Parse encryption (field 3)
-	encryption := "0"
-	if len(infoList) > 3 && len(infoList[3]) > 0 {
-		encryption = string(infoList[3])
-	}
-	pin.Encryption = encryption
-
-	// Parse version (field 4)
-	version := "0"
-	if len(infoList) > 4 && len(infoList[4]) > 0 {
-		version = string(infoList[4])
-	}
-	pin.Version = version
-
-	// Parse field 5: address:path format
-	// Example: "bc1p20k3x2c4mglfxr5wa5sgtgechwstpld80kru2cg4gmm4urvuaqqsvapxu0:/protocols/simplegroupchat"
-	if len(infoList) > 5 && len(infoList[5]) > 0 {
-		field5 := string(infoList[5])
-		// Split by colon to separate address and path
-		parts := strings.SplitN(field5, ":", 2)
-		if len(parts) == 2 {
-			// Use the path part (after colon)
-			pin.Path = common.NormalizePath(parts[1])
-		} else {
-			// If no colon found, treat entire field as path
-			pin.Path = common.NormalizePath(field5)
-		}
-	} else {
-		// Default path if field 5 is missing
-		pin.Path = "/info"
-	}
-	pin.ParentPath = common.GetParentPath(pin.Path)
-
-	// Parse content body (field 6 onwards)
-	// Stop if this looks like a signature (starts with 0x30 and is 70-73 bytes)
-	// or a pubkey (33 or 65 bytes starting with 0x02, 0x03, 0x04)
-	var body []byte
-	for i := 6; i < len(infoList); i++ {
-		data := infoList[i]
-		// Stop if this looks like a signature or pubkey
-		if len(data) >= 70 && len(data) <= 73 && data[0] == 0x30 {
-			break
-		}
-		if (len(data) == 33 || len(data) == 65) && (data[0] == 0x02 || data[0] == 0x03 || data[0] == 0x04) {
-			break
-		}
-		body = append(body, data...)
-	}
-
-	pin.ContentBody = body
-	pin.ContentLength = uint64(len(body))
-
-	return pin
-}
-
-// parseOnePin parses a single PIN data
-func (p *DOGEParser) parseOnePin(infoList [][]byte) *decoder.Pin {
-	if len(infoList) < 1 {
-		return nil
-	}
-
-	pin := &decoder.Pin{}
-	pin.Operation = strings.ToLower(string(infoList[0]))
-
-	// Special case: init operation
-	if pin.Operation == "init" {
-		pin.Path = "/"
-		pin.ParentPath = ""
-		pin.Encryption = "0"
-		pin.Version = "0"
-		pin.ContentType = "application/json"
+// parseScriptSig parses the PIN of a ScriptSig
+// The direct format, with metaid data at the beginning of the ScriptSig, is tried first:
+//
+//	<protocolID> <operation> <contentType> <encryption> <version> <address:path> <content...> <signature> <redeemScript or pubkey>
+//
+// The content ends where the spending data starts, see envelope.DirectScriptSigPushes.
+// Otherwise the last push is taken as a P2SH redeem script:
+//
+//	<pubkey> OP_CHECKSIGVERIFY OP_FALSE OP_IF <protocolID> <operation> <path> <encryption> <version> <contentType> <content...> OP_ENDIF
+func (p *DOGEParser) parseScriptSig(scriptSig []byte) *decoder.Pin {
+	if pin := envelope.DOGEDirect.Decode(p.config, envelope.DirectScriptSigPushes(scriptSig)); pin != nil {
 		return pin
 	}
-
-	// revoke operation requires at least 5 fields
-	if pin.Operation == "revoke" && len(infoList) < 5 {
-		return nil
-	}
-
-	// Other operations require at least 6 fields
-	if len(infoList) < 6 && pin.Operation != "revoke" {
+	redeemScript := envelope.LastPush(scriptSig)
+	if len(redeemScript) == 0 {
 		return nil
 	}
-
-	// Parse each field
-	pin.Path = common.NormalizePath(string(infoList[1]))
-	pin.ParentPath = common.GetParentPath(pin.Path)
-
-	encryption := "0"
-	if len(infoList) > 2 && infoList[2] != nil {
-		encryption = string(infoList[2])
-	}
-	pin.Encryption = encryption
-
-	version := "0"
-	if len(infoList) > 3 && infoList[3] != nil {
-		version = string(infoList[3])
-	}
-	pin.Version = version
-
-	contentType := "application/json"
-	if len(infoList) > 4 && infoList[4] != nil {
-		contentType = common.NormalizeContentType(string(infoList[4]))
-	}
-	pin.ContentType = contentType
-
-	// Merge remaining body data
-	var body []byte
-	for i := 5; i < len(infoList); i++ {
-		body = append(body, infoList[i]...)
-	}
-	pin.ContentBody = body
-	pin.ContentLength = uint64(len(body))
-
-	return pin
-}
-
-// getScriptSigOwner gets the owner of a ScriptSig format PIN
-func (p *DOGEParser) getScriptSigOwner(tx *wire.MsgTx, inIdx int, params *chaincfg.Params) (address string, vout int, outValue int64, locationIdx int64) {
-	// Simple case: single input or single output
-	if len(tx.TxIn) == 1 || len(tx.TxOut) == 1 || inIdx == 0 {
-		if len(tx.TxOut) > 0 {
-			_, addresses, _, _ := txscript.ExtractPkScriptAddrs(tx.TxOut[0].PkScript, params)
-			if len(addresses) > 0 {
-				address = addresses[0].EncodeAddress()
-				vout = 0
-				outValue = tx.TxOut[0].Value
-				locationIdx = 0
-			}
-		}
-		return
-	}
-
-	// For multiple inputs/outputs, return the first output
-	// Note: Complete owner determination requires querying input transactions, which needs an external node service
-	// Here we simplify by only returning the first valid output
-	if len(tx.TxOut) > 0 {
-		_, addresses, _, _ := txscript.ExtractPkScriptAddrs(tx.TxOut[0].PkScript, params)
-		if len(addresses) > 0 {
-			address = addresses[0].EncodeAddress()
-			vout = 0
-			outValue = tx.TxOut[0].Value
-			locationIdx = 0
-		}
-	}
-
-	return
+	return envelope.DOGE.Decode(p.config, envelope.RedeemScriptPushes(redeemScript))
 }
 
 // DogeMainNetParams defines the network parameters for the main Dogecoin network.
@@ -428,7 +151,7 @@ var DogeMainNetParams = chaincfg.Params{
 // DogeTestNetParams defines the network parameters for the test Dogecoin network.
 var DogeTestNetParams = chaincfg.Params{
 	Name:        "testnet",
-	Net:         wire.BitcoinNet(0xfcc1b7dc), // Dogecoin TestNet magic
+	Net:         wire.BitcoinNet(0xdcb7c1fc), // Dogecoin TestNet magic, fc c1 b7 dc on the wire
 	DefaultPort: "44556",
 	DNSSeeds: []chaincfg.DNSSeed{
 		{Host: "testseed.jrn.me.uk", HasFiltering: true},
@@ -446,7 +169,7 @@ var DogeTestNetParams = chaincfg.Params{
 // DogeRegTestParams defines the network parameters for the regression test Dogecoin network.
 var DogeRegTestParams = chaincfg.Params{
 	Name:             "regtest",
-	Net:              wire.BitcoinNet(0xfabfb5da), // Dogecoin RegTest magic
+	Net:              wire.BitcoinNet(0xdab5bffa), // Dogecoin RegTest magic, fa bf b5 da on the wire
 	DefaultPort:      "18444",
 	DNSSeeds:         []chaincfg.DNSSeed{},
 	GenesisHash:      newHashFromStr("3d2160a3b5dc4a9d62e7404bb5aa85b0183cd8db1d244508f6003d23713e8819"),
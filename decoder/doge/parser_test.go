@@ -1,10 +1,19 @@
 package doge
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
 	"github.com/metaid-developers/metaid-script-decoder/decoder"
 )
 
@@ -93,3 +102,78 @@ func TestParseTransaction_ValidData(t *testing.T) {
 		t.Log("No pins found in transaction (this is expected if transaction doesn't contain metaid data)")
 	}
 }
+
+func TestParseTransaction_DirectBinaryBody(t *testing.T) {
+	privKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	pubKey := privKey.PubKey().SerializeCompressed()
+	signature := append(ecdsa.Sign(privKey, bytes.Repeat([]byte{0x02}, 32)).Serialize(), byte(txscript.SigHashAll))
+
+	// Binary chunks shaped like a DER signature and a compressed pubkey
+	chunks := [][]byte{
+		append([]byte{0x30}, bytes.Repeat([]byte{0x44}, 70)...),
+		append([]byte{0x03}, bytes.Repeat([]byte{0x99}, 32)...),
+	}
+	builder := txscript.NewScriptBuilder().
+		AddData([]byte("metaid")).
+		AddData([]byte("create")).
+		AddData([]byte("application/octet-stream")).
+		AddData([]byte("0")).
+		AddData([]byte("1.0.0")).
+		AddData([]byte("DAddr:/file/blob"))
+	for _, chunk := range chunks {
+		builder.AddData(chunk)
+	}
+	redeemScript, _ := txscript.NewScriptBuilder().
+		AddData(pubKey).
+		AddOp(txscript.OP_CHECKSIGVERIFY).
+		AddOp(txscript.OP_2DROP).AddOp(txscript.OP_2DROP).AddOp(txscript.OP_2DROP).AddOp(txscript.OP_2DROP).
+		AddOp(txscript.OP_TRUE).
+		Script()
+	scriptSig, err := builder.AddData(signature).AddData(redeemScript).Script()
+	if err != nil {
+		t.Fatalf("Failed to build ScriptSig: %v", err)
+	}
+
+	msgTx := wire.NewMsgTx(1)
+	prevHash := chainhash.Hash{0x01}
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 1), scriptSig, nil))
+	msgTx.AddTxOut(wire.NewTxOut(100000, append([]byte{txscript.OP_DUP, txscript.OP_HASH160, 0x14}, append(bytes.Repeat([]byte{0x11}, 20), txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)...)))
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	pins, err := NewDOGEParser(nil).ParseTransaction(buf.Bytes(), nil)
+	if err != nil {
+		t.Fatalf("ParseTransaction failed: %v", err)
+	}
+	if len(pins) != 1 {
+		t.Fatalf("Expected 1 pin, got %d", len(pins))
+	}
+	if want := bytes.Join(chunks, nil); !bytes.Equal(pins[0].ContentBody, want) {
+		t.Errorf("Expected the binary body to be kept intact, got %d bytes", len(pins[0].ContentBody))
+	}
+	if pins[0].Path != "/file/blob" || pins[0].CreatorInputLocation != prevHash.String()+":1" {
+		t.Errorf("Unexpected path %q / creator input %q", pins[0].Path, pins[0].CreatorInputLocation)
+	}
+}
+
+func TestParams_NetworkMagic(t *testing.T) {
+	// pchMessageStart of Dogecoin Core, in wire order
+	tests := []struct {
+		name   string
+		params *chaincfg.Params
+		want   string
+	}{
+		{"mainnet", &DogeMainNetParams, "c0c0c0c0"},
+		{"testnet", &DogeTestNetParams, "fcc1b7dc"},
+		{"regtest", &DogeRegTestParams, "fabfb5da"},
+	}
+	for _, tt := range tests {
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], uint32(tt.params.Net))
+		if got := hex.EncodeToString(buf[:]); got != tt.want {
+			t.Errorf("%s: magic = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
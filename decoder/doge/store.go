@@ -0,0 +1,93 @@
+package doge
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Part is a multi-transaction inscription waiting for its next transaction
+type Part struct {
+	TxID        string    // Transaction carrying the latest part
+	Vout        uint32    // Output of TxID the next part spends
+	FirstTxID   string    // Transaction carrying the first part
+	CreatorTxID string    // Previous transaction of the input carrying the first part
+	CreatorVout uint32    // Previous output index of the input carrying the first part
+	CreatorVin  int       // Index of the input carrying the first part in the first transaction
+	Header      [][]byte  // Envelope fields of the first part: operation, content type, encryption, version, path
+	Body        []byte    // Body pieces received so far
+	Remaining   int       // Number of pieces still expected
+	Updated     time.Time // Time the latest part was added
+}
+
+// Outpoint returns the txid:vout of the output the next part spends, the key of the part in a PartStore
+// Several inscriptions can be continued from the same transaction, each through its own output.
+func (p *Part) Outpoint() string {
+	return fmt.Sprintf("%s:%d", p.TxID, p.Vout)
+}
+
+// PartStore is the interface for pending part storage used by the assembler
+// The next part of an inscription may be mined blocks later, so a persistent implementation lets
+// an indexer pick up a spending chain it was following before a restart.
+type PartStore interface {
+	// PutPart stores a pending inscription under part.Outpoint()
+	PutPart(part *Part) error
+	// GetPart returns the pending inscription continued by spending outpoint, formatted as txid:vout
+	GetPart(outpoint string) (*Part, bool, error)
+	// DeletePart removes a pending inscription
+	DeletePart(outpoint string) error
+	// StaleParts returns the outpoints of pending inscriptions last updated before t
+	StaleParts(before time.Time) ([]string, error)
+}
+
+// MemoryStore is an in-memory PartStore
+type MemoryStore struct {
+	mu    sync.RWMutex
+	parts map[string]*Part
+}
+
+// NewMemoryStore creates an in-memory part store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		parts: make(map[string]*Part),
+	}
+}
+
+// PutPart implements PartStore
+func (s *MemoryStore) PutPart(part *Part) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parts[part.Outpoint()] = part
+	return nil
+}
+
+// GetPart implements PartStore
+func (s *MemoryStore) GetPart(outpoint string) (*Part, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	part, ok := s.parts[outpoint]
+	return part, ok, nil
+}
+
+// DeletePart implements PartStore
+func (s *MemoryStore) DeletePart(outpoint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.parts, outpoint)
+	return nil
+}
+
+// StaleParts implements PartStore
+func (s *MemoryStore) StaleParts(before time.Time) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var outpoints []string
+	for outpoint, part := range s.parts {
+		if part.Updated.Before(before) {
+			outpoints = append(outpoints, outpoint)
+		}
+	}
+	sort.Strings(outpoints)
+	return outpoints, nil
+}
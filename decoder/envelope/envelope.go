@@ -0,0 +1,175 @@
+// Package envelope turns the script pushes of a MetaID envelope into a PIN.
+//
+// Chain parsers locate the envelope with a push extractor (WitnessPushes,
+// OpReturnPushes, RedeemScriptPushes, DirectScriptSigPushes), decode it with the
+// chain's Profile and hand the PIN to an owner policy. DecodeWitnessInputs and
+// DecodeOpReturns run that scan over a whole transaction. Field handling lives
+// here once, so every chain shares the same rules; the differences between
+// chains are spelled out in their profiles.
+package envelope
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/common"
+)
+
+// ProtocolMarker is the literal protocol marker some DOGE envelopes push instead of the protocol ID
+const ProtocolMarker = "metaid"
+
+// Layout gives the position of each PIN field in the pushes that follow the protocol ID
+// The operation is always the first field
+type Layout struct {
+	Path        int
+	Encryption  int
+	Version     int
+	ContentType int
+	Body        int // first body push, the body runs to the end of the envelope
+}
+
+// StandardLayout is <operation> <path> <encryption> <version> <content-type> <body...>
+var StandardLayout = Layout{Path: 1, Encryption: 2, Version: 3, ContentType: 4, Body: 5}
+
+// DirectLayout is <operation> <content-type> <encryption> <version> <address:path> <body...>
+// It is used by DOGE envelopes pushed directly in the ScriptSig
+var DirectLayout = Layout{ContentType: 1, Encryption: 2, Version: 3, Path: 4, Body: 5}
+
+// PathStyle tells how a prefix before the path is interpreted
+type PathStyle int
+
+const (
+	// PathPlain uses the whole field as the path
+	PathPlain PathStyle = iota
+	// PathHost splits "host:/path" into Host and Path
+	PathHost
+	// PathAddress drops an "address:" prefix from the path
+	PathAddress
+)
+
+// Profile describes how a chain lays out and interprets envelope fields
+type Profile struct {
+	Name         string
+	Layout       Layout
+	PathStyle    PathStyle
+	MaxPushSize  int    // largest allowed field push, 0 for no limit
+	AcceptMarker bool   // accept ProtocolMarker in place of the configured protocol ID
+	DefaultPath  string // path of a PIN whose path field is missing or empty, "" to keep it empty
+}
+
+// Chain profiles
+var (
+	// BTC is the witness envelope of BTC and the chains sharing it, such as Litecoin
+	BTC = &Profile{Name: "btc", Layout: StandardLayout, MaxPushSize: 520}
+	// MVC is the OP_RETURN envelope of MVC and the other chains of the BSV family
+	MVC = &Profile{Name: "mvc", Layout: StandardLayout, PathStyle: PathHost}
+	// DOGE is the envelope in a DOGE P2SH redeem script
+	DOGE = &Profile{Name: "doge", Layout: StandardLayout, MaxPushSize: 520, AcceptMarker: true}
+	// DOGEDirect is the envelope pushed directly at the start of a DOGE ScriptSig
+	// Its pushes come from DirectScriptSigPushes, which strips the spending data
+	DOGEDirect = &Profile{Name: "doge-direct", Layout: DirectLayout, PathStyle: PathAddress, AcceptMarker: true, DefaultPath: "/info"}
+)
+
+// MatchProtocol checks whether a push is the protocol ID, given in hex
+func (p *Profile) MatchProtocol(push []byte, protocolID string) bool {
+	if hex.EncodeToString(push) == protocolID {
+		return true
+	}
+	return p.AcceptMarker && string(push) == ProtocolMarker
+}
+
+// Decode decodes the pushes of an envelope, starting with the protocol ID
+// The protocol ID and accepted operations are taken from config
+// Returns nil if the pushes do not carry a PIN of the protocol
+func (p *Profile) Decode(config *decoder.ParserConfig, pushes [][]byte) *decoder.Pin {
+	if len(pushes) == 0 || !p.MatchProtocol(pushes[0], config.ProtocolID) {
+		return nil
+	}
+	return p.Parse(config.Operations, pushes[1:])
+}
+
+// Parse builds a PIN from the fields following the protocol ID
+// The operation must be in ops, with at least its required number of fields
+// Returns nil if the fields do not form a valid PIN
+func (p *Profile) Parse(ops common.OperationSet, fields [][]byte) *decoder.Pin {
+	if len(fields) < 1 {
+		return nil
+	}
+	if p.MaxPushSize > 0 {
+		for _, field := range fields {
+			if len(field) > p.MaxPushSize {
+				return nil
+			}
+		}
+	}
+
+	pin := &decoder.Pin{}
+	pin.Operation = strings.ToLower(string(fields[0]))
+
+	spec, ok := ops.Lookup(pin.Operation)
+	if !ok || len(fields) < spec.MinFields {
+		return nil
+	}
+
+	layout := p.Layout
+	if spec.Root {
+		pin.OriginalPath = "/"
+		pin.Path = "/"
+		pin.ParentPath = ""
+	} else {
+		pin.OriginalPath = string(field(fields, layout.Path))
+		pin.Host, pin.Path = p.splitPath(pin.OriginalPath)
+		if pin.OriginalPath == "" && p.DefaultPath != "" {
+			pin.Path = p.DefaultPath
+		}
+		pin.ParentPath = common.GetParentPath(pin.Path)
+	}
+
+	pin.Encryption = fieldOr(fields, layout.Encryption, "0")
+	pin.Version = fieldOr(fields, layout.Version, "0")
+	pin.ContentType = common.NormalizeContentType(fieldOr(fields, layout.ContentType, "application/json"))
+
+	// Merge remaining body data
+	var body []byte
+	for i := layout.Body; i < len(fields); i++ {
+		body = append(body, fields[i]...)
+	}
+	pin.ContentBody = body
+	pin.ContentLength = uint64(len(body))
+
+	return pin
+}
+
+// splitPath splits the path field into host and normalized path
+func (p *Profile) splitPath(original string) (host, path string) {
+	switch p.PathStyle {
+	case PathHost:
+		// Look for ":/" to separate the host, e.g. "example.com:8080:/path"
+		if i := strings.Index(original, ":/"); i > 0 {
+			return original[:i], common.NormalizePath(original[i+1:])
+		}
+	case PathAddress:
+		// e.g. "bc1p20k3...:/protocols/simplegroupchat"
+		if parts := strings.SplitN(original, ":", 2); len(parts) == 2 {
+			return "", common.NormalizePath(parts[1])
+		}
+	}
+	return "", common.NormalizePath(original)
+}
+
+// field returns the field at index i, or nil if it is missing
+func field(fields [][]byte, i int) []byte {
+	if i < len(fields) {
+		return fields[i]
+	}
+	return nil
+}
+
+// fieldOr returns the field at index i as a string, or def if it is missing or empty
+func fieldOr(fields [][]byte, i int, def string) string {
+	if f := field(fields, i); len(f) > 0 {
+		return string(f)
+	}
+	return def
+}
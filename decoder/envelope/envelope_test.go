@@ -0,0 +1,510 @@
+package envelope
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/common"
+)
+
+var config = decoder.DefaultConfig()
+
+func pushes(fields ...string) [][]byte {
+	var out [][]byte
+	for _, f := range fields {
+		out = append(out, []byte(f))
+	}
+	return out
+}
+
+func TestProfileDecode_Standard(t *testing.T) {
+	pin := BTC.Decode(config, pushes("metaid", "Create", "/Protocols/SimpleBuzz", "0", "1.0.0", "application/json", `{"a":`, `1}`))
+	if pin == nil {
+		t.Fatal("Expected a pin")
+	}
+	if pin.Operation != "create" || pin.Path != "/protocols/simplebuzz" || pin.ParentPath != "/protocols" {
+		t.Errorf("Unexpected operation/path: %+v", pin)
+	}
+	if pin.OriginalPath != "/Protocols/SimpleBuzz" {
+		t.Errorf("Expected original path to be kept, got %q", pin.OriginalPath)
+	}
+	if pin.Encryption != "0" || pin.Version != "1.0.0" || pin.ContentType != "application/json" {
+		t.Errorf("Unexpected fields: %+v", pin)
+	}
+	if string(pin.ContentBody) != `{"a":1}` || pin.ContentLength != 7 {
+		t.Errorf("Unexpected body %q", pin.ContentBody)
+	}
+
+	// Protocol ID mismatch
+	if BTC.Decode(config, pushes("other", "create", "/a", "0", "0", "text/plain", "x")) != nil {
+		t.Error("Expected nil for a foreign protocol")
+	}
+	// Too few fields
+	if BTC.Decode(config, pushes("metaid", "create", "/a", "0", "0", "text/plain")) != nil {
+		t.Error("Expected nil for a create without body")
+	}
+	// Revoke needs no body
+	if pin := BTC.Decode(config, pushes("metaid", "revoke", "@abci0", "0", "0", "text/plain")); pin == nil || pin.Operation != "revoke" {
+		t.Errorf("Expected a revoke pin, got %+v", pin)
+	}
+	// Empty fields take defaults
+	pin = BTC.Decode(config, [][]byte{[]byte("metaid"), []byte("create"), []byte("/a/b"), nil, {}, nil, []byte("x")})
+	if pin == nil || pin.Encryption != "0" || pin.Version != "0" || pin.ContentType != "application/json" {
+		t.Errorf("Expected defaults, got %+v", pin)
+	}
+}
+
+func TestProfileDecode_MaxPushSize(t *testing.T) {
+	fields := pushes("metaid", "create", "/a", "0", "0", "text/plain", string(bytes.Repeat([]byte("x"), 521)))
+	if BTC.Decode(config, fields) != nil {
+		t.Error("Expected nil for a push over 520 bytes on BTC")
+	}
+	if MVC.Decode(config, fields) == nil {
+		t.Error("Expected MVC to accept large pushes")
+	}
+}
+
+func TestProfileDecode_Host(t *testing.T) {
+	pin := MVC.Decode(config, pushes("metaid", "create", "example.com:8080:/protocols/simplebuzz", "0", "0", "text/plain", "x"))
+	if pin == nil {
+		t.Fatal("Expected a pin")
+	}
+	if pin.Host != "example.com:8080" || pin.Path != "/protocols/simplebuzz" || pin.ParentPath != "/protocols" {
+		t.Errorf("Unexpected host/path: %q %q %q", pin.Host, pin.Path, pin.ParentPath)
+	}
+
+	// BTC keeps the whole field as the path
+	pin = BTC.Decode(config, pushes("metaid", "create", "example.com:/info", "0", "0", "text/plain", "x"))
+	if pin == nil || pin.Host != "" || pin.Path != "example.com:/info" {
+		t.Errorf("Unexpected BTC host/path: %+v", pin)
+	}
+}
+
+func TestProfileDecode_Init(t *testing.T) {
+	// Every chain accepts a bare init creating the root PIN
+	for _, profile := range []*Profile{BTC, MVC, DOGE, DOGEDirect} {
+		pin := profile.Decode(config, pushes("metaid", "init"))
+		if pin == nil || pin.Path != "/" || pin.ParentPath != "" || pin.ContentType != "application/json" {
+			t.Errorf("%s: expected an init pin, got %+v", profile.Name, pin)
+		}
+	}
+
+	// The path field of an init is ignored, the other fields are kept
+	pin := MVC.Decode(config, pushes("metaid", "init", "host:/info", "0", "1.0.0", "text/plain", "x"))
+	if pin == nil || pin.Path != "/" || pin.Host != "" || pin.Version != "1.0.0" || string(pin.ContentBody) != "x" {
+		t.Errorf("Unexpected init pin %+v", pin)
+	}
+}
+
+func TestProfileDecode_Operations(t *testing.T) {
+	hide := pushes("metaid", "hide", "@abci0", "0", "0", "text/plain")
+	if BTC.Decode(config, hide) != nil {
+		t.Error("Expected hide to be rejected by default")
+	}
+
+	custom := &decoder.ParserConfig{
+		ProtocolID: config.ProtocolID,
+		Operations: common.NewOperationSet(append(common.DefaultOperations(), common.OperationSpec{Name: "hide", MinFields: 5})...),
+	}
+	if pin := BTC.Decode(custom, hide); pin == nil || pin.Operation != "hide" {
+		t.Errorf("Expected a hide pin, got %+v", pin)
+	}
+	if BTC.Decode(custom, hide[:5]) != nil {
+		t.Error("Expected hide with too few fields to be rejected")
+	}
+
+	noInit := &decoder.ParserConfig{ProtocolID: config.ProtocolID, Operations: common.NewOperationSet(common.OpCreate)}
+	if DOGE.Decode(noInit, pushes("metaid", "init")) != nil {
+		t.Error("Expected init to be rejected when not configured")
+	}
+}
+
+func TestProfileDecode_Direct(t *testing.T) {
+	fields := pushes("metaid", "create", "text/plain", "0", "1.0.0", "DAddr:/protocols/simplegroupchat", "hello", " world")
+
+	pin := DOGEDirect.Decode(config, fields)
+	if pin == nil {
+		t.Fatal("Expected a pin")
+	}
+	if pin.Path != "/protocols/simplegroupchat" || pin.Host != "" || pin.ContentType != "text/plain" || pin.Version != "1.0.0" {
+		t.Errorf("Unexpected fields: %+v", pin)
+	}
+	if string(pin.ContentBody) != "hello world" {
+		t.Errorf("Expected body %q, got %q", "hello world", pin.ContentBody)
+	}
+
+	// Custom protocol IDs still accept the literal marker
+	if DOGEDirect.Decode(&decoder.ParserConfig{ProtocolID: "746573746964"}, fields) == nil {
+		t.Error("Expected the metaid marker to be accepted")
+	}
+	// Unknown operations are rejected
+	if DOGEDirect.Decode(config, pushes("metaid", "delete", "text/plain", "0", "0", "a:/b", "x")) != nil {
+		t.Error("Expected nil for an unknown operation")
+	}
+}
+
+func TestProfileDecode_BaselineChanges(t *testing.T) {
+	// The per-chain parsers this package replaced differed in three ways, now aligned across chains
+
+	// MVC set ParentPath to the path itself, it is now the parent like on every other chain
+	pin := MVC.Decode(config, pushes("metaid", "create", "host:/protocols/simplebuzz", "0", "0", "text/plain", "x"))
+	if pin == nil || pin.ParentPath != "/protocols" {
+		t.Errorf("MVC: expected parent path /protocols (was /protocols/simplebuzz), got %+v", pin)
+	}
+
+	// BTC left OriginalPath empty, it now holds the raw path field like on MVC
+	pin = BTC.Decode(config, pushes("metaid", "create", "/Protocols/SimpleBuzz", "0", "0", "text/plain", "x"))
+	if pin == nil || pin.OriginalPath != "/Protocols/SimpleBuzz" {
+		t.Errorf("BTC: expected original path /Protocols/SimpleBuzz (was empty), got %+v", pin)
+	}
+
+	// DOGE direct dropped empty pushes, which shifted every later field; an empty push is now
+	// an empty field taking its default, so the version stays in place
+	fields := [][]byte{[]byte("metaid"), []byte("create"), []byte("text/plain"), {}, []byte("1.0.0"), []byte("D:/a/b"), []byte("x")}
+	pin = DOGEDirect.Decode(config, fields)
+	if pin == nil || pin.Encryption != "0" || pin.Version != "1.0.0" || pin.Path != "/a/b" {
+		t.Errorf("DOGE direct: expected encryption 0, version 1.0.0 and path /a/b (was rejected), got %+v", pin)
+	}
+
+	// DOGE direct still defaults an empty path to /info, as before
+	fields[5] = nil
+	pin = DOGEDirect.Decode(config, fields)
+	if pin == nil || pin.Path != "/info" || pin.ParentPath != common.GetParentPath("/info") {
+		t.Errorf("DOGE direct: expected the /info default path, got %+v", pin)
+	}
+	if pin := BTC.Decode(config, pushes("metaid", "create", "", "0", "0", "text/plain", "x")); pin == nil || pin.Path != "" {
+		t.Errorf("BTC: expected an empty path to stay empty, got %+v", pin)
+	}
+}
+
+func TestWitnessPushes(t *testing.T) {
+	script, _ := txscript.NewScriptBuilder().
+		AddData(bytes.Repeat([]byte{0x02}, 32)).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_IF).
+		AddData([]byte("metaid")).
+		AddData([]byte("create")).
+		AddOp(txscript.OP_ENDIF).
+		Script()
+	got := WitnessPushes(script)
+	if len(got) != 2 || string(got[0]) != "metaid" || string(got[1]) != "create" {
+		t.Errorf("Unexpected pushes %q", got)
+	}
+
+	// OP_FALSE must open an envelope
+	script, _ = txscript.NewScriptBuilder().AddOp(txscript.OP_FALSE).AddOp(txscript.OP_DROP).AddData([]byte("metaid")).Script()
+	if WitnessPushes(script) != nil {
+		t.Error("Expected nil without OP_IF")
+	}
+}
+
+func TestOpReturnPushes(t *testing.T) {
+	for _, prefix := range [][]byte{{txscript.OP_RETURN}, {txscript.OP_FALSE, txscript.OP_RETURN}} {
+		script, _ := txscript.NewScriptBuilder().AddData([]byte("metaid")).AddOp(txscript.OP_1).AddData([]byte("create")).Script()
+		got := OpReturnPushes(append(append([]byte(nil), prefix...), script...))
+		if len(got) != 2 || string(got[0]) != "metaid" || string(got[1]) != "create" {
+			t.Errorf("Unexpected pushes %q", got)
+		}
+	}
+	if OpReturnPushes([]byte{txscript.OP_DUP}) != nil {
+		t.Error("Expected nil for a script without OP_RETURN")
+	}
+	if OpReturnPushes([]byte{txscript.OP_RETURN, 0x05, 0x01}) != nil {
+		t.Error("Expected nil for a truncated push")
+	}
+}
+
+func TestRedeemScriptPushes(t *testing.T) {
+	script, _ := txscript.NewScriptBuilder().
+		AddData(bytes.Repeat([]byte{0x02}, 33)).
+		AddOp(txscript.OP_CHECKSIGVERIFY).
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_IF).
+		AddData([]byte("metaid")).
+		AddOp(txscript.OP_ENDIF).
+		Script()
+	if got := RedeemScriptPushes(script); len(got) != 1 || string(got[0]) != "metaid" {
+		t.Errorf("Unexpected pushes %q", got)
+	}
+	if RedeemScriptPushes(script[34:]) != nil {
+		t.Error("Expected nil without the pubkey and OP_CHECKSIGVERIFY")
+	}
+
+	scriptSig, _ := txscript.NewScriptBuilder().AddData([]byte("sig")).AddData(script).Script()
+	if !bytes.Equal(LastPush(scriptSig), script) {
+		t.Error("Expected the redeem script to be the last push")
+	}
+}
+
+// spendingKey returns a key pair and a strict DER signature with SIGHASH_ALL made by it
+func spendingKey(t *testing.T) (pubKey, signature []byte) {
+	t.Helper()
+	privKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	sig := ecdsa.Sign(privKey, bytes.Repeat([]byte{0x02}, 32))
+	return privKey.PubKey().SerializeCompressed(), append(sig.Serialize(), byte(txscript.SigHashAll))
+}
+
+// dropRedeemScript builds <pubkey> OP_CHECKSIGVERIFY OP_DROP... OP_TRUE dropping n items
+func dropRedeemScript(t *testing.T, pubKey []byte, n int) []byte {
+	t.Helper()
+	builder := txscript.NewScriptBuilder().AddData(pubKey).AddOp(txscript.OP_CHECKSIGVERIFY)
+	for ; n >= 2; n -= 2 {
+		builder.AddOp(txscript.OP_2DROP)
+	}
+	if n == 1 {
+		builder.AddOp(txscript.OP_DROP)
+	}
+	script, err := builder.AddOp(txscript.OP_TRUE).Script()
+	if err != nil {
+		t.Fatalf("Failed to build redeem script: %v", err)
+	}
+	return script
+}
+
+func TestDirectScriptSigPushes(t *testing.T) {
+	pubKey, signature := spendingKey(t)
+	// Body chunks shaped like a signature and a pubkey must be kept
+	sigShaped := append([]byte{0x30}, bytes.Repeat([]byte{0x45}, 70)...)
+	pubKeyShaped := append([]byte{0x02}, bytes.Repeat([]byte{0xff}, 32)...)
+	envelope := append(pushes("metaid", "create", "image/png", "", "1.0.0", "DAddr:/file"), sigShaped, pubKeyShaped)
+
+	scriptSig := func(trailer ...[]byte) []byte {
+		builder := txscript.NewScriptBuilder()
+		for _, push := range append(append([][]byte{}, envelope...), trailer...) {
+			builder.AddData(push)
+		}
+		script, err := builder.Script()
+		if err != nil {
+			t.Fatalf("Failed to build ScriptSig: %v", err)
+		}
+		return script
+	}
+
+	tests := []struct {
+		name      string
+		scriptSig []byte
+		valid     bool
+	}{
+		{"redeem script", scriptSig(signature, dropRedeemScript(t, pubKey, len(envelope))), true},
+		{"pubkey", scriptSig(signature, pubKey), true},
+		{"drop count mismatch", scriptSig(signature, dropRedeemScript(t, pubKey, len(envelope)-1)), false},
+		{"signature shaped", scriptSig(sigShaped, pubKey), false},
+		{"pubkey shaped", scriptSig(signature, pubKeyShaped), false},
+		{"no trailer", scriptSig(), false},
+		{"not push only", append(scriptSig(signature, pubKey), txscript.OP_CHECKSIG), false},
+	}
+	for _, tt := range tests {
+		got := DirectScriptSigPushes(tt.scriptSig)
+		if !tt.valid {
+			if got != nil {
+				t.Errorf("%s: expected nil, got %d pushes", tt.name, len(got))
+			}
+			continue
+		}
+		if len(got) != len(envelope) {
+			t.Fatalf("%s: expected %d pushes, got %d", tt.name, len(envelope), len(got))
+		}
+		for i := range envelope {
+			if !bytes.Equal(got[i], envelope[i]) {
+				t.Errorf("%s: push %d differs: %x", tt.name, i, got[i])
+			}
+		}
+	}
+}
+
+func TestOwnerPolicies(t *testing.T) {
+	outputs := []Output{{Index: 0, Value: 0}, {Index: 1, Value: 546, Address: "addr"}}
+	if owner, ok := FirstOutput(outputs); !ok || owner.Index != 0 {
+		t.Errorf("FirstOutput = %+v, %v", owner, ok)
+	}
+	if owner, ok := FirstAddressOutput(outputs); !ok || owner.Index != 1 {
+		t.Errorf("FirstAddressOutput = %+v, %v", owner, ok)
+	}
+	if _, ok := FirstAddressOutput(outputs[:1]); ok {
+		t.Error("Expected no owner without an address")
+	}
+
+	pin := BTC.Decode(config, pushes("metaid", "create", "/a", "0", "0", "text/plain", "x"))
+	Assign(pin, "btc", "abc", outputs[1])
+	if pin.Id != "abci1" || pin.Vout != 1 || pin.Output != "abc:1" || pin.Location != "abc:1:0" || pin.OutputValue != 546 {
+		t.Errorf("Unexpected location fields: %+v", pin)
+	}
+	if pin.OwnerAddress != "addr" || pin.OwnerMetaId == "" || pin.ChainName != "btc" {
+		t.Errorf("Unexpected owner fields: %+v", pin)
+	}
+}
+
+func TestSequentialOwners(t *testing.T) {
+	outputs := []Output{
+		{Index: 0, Class: "nulldata"},
+		{Index: 1, Value: 1, Address: "a"},
+		{Index: 2, Class: "nulldata"},
+		{Index: 3, Value: 2, Address: "b"},
+		{Index: 4, Class: "nulldata"},
+	}
+	owners := SequentialOwners(outputs, []int{0, 2, 4})
+	if len(owners) != 3 || owners[0].Address != "a" || owners[1].Address != "b" {
+		t.Fatalf("Unexpected owners %+v", owners)
+	}
+	if owners[2].Index != 4 || owners[2].Address != "" {
+		t.Errorf("Expected the third PIN to stay on its own output, got %+v", owners[2])
+	}
+
+	// Without address outputs every PIN stays on its own output
+	owners = SequentialOwners(outputs[:1], []int{0})
+	if owners[0].Index != 0 || owners[0].Class != "nulldata" {
+		t.Errorf("Unexpected owner %+v", owners[0])
+	}
+}
+
+func TestPreferredOwners(t *testing.T) {
+	outputs := []Output{
+		{Index: 0, Value: 5000, Address: "change"},
+		{Index: 1, Value: 1, Address: "holder", Class: "token"},
+		{Index: 2, Class: "nulldata"},
+		{Index: 3, Class: "nulldata"},
+	}
+	token := func(out Output) bool { return out.Class == "token" }
+	owners := PreferredOwners(outputs, []int{2, 3}, token)
+	if owners[0].Address != "holder" || owners[1].Address != "change" {
+		t.Errorf("Expected the token output ahead of the change output, got %+v", owners)
+	}
+	if owners = PreferredOwners(outputs, []int{2}, nil); owners[0].Address != "change" {
+		t.Errorf("Expected a nil preference to keep the output order, got %+v", owners)
+	}
+}
+
+// witnessEnvelope builds a tapscript carrying a PIN at path
+func witnessEnvelope(t *testing.T, path string) []byte {
+	t.Helper()
+	script, err := txscript.NewScriptBuilder().
+		AddData(bytes.Repeat([]byte{0x02}, 32)).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_IF).
+		AddData([]byte("metaid")).
+		AddData([]byte("create")).
+		AddData([]byte(path)).
+		AddData([]byte("0")).
+		AddData([]byte("1.0.0")).
+		AddData([]byte("text/plain")).
+		AddData([]byte("x")).
+		AddOp(txscript.OP_ENDIF).
+		Script()
+	if err != nil {
+		t.Fatalf("Failed to build script: %v", err)
+	}
+	return script
+}
+
+func TestWitnessScript(t *testing.T) {
+	script := []byte{0x51, 0x52}
+	controlBlock := []byte{0xc0, 0x01}
+	annex := []byte{txscript.TaprootAnnexTag, 0x01}
+	tests := []struct {
+		name    string
+		witness wire.TxWitness
+		want    []byte
+	}{
+		{"script path", wire.TxWitness{{0x01}, script, controlBlock}, script},
+		{"annex", wire.TxWitness{script, controlBlock, annex}, annex},
+		{"key path with annex", wire.TxWitness{{0x01}, annex}, nil},
+		{"single item", wire.TxWitness{script}, nil},
+		{"short last item", wire.TxWitness{script, {0xc0}}, nil},
+	}
+	for _, tt := range tests {
+		if got := WitnessScript(tt.witness); !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: got %x, want %x", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeWitnessInputs(t *testing.T) {
+	msgTx := wire.NewMsgTx(2)
+	for i, path := range []string{"/a", "", "/b"} {
+		txIn := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{byte(i + 1)}, uint32(i+5)), nil, nil)
+		if path != "" {
+			txIn.Witness = wire.TxWitness{{0x01}, witnessEnvelope(t, path), {0xc0, 0x01}}
+		}
+		msgTx.AddTxIn(txIn)
+	}
+	msgTx.AddTxOut(wire.NewTxOut(546, []byte{txscript.OP_TRUE}))
+
+	calls := 0
+	pins := BTC.DecodeWitnessInputs(config, "btc", msgTx, func() []Output {
+		calls++
+		return []Output{{Index: 0, Value: 546, Address: "addr"}}
+	})
+	if len(pins) != 2 || calls != 1 {
+		t.Fatalf("Expected 2 PINs and one outputs call, got %d and %d", len(pins), calls)
+	}
+	for k, want := range []struct {
+		path  string
+		index int
+	}{{"/a", 0}, {"/b", 2}} {
+		pin := pins[k]
+		prevOut := msgTx.TxIn[want.index].PreviousOutPoint
+		if pin.Path != want.path || pin.InscriptionTxIndex != want.index || pin.OwnerAddress != "addr" || pin.Id != msgTx.TxHash().String()+"i0" {
+			t.Errorf("Unexpected PIN %+v", pin)
+		}
+		if pin.CreatorInputLocation != fmt.Sprintf("%s:%d", prevOut.Hash, prevOut.Index) {
+			t.Errorf("Unexpected creator input %s", pin.CreatorInputLocation)
+		}
+	}
+
+	// Outputs are not listed for a transaction without PINs
+	if pins := BTC.DecodeWitnessInputs(config, "btc", wire.NewMsgTx(2), func() []Output {
+		t.Error("Unexpected outputs call")
+		return nil
+	}); pins != nil {
+		t.Errorf("Expected no PINs, got %d", len(pins))
+	}
+}
+
+func TestDecodeOpReturns(t *testing.T) {
+	opReturn := func(path string) []byte {
+		script, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_FALSE).AddOp(txscript.OP_RETURN).
+			AddData([]byte("metaid")).AddData([]byte("create")).AddData([]byte(path)).
+			AddData([]byte("0")).AddData([]byte("1.0.0")).AddData([]byte("text/plain")).AddData([]byte("x")).
+			Script()
+		return script
+	}
+	tx := &OpReturnTx{
+		Hash:       "abc",
+		PkScripts:  [][]byte{opReturn("/a"), {txscript.OP_DUP}, opReturn("/b")},
+		FirstInput: &Outpoint{TxID: "prev", Vout: 3},
+		Outputs: func() []Output {
+			return []Output{{Index: 0, Class: "nonstandard"}, {Index: 1, Value: 1, Address: "owner"}, {Index: 2, Class: "nonstandard"}}
+		},
+	}
+	pins := MVC.DecodeOpReturns(config, "mvc", tx)
+	if len(pins) != 2 {
+		t.Fatalf("Expected 2 PINs, got %d", len(pins))
+	}
+	if pins[0].Path != "/a" || pins[0].Id != "abci1" || pins[0].OwnerAddress != "owner" || pins[0].InscriptionTxIndex != 0 {
+		t.Errorf("Unexpected first PIN %+v", pins[0])
+	}
+	if pins[1].Path != "/b" || pins[1].Id != "abci2" || pins[1].OwnerAddress != "" || pins[1].InscriptionTxIndex != 2 {
+		t.Errorf("Unexpected second PIN %+v", pins[1])
+	}
+	for _, pin := range pins {
+		if pin.CreatorInputLocation != "prev:3" || pin.ChainName != "mvc" {
+			t.Errorf("Unexpected creator input or chain %+v", pin)
+		}
+	}
+
+	// Without inputs the creator input stays empty
+	tx.FirstInput = nil
+	if pins := MVC.DecodeOpReturns(config, "mvc", tx); pins[0].CreatorInputLocation != "" {
+		t.Errorf("Expected no creator input, got %q", pins[0].CreatorInputLocation)
+	}
+}
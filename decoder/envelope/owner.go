@@ -0,0 +1,99 @@
+package envelope
+
+import (
+	"fmt"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/common"
+)
+
+// Output is a transaction output a PIN can be assigned to
+type Output struct {
+	Index   int
+	Value   int64
+	Address string // "" if the output pays to no address
+	Class   string // script class, e.g. pubkeyhash, nulldata, metacontract-ft
+}
+
+// OwnerPolicy picks the output that owns a PIN
+// ok is false if no output qualifies
+type OwnerPolicy func(outputs []Output) (owner Output, ok bool)
+
+// FirstOutput assigns the PIN to the first output, as inscriptions on BTC-style chains
+func FirstOutput(outputs []Output) (Output, bool) {
+	if len(outputs) == 0 {
+		return Output{}, false
+	}
+	return outputs[0], true
+}
+
+// FirstAddressOutput assigns the PIN to the first output paying to an address, as on OP_RETURN chains
+func FirstAddressOutput(outputs []Output) (Output, bool) {
+	for _, out := range outputs {
+		if out.Address != "" {
+			return out, true
+		}
+	}
+	return Output{}, false
+}
+
+// SequentialOwners assigns the PINs of a transaction carrying several envelopes, such as OP_RETURN batches
+// pinOutputs are the output indexes of the envelopes in order: the k-th PIN goes to the k-th output paying
+// to an address, and PINs left over stay on their own envelope output so that every PIN keeps a distinct id
+func SequentialOwners(outputs []Output, pinOutputs []int) []Output {
+	return PreferredOwners(outputs, pinOutputs, nil)
+}
+
+// PreferredOwners is SequentialOwners with the outputs matching prefer ahead of the other
+// outputs paying to an address, such as MVC token outputs ahead of a change output.
+// A nil prefer keeps the output order.
+func PreferredOwners(outputs []Output, pinOutputs []int, prefer func(Output) bool) []Output {
+	var candidates, others []Output
+	for _, out := range outputs {
+		switch {
+		case out.Address == "":
+		case prefer == nil || prefer(out):
+			candidates = append(candidates, out)
+		default:
+			others = append(others, out)
+		}
+	}
+	candidates = append(candidates, others...)
+	owners := make([]Output, len(pinOutputs))
+	for k, index := range pinOutputs {
+		switch {
+		case k < len(candidates):
+			owners[k] = candidates[k]
+		case index >= 0 && index < len(outputs):
+			owners[k] = outputs[index]
+		default:
+			owners[k] = Output{Index: index}
+		}
+	}
+	return owners
+}
+
+// SetCreatorInput records the creator input of a PIN, input vin spending prevTxId:prevVout
+// CreatorInputLocation is set to "prevTxId:prevVout" and CreatorInputTxVinLocation to "prevTxId:vin".
+// The creator input is the input carrying the envelope, or the first input for OP_RETURN envelopes
+func SetCreatorInput(pin *decoder.Pin, prevTxID string, prevVout uint32, vin int) {
+	pin.CreatorInputLocation = fmt.Sprintf("%s:%d", prevTxID, prevVout)
+	pin.CreatorInputTxVinLocation = fmt.Sprintf("%s:%d", prevTxID, vin)
+}
+
+// Assign sets the owner, id and location fields of a PIN held by an output
+func Assign(pin *decoder.Pin, chainName, txHash string, owner Output) {
+	pin.Id = fmt.Sprintf("%si%d", txHash, owner.Index)
+	pin.TxID = txHash
+	pin.Vout = uint32(owner.Index)
+	pin.OwnerAddress = owner.Address
+	pin.OwnerMetaId = common.CalculateMetaId(owner.Address)
+	pin.ChainName = chainName
+
+	// PIN location
+	pin.Location = fmt.Sprintf("%s:%d:%d", txHash, owner.Index, 0)
+	pin.Offset = uint64(owner.Index)
+	pin.Output = fmt.Sprintf("%s:%d", txHash, owner.Index)
+	pin.OutputValue = owner.Value
+	pin.OutputClass = owner.Class
+}
@@ -0,0 +1,104 @@
+package envelope
+
+import (
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+// WitnessScript returns the item of a witness that may hold an envelope, or nil
+// That is the second to last item, or the last one when it starts with the taproot annex tag
+func WitnessScript(witness wire.TxWitness) []byte {
+	if len(witness) <= 1 {
+		return nil
+	}
+	last := witness[len(witness)-1]
+	if len(last) <= 1 {
+		return nil
+	}
+	if last[0] == txscript.TaprootAnnexTag {
+		// A key path spend with an annex has no script
+		if len(witness) == 2 {
+			return nil
+		}
+		return last
+	}
+	return witness[len(witness)-2]
+}
+
+// DecodeWitnessInputs decodes the witness envelope of every input of a transaction
+// Each PIN is owned by the first output and its creator input is the input carrying it.
+// outputs is only called when the transaction carries PINs.
+func (p *Profile) DecodeWitnessInputs(config *decoder.ParserConfig, chainName string, msgTx *wire.MsgTx, outputs func() []Output) []*decoder.Pin {
+	var pins []*decoder.Pin
+	var owner Output
+	txHash := ""
+	for i, txIn := range msgTx.TxIn {
+		script := WitnessScript(txIn.Witness)
+		if len(script) == 0 {
+			continue
+		}
+		pin := p.Decode(config, WitnessPushes(script))
+		if pin == nil {
+			continue
+		}
+		if txHash == "" {
+			txHash = msgTx.TxHash().String()
+			owner, _ = FirstOutput(outputs())
+		}
+		Assign(pin, chainName, txHash, owner)
+		pin.InscriptionTxIndex = i
+		SetCreatorInput(pin, txIn.PreviousOutPoint.Hash.String(), txIn.PreviousOutPoint.Index, i)
+		pins = append(pins, pin)
+	}
+	return pins
+}
+
+// Outpoint is the output spent by an input
+type Outpoint struct {
+	TxID string
+	Vout uint32
+}
+
+// OpReturnTx is a transaction of an OP_RETURN chain, reduced to what DecodeOpReturns needs
+// Chains of the BSV family use their own wire types, so the fields are plain values.
+type OpReturnTx struct {
+	Hash       string
+	PkScripts  [][]byte        // locking script of each output
+	FirstInput *Outpoint       // outpoint spent by the first input, nil for a transaction without inputs
+	Outputs    func() []Output // lists the outputs, only called when the transaction carries PINs
+	// PreferOwner selects the outputs owning PINs ahead of the others, nil to keep the output order
+	PreferOwner func(Output) bool
+}
+
+// DecodeOpReturns decodes every metaid OP_RETURN output of a transaction
+// The PINs are owned as given by PreferredOwners with tx.PreferOwner and their creator input is the first input.
+// Every chain accepts the same outputs: a script starting with OP_RETURN or OP_FALSE OP_RETURN
+// and pushing at least the protocol ID and an operation. Such a script is always of the
+// nonstandard class, so no separate script class filter is needed.
+func (p *Profile) DecodeOpReturns(config *decoder.ParserConfig, chainName string, tx *OpReturnTx) []*decoder.Pin {
+	var pins []*decoder.Pin
+	var pinOutputs []int
+	for i, pkScript := range tx.PkScripts {
+		pin := p.Decode(config, OpReturnPushes(pkScript))
+		if pin == nil {
+			continue
+		}
+		pins = append(pins, pin)
+		pinOutputs = append(pinOutputs, i)
+	}
+	if len(pins) == 0 {
+		return nil
+	}
+
+	owners := PreferredOwners(tx.Outputs(), pinOutputs, tx.PreferOwner)
+	for k, pin := range pins {
+		Assign(pin, chainName, tx.Hash, owners[k])
+		pin.InscriptionTxIndex = pinOutputs[k]
+		if tx.FirstInput != nil {
+			SetCreatorInput(pin, tx.FirstInput.TxID, tx.FirstInput.Vout, 0)
+		}
+	}
+	return pins
+}
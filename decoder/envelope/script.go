@@ -0,0 +1,199 @@
+package envelope
+
+import (
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// Push extractors return the pushes of an envelope, starting with the protocol ID,
+// or nil if the script holds no envelope. Opcodes are shared by all supported chains,
+// so the btcd tokenizer is used for every script.
+
+// WitnessPushes extracts the OP_FALSE OP_IF ... OP_ENDIF envelope of a witness or tapscript
+func WitnessPushes(script []byte) [][]byte {
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+	for tokenizer.Next() {
+		if tokenizer.Opcode() == txscript.OP_FALSE {
+			return ifPushes(&tokenizer)
+		}
+	}
+	return nil
+}
+
+// RedeemScriptPushes extracts the envelope of a DOGE P2SH redeem script:
+// <pubkey> OP_CHECKSIGVERIFY OP_FALSE OP_IF <pushes...> OP_ENDIF
+func RedeemScriptPushes(redeemScript []byte) [][]byte {
+	tokenizer := txscript.MakeScriptTokenizer(0, redeemScript)
+
+	// Skip the pubkey and OP_CHECKSIGVERIFY at the beginning
+	if !tokenizer.Next() {
+		return nil
+	}
+	if !tokenizer.Next() || tokenizer.Opcode() != txscript.OP_CHECKSIGVERIFY {
+		return nil
+	}
+	if !tokenizer.Next() || tokenizer.Opcode() != txscript.OP_FALSE {
+		return nil
+	}
+	return ifPushes(&tokenizer)
+}
+
+// ifPushes collects the pushes of an envelope whose OP_FALSE was just read
+func ifPushes(tokenizer *txscript.ScriptTokenizer) [][]byte {
+	if !tokenizer.Next() || tokenizer.Opcode() != txscript.OP_IF {
+		return nil
+	}
+	var pushes [][]byte
+	for tokenizer.Next() {
+		if tokenizer.Opcode() == txscript.OP_ENDIF {
+			break
+		}
+		pushes = append(pushes, tokenizer.Data())
+	}
+	if tokenizer.Err() != nil {
+		return nil
+	}
+	return pushes
+}
+
+// OpReturnPushes extracts the data pushes after OP_RETURN or OP_FALSE OP_RETURN
+// Opcodes other than pushes are skipped
+func OpReturnPushes(pkScript []byte) [][]byte {
+	if len(pkScript) < 1 {
+		return nil
+	}
+	offset := 0
+	if pkScript[0] == txscript.OP_FALSE {
+		offset = 1
+	}
+	if offset >= len(pkScript) || pkScript[offset] != txscript.OP_RETURN {
+		return nil
+	}
+
+	var pushes [][]byte
+	tokenizer := txscript.MakeScriptTokenizer(0, pkScript[offset+1:])
+	for tokenizer.Next() {
+		if tokenizer.Opcode() > txscript.OP_PUSHDATA4 {
+			continue
+		}
+		pushes = append(pushes, tokenizer.Data())
+	}
+	if tokenizer.Err() != nil {
+		return nil
+	}
+	return pushes
+}
+
+// DirectScriptSigPushes extracts the envelope pushed directly at the start of a DOGE ScriptSig
+// The envelope is followed by the data spending the input, in one of two forms:
+//
+//	<pushes...> <signature> <pubkey> OP_CHECKSIGVERIFY OP_DROP... OP_TRUE
+//	<pushes...> <signature> <pubkey>
+//
+// In the first form the last push is a redeem script dropping exactly the envelope pushes,
+// so it gives their count. In the second form the trailing signature and pubkey are checked
+// structurally. Every push before the trailer is kept, whatever its shape; small integer
+// opcodes are returned as their one byte script number.
+// Returns nil if the ScriptSig does not end with a valid trailer
+func DirectScriptSigPushes(scriptSig []byte) [][]byte {
+	var pushes [][]byte
+	tokenizer := txscript.MakeScriptTokenizer(0, scriptSig)
+	for tokenizer.Next() {
+		// A ScriptSig spending the envelope input is push-only
+		opcode := tokenizer.Opcode()
+		switch {
+		case opcode > txscript.OP_16 || opcode == txscript.OP_RESERVED:
+			return nil
+		case opcode >= txscript.OP_1:
+			pushes = append(pushes, []byte{opcode - txscript.OP_1 + 1})
+		case opcode == txscript.OP_1NEGATE:
+			pushes = append(pushes, []byte{0x81})
+		default:
+			pushes = append(pushes, tokenizer.Data())
+		}
+	}
+	if tokenizer.Err() != nil || len(pushes) < 3 {
+		return nil
+	}
+
+	n := len(pushes)
+	last, signature := pushes[n-1], pushes[n-2]
+	if !IsSignature(signature) {
+		return nil
+	}
+	if drops, ok := dropCount(last); ok {
+		if drops != n-2 {
+			return nil
+		}
+		return pushes[:n-2]
+	}
+	if !IsPubKey(last) {
+		return nil
+	}
+	return pushes[:n-2]
+}
+
+// dropCount parses a redeem script of the form <pubkey> OP_CHECKSIGVERIFY OP_DROP... OP_TRUE
+// and returns the number of stack items it drops
+func dropCount(redeemScript []byte) (int, bool) {
+	tokenizer := txscript.MakeScriptTokenizer(0, redeemScript)
+	if !tokenizer.Next() || !IsPubKey(tokenizer.Data()) {
+		return 0, false
+	}
+	if !tokenizer.Next() || tokenizer.Opcode() != txscript.OP_CHECKSIGVERIFY {
+		return 0, false
+	}
+	drops := 0
+	for tokenizer.Next() {
+		switch tokenizer.Opcode() {
+		case txscript.OP_DROP:
+			drops++
+		case txscript.OP_2DROP:
+			drops += 2
+		case txscript.OP_TRUE:
+			// OP_TRUE must end the script
+			if tokenizer.Next() || tokenizer.Err() != nil {
+				return 0, false
+			}
+			return drops, true
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// IsSignature reports whether a push is a strict DER signature followed by a sighash type
+func IsSignature(data []byte) bool {
+	if len(data) < 9 {
+		return false
+	}
+	hashType := txscript.SigHashType(data[len(data)-1]) &^ txscript.SigHashAnyOneCanPay
+	if hashType < txscript.SigHashAll || hashType > txscript.SigHashSingle {
+		return false
+	}
+	_, err := ecdsa.ParseDERSignature(data[:len(data)-1])
+	return err == nil
+}
+
+// IsPubKey reports whether a push is a valid compressed or uncompressed public key
+func IsPubKey(data []byte) bool {
+	if len(data) != 33 && len(data) != 65 {
+		return false
+	}
+	_, err := btcec.ParsePubKey(data)
+	return err == nil
+}
+
+// LastPush returns the last non-empty push of a script, such as the redeem script of a P2SH ScriptSig
+func LastPush(script []byte) []byte {
+	var last []byte
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+	for tokenizer.Next() {
+		if len(tokenizer.Data()) > 0 {
+			last = tokenizer.Data()
+		}
+	}
+	return last
+}
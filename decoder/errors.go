@@ -0,0 +1,11 @@
+package decoder
+
+import "errors"
+
+// Errors returned by chain parsers, wrapped with details
+var (
+	// ErrInvalidChainParams is returned when the chain params do not belong to the chain
+	ErrInvalidChainParams = errors.New("invalid chainParams type")
+	// ErrInvalidTransaction is returned when the transaction bytes cannot be deserialized
+	ErrInvalidTransaction = errors.New("failed to deserialize transaction")
+)
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Format is an output format of an Encoder
+type Format int
+
+const (
+	// NDJSON writes one event record per line
+	NDJSON Format = iota
+	// JSON writes a JSON array of event records, one record per line
+	JSON
+)
+
+// ErrEncoderClosed is returned when encoding after Close
+var ErrEncoderClosed = errors.New("events: encoder closed")
+
+// Encoder streams event records to a writer
+type Encoder struct {
+	w      io.Writer
+	format Format
+	count  int
+	closed bool
+}
+
+// NewEncoder creates an encoder writing to w
+func NewEncoder(w io.Writer, format Format) *Encoder {
+	return &Encoder{w: w, format: format}
+}
+
+// Encode writes events
+func (e *Encoder) Encode(events ...Event) error {
+	if e.closed {
+		return ErrEncoderClosed
+	}
+	for _, event := range events {
+		record, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		var prefix string
+		if e.format == JSON {
+			prefix = ",\n  "
+			if e.count == 0 {
+				prefix = "[\n  "
+			}
+		}
+		if _, err := io.WriteString(e.w, prefix); err != nil {
+			return err
+		}
+		if e.format == NDJSON {
+			record = append(record, '\n')
+		}
+		if _, err := e.w.Write(record); err != nil {
+			return err
+		}
+		e.count++
+	}
+	return nil
+}
+
+// Close ends the output, closing the JSON array
+// It does not close the underlying writer.
+func (e *Encoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	if e.format != JSON {
+		return nil
+	}
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
@@ -0,0 +1,186 @@
+// Package events turns parser output into a stable, versioned event format for indexers.
+//
+// Events are pin.created, pin.modified, pin.revoked and pin.transferred. The first three
+// come from decoded PINs; transfers come from the UTXO state machine of an indexer, which
+// follows PIN outputs as they are spent. The record layout is described by the JSON
+// Schema in event.schema.json, see JSONSchema, and only changes with SchemaVersion.
+package events
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/common"
+)
+
+// SchemaVersion is the version of the event record layout
+// It is bumped on any change that is not a new optional field.
+const SchemaVersion = 1
+
+// Event types
+const (
+	TypePinCreated     = "pin.created"
+	TypePinModified    = "pin.modified"
+	TypePinRevoked     = "pin.revoked"
+	TypePinTransferred = "pin.transferred"
+)
+
+// JSONSchema is the JSON Schema of event records
+//
+//go:embed event.schema.json
+var JSONSchema []byte
+
+// Event is an indexer event
+type Event struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	Id            string    `json:"id"` // Deterministic event ID, see EventID
+	Type          string    `json:"type"`
+	Chain         string    `json:"chain"`
+	PinId         string    `json:"pinId"`
+	Block         *Block    `json:"block,omitempty"`    // Block context, nil for mempool transactions
+	Pin           *Pin      `json:"pin,omitempty"`      // Set on pin.created, pin.modified and pin.revoked
+	Transfer      *Transfer `json:"transfer,omitempty"` // Set on pin.transferred
+}
+
+// Block is the block context of an event
+type Block struct {
+	Height  int64  `json:"height"`
+	Hash    string `json:"hash"`
+	Time    int64  `json:"time"`    // Block time in Unix seconds
+	TxIndex int    `json:"txIndex"` // Position of the transaction in the block
+}
+
+// Pin is the PIN carried by pin.created, pin.modified and pin.revoked events
+type Pin struct {
+	Operation      string `json:"operation"`
+	Path           string `json:"path"`
+	ParentPath     string `json:"parentPath"`
+	Host           string `json:"host"`
+	Target         string `json:"target,omitempty"` // PIN ID a modify or revoke applies to, from an @pinId path
+	Encryption     string `json:"encryption"`
+	Version        string `json:"version"`
+	ContentType    string `json:"contentType"`
+	ContentBody    []byte `json:"contentBody"` // Base64 in JSON
+	ContentLength  uint64 `json:"contentLength"`
+	OwnerAddress   string `json:"ownerAddress"`
+	OwnerMetaId    string `json:"ownerMetaId"`
+	CreatorAddress string `json:"creatorAddress"`
+	CreatorMetaId  string `json:"creatorMetaId"`
+	TxId           string `json:"txId"`
+	Output         string `json:"output"` // Output holding the PIN, txId:vout
+	OutputValue    int64  `json:"outputValue"`
+}
+
+// Transfer is a PIN output moving to a new owner, as reported by a UTXO state machine
+type Transfer struct {
+	FromAddress string `json:"fromAddress"`
+	ToAddress   string `json:"toAddress"`
+	ToMetaId    string `json:"toMetaId"` // Computed from ToAddress when empty
+	TxId        string `json:"txId"`     // Transaction spending the previous PIN output
+	Output      string `json:"output"`   // New output holding the PIN, txId:vout
+	OutputValue int64  `json:"outputValue"`
+}
+
+// TransferInput is a transfer of a PIN given to FromTransfers
+type TransferInput struct {
+	Chain string
+	PinId string
+	Transfer
+}
+
+// operationTypes maps PIN operations to event types
+// init creates the root PIN of a MetaID, other operations have no event
+var operationTypes = map[string]string{
+	common.OpInit.Name:   TypePinCreated,
+	common.OpCreate.Name: TypePinCreated,
+	common.OpModify.Name: TypePinModified,
+	common.OpRevoke.Name: TypePinRevoked,
+}
+
+// FromPins returns the events of the PINs of one transaction
+// block is the block context, nil for mempool transactions. PINs with operations
+// outside init, create, modify and revoke are skipped.
+func FromPins(pins []*decoder.Pin, block *Block) []Event {
+	var events []Event
+	for _, pin := range pins {
+		eventType, ok := operationTypes[strings.ToLower(pin.Operation)]
+		if !ok {
+			continue
+		}
+		events = append(events, Event{
+			SchemaVersion: SchemaVersion,
+			Id:            EventID(pin.ChainName, eventType, pin.Id, pin.Output),
+			Type:          eventType,
+			Chain:         pin.ChainName,
+			PinId:         pin.Id,
+			Block:         block,
+			Pin:           newPin(pin),
+		})
+	}
+	return events
+}
+
+// FromTransfers returns the events of transfers found in one transaction
+// block is the block context, nil for mempool transactions
+func FromTransfers(transfers []TransferInput, block *Block) []Event {
+	events := make([]Event, 0, len(transfers))
+	for _, in := range transfers {
+		transfer := in.Transfer
+		if transfer.ToMetaId == "" {
+			transfer.ToMetaId = common.CalculateMetaId(transfer.ToAddress)
+		}
+		events = append(events, Event{
+			SchemaVersion: SchemaVersion,
+			Id:            EventID(in.Chain, TypePinTransferred, in.PinId, transfer.Output),
+			Type:          TypePinTransferred,
+			Chain:         in.Chain,
+			PinId:         in.PinId,
+			Block:         block,
+			Transfer:      &transfer,
+		})
+	}
+	return events
+}
+
+// EventID returns the deterministic ID of an event: the hex SHA-256 of the schema version,
+// chain, event type, PIN ID and the output the event leaves the PIN in.
+// The block context is left out, so an event seen in the mempool and then in a block keeps its ID.
+func EventID(chain, eventType, pinId, output string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{strconv.Itoa(SchemaVersion), chain, eventType, pinId, output}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// newPin converts a decoded PIN to its event form
+func newPin(pin *decoder.Pin) *Pin {
+	p := &Pin{
+		Operation:      strings.ToLower(pin.Operation),
+		Path:           pin.Path,
+		ParentPath:     pin.ParentPath,
+		Host:           pin.Host,
+		Encryption:     pin.Encryption,
+		Version:        pin.Version,
+		ContentType:    pin.ContentType,
+		ContentBody:    pin.ContentBody,
+		ContentLength:  pin.ContentLength,
+		OwnerAddress:   pin.OwnerAddress,
+		OwnerMetaId:    pin.OwnerMetaId,
+		CreatorAddress: pin.CreatorAddress,
+		CreatorMetaId:  pin.CreatorMetaId,
+		TxId:           pin.TxID,
+		Output:         pin.Output,
+		OutputValue:    pin.OutputValue,
+	}
+	if p.ContentBody == nil {
+		p.ContentBody = []byte{}
+	}
+	if p.Operation == common.OpModify.Name || p.Operation == common.OpRevoke.Name {
+		if target, ok := strings.CutPrefix(pin.Path, "@"); ok {
+			p.Target = target
+		}
+	}
+	return p
+}
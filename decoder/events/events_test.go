@@ -0,0 +1,211 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/schema"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+const (
+	createTx = "1111111111111111111111111111111111111111111111111111111111111111"
+	modifyTx = "2222222222222222222222222222222222222222222222222222222222222222"
+	revokeTx = "3333333333333333333333333333333333333333333333333333333333333333"
+	spendTx  = "4444444444444444444444444444444444444444444444444444444444444444"
+	owner    = "12ZEw5Hcv1hTb6YUQJ69y1V7uhcoDz92PH"
+	receiver = "1111111111111111111114oLvT2"
+)
+
+// decodedPin builds a parser PIN
+func decodedPin(txId, operation, path string, body []byte) *decoder.Pin {
+	return &decoder.Pin{
+		Id:            txId + "i0",
+		ChainName:     "btc",
+		Operation:     operation,
+		Path:          path,
+		ParentPath:    "/protocols",
+		Encryption:    "0",
+		Version:       "1.0.0",
+		ContentType:   "application/json",
+		ContentBody:   body,
+		ContentLength: uint64(len(body)),
+		OwnerAddress:  owner,
+		OwnerMetaId:   "owner-metaid",
+		TxID:          txId,
+		Output:        txId + ":0",
+		OutputValue:   546,
+	}
+}
+
+// sampleEvents covers every event type, in and out of blocks
+func sampleEvents() []Event {
+	block := &Block{Height: 840000, Hash: "00000000000000000000" + createTx[:44], Time: 1713571767, TxIndex: 7}
+	var events []Event
+	events = append(events, FromPins([]*decoder.Pin{decodedPin(createTx, "create", "/protocols/simplebuzz", []byte(`{"content":"hello"}`))}, block)...)
+	events = append(events, FromPins([]*decoder.Pin{
+		decodedPin(modifyTx, "modify", "@"+createTx+"i0", []byte(`{"content":"edited"}`)),
+		decodedPin(modifyTx, "hide", "/protocols/simplebuzz", nil),
+	}, nil)...)
+	events = append(events, FromPins([]*decoder.Pin{decodedPin(revokeTx, "REVOKE", "@"+createTx+"i0", nil)}, block)...)
+	events = append(events, FromTransfers([]TransferInput{{
+		Chain: "btc",
+		PinId: createTx + "i0",
+		Transfer: Transfer{
+			FromAddress: owner,
+			ToAddress:   receiver,
+			TxId:        spendTx,
+			Output:      spendTx + ":1",
+			OutputValue: 546,
+		},
+	}}, block)...)
+	return events
+}
+
+// golden compares data with a golden file, or updates it with -update
+func golden(t *testing.T, name string, data []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("Failed to update %s: %v", path, err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("Output differs from %s, run go test -update to accept:\n%s", path, data)
+	}
+}
+
+func TestFromPins(t *testing.T) {
+	events := sampleEvents()
+	if len(events) != 4 {
+		t.Fatalf("Expected 4 events, the hide PIN skipped, got %d", len(events))
+	}
+	types := []string{TypePinCreated, TypePinModified, TypePinRevoked, TypePinTransferred}
+	for i, event := range events {
+		if event.Type != types[i] || event.SchemaVersion != SchemaVersion {
+			t.Errorf("Event %d: expected %s v%d, got %s v%d", i, types[i], SchemaVersion, event.Type, event.SchemaVersion)
+		}
+	}
+	if events[1].Block != nil {
+		t.Error("Expected no block context for a mempool PIN")
+	}
+	if events[1].Pin.Target != createTx+"i0" || events[2].Pin.Target != createTx+"i0" || events[0].Pin.Target != "" {
+		t.Errorf("Unexpected targets %q %q %q", events[0].Pin.Target, events[1].Pin.Target, events[2].Pin.Target)
+	}
+	if events[2].Pin.Operation != "revoke" {
+		t.Errorf("Expected the operation in lower case, got %q", events[2].Pin.Operation)
+	}
+	if events[3].Transfer.ToMetaId == "" {
+		t.Error("Expected ToMetaId computed from ToAddress")
+	}
+}
+
+func TestEventID(t *testing.T) {
+	pin := decodedPin(createTx, "create", "/protocols/simplebuzz", []byte("{}"))
+	mempool := FromPins([]*decoder.Pin{pin}, nil)[0]
+	mined := FromPins([]*decoder.Pin{pin}, &Block{Height: 1, Hash: "ab"})[0]
+	if mempool.Id != mined.Id {
+		t.Errorf("Expected the same ID in the mempool and in a block, got %s and %s", mempool.Id, mined.Id)
+	}
+	ids := make(map[string]bool)
+	for _, event := range sampleEvents() {
+		if ids[event.Id] {
+			t.Errorf("Duplicate event ID %s", event.Id)
+		}
+		ids[event.Id] = true
+	}
+	if EventID("btc", TypePinCreated, "a", "b") == EventID("mvc", TypePinCreated, "a", "b") {
+		t.Error("Expected the chain in the event ID")
+	}
+}
+
+func TestEncoder_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, NDJSON)
+	if err := enc.Encode(sampleEvents()...); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	golden(t, "events.ndjson", buf.Bytes())
+
+	// Every line is a record valid against the schema and decodes back to its event
+	s, err := schema.Compile(JSONSchema)
+	if err != nil {
+		t.Fatalf("Failed to compile the event schema: %v", err)
+	}
+	events := sampleEvents()
+	scanner := bufio.NewScanner(&buf)
+	for i := 0; scanner.Scan(); i++ {
+		if errs := s.ValidateJSON(scanner.Bytes()); len(errs) > 0 {
+			t.Errorf("Line %d does not match the schema: %v", i, errs)
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Line %d: %v", i, err)
+		}
+		again, _ := json.Marshal(event)
+		want, _ := json.Marshal(events[i])
+		if !bytes.Equal(again, want) {
+			t.Errorf("Line %d does not round-trip:\n%s\n%s", i, again, want)
+		}
+	}
+}
+
+func TestEncoder_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, JSON)
+	events := sampleEvents()
+	enc.Encode(events[:2]...)
+	enc.Encode(events[2:]...)
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	golden(t, "events.json", buf.Bytes())
+
+	var decoded []Event
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not a JSON array: %v", err)
+	}
+	if len(decoded) != len(events) {
+		t.Errorf("Expected %d records, got %d", len(events), len(decoded))
+	}
+	if err := enc.Encode(events[0]); err != ErrEncoderClosed {
+		t.Errorf("Expected ErrEncoderClosed, got %v", err)
+	}
+
+	buf.Reset()
+	NewEncoder(&buf, JSON).Close()
+	if buf.String() != "[]\n" {
+		t.Errorf("Expected an empty array, got %q", buf.String())
+	}
+}
+
+func TestJSONSchema_RejectsInvalid(t *testing.T) {
+	s, err := schema.Compile(JSONSchema)
+	if err != nil {
+		t.Fatalf("Failed to compile the event schema: %v", err)
+	}
+	for _, record := range []string{
+		`{"schemaVersion":2,"id":"` + EventID("btc", "pin.created", "a", "b") + `","type":"pin.created","chain":"btc","pinId":"a"}`,
+		`{"schemaVersion":1,"id":"x","type":"pin.created","chain":"btc","pinId":"a"}`,
+		`{"schemaVersion":1,"id":"` + EventID("btc", "pin.burned", "a", "b") + `","type":"pin.burned","chain":"btc","pinId":"a"}`,
+	} {
+		if errs := s.ValidateJSON([]byte(record)); len(errs) == 0 {
+			t.Errorf("Expected %s to be rejected", record)
+		}
+	}
+}
@@ -12,6 +12,7 @@ func NewConfigWithProtocol(protocolID string) *ParserConfig {
 }
 
 // NewConfigWithResolver creates a complete configuration with CreatorResolver
+// Set ResolveCreators on the result to have parsers call the resolver
 func NewConfigWithResolver(protocolID string, creatorResolver CreatorResolver) *ParserConfig {
 	if protocolID == "" {
 		protocolID = "6d6574616964"
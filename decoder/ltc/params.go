@@ -0,0 +1,76 @@
+package ltc
+
+import (
+	"math/big"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// LTCMainNetParams defines the network parameters for the main Litecoin network.
+var LTCMainNetParams = chaincfg.Params{
+	Name:        "mainnet",
+	Net:         wire.BitcoinNet(0xdbb6c0fb), // Litecoin MainNet magic
+	DefaultPort: "9333",
+	DNSSeeds: []chaincfg.DNSSeed{
+		{Host: "seed-a.litecoin.loshan.co.uk", HasFiltering: true},
+		{Host: "dnsseed.thrasher.io", HasFiltering: true},
+		{Host: "dnsseed.litecointools.com", HasFiltering: false},
+		{Host: "dnsseed.litecoinpool.org", HasFiltering: false},
+	},
+	GenesisHash:      newHashFromStr("12a765e31ffd4059bada1e25190f6e98c99d9714d334efa41a195a7e7e04bfe2"),
+	PowLimit:         newBigIntFromHex("00000fffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+	CoinbaseMaturity: 100,
+	PubKeyHashAddrID: 0x30,  // starts with L
+	ScriptHashAddrID: 0x32,  // starts with M
+	PrivateKeyID:     0xb0,  // starts with 6 or T
+	Bech32HRPSegwit:  "ltc", // starts with ltc1
+	HDCoinType:       2,
+}
+
+// LTCTestNetParams defines the network parameters for the test Litecoin network (testnet4).
+var LTCTestNetParams = chaincfg.Params{
+	Name:        "testnet4",
+	Net:         wire.BitcoinNet(0xf1c8d2fd), // Litecoin TestNet magic
+	DefaultPort: "19335",
+	DNSSeeds: []chaincfg.DNSSeed{
+		{Host: "testnet-seed.litecointools.com", HasFiltering: false},
+		{Host: "seed-b.litecoin.loshan.co.uk", HasFiltering: true},
+		{Host: "dnsseed-testnet.thrasher.io", HasFiltering: true},
+	},
+	GenesisHash:      newHashFromStr("4966625a4b2851d9fdee139e56211a0d88575f59ed816ff5e6a63deb4e3e29a0"),
+	PowLimit:         newBigIntFromHex("00000fffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+	CoinbaseMaturity: 100,
+	PubKeyHashAddrID: 0x6f,   // starts with m or n
+	ScriptHashAddrID: 0x3a,   // starts with Q
+	PrivateKeyID:     0xef,   // starts with 9 or c
+	Bech32HRPSegwit:  "tltc", // starts with tltc1
+	HDCoinType:       1,
+}
+
+// LTCRegTestParams defines the network parameters for the regression test Litecoin network.
+var LTCRegTestParams = chaincfg.Params{
+	Name:             "regtest",
+	Net:              wire.BitcoinNet(0xdab5bffa), // Litecoin RegTest magic
+	DefaultPort:      "19444",
+	DNSSeeds:         []chaincfg.DNSSeed{},
+	GenesisHash:      newHashFromStr("530827f38f93b43ed12af0b3ad25a288dc02ed74d6d7857862df51fc56c416f9"),
+	PowLimit:         newBigIntFromHex("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+	CoinbaseMaturity: 100,
+	PubKeyHashAddrID: 0x6f, // starts with m or n
+	ScriptHashAddrID: 0x3a, // starts with Q
+	PrivateKeyID:     0xef,
+	Bech32HRPSegwit:  "rltc", // starts with rltc1
+	HDCoinType:       1,
+}
+
+func newHashFromStr(str string) *chainhash.Hash {
+	hash, _ := chainhash.NewHashFromStr(str)
+	return hash
+}
+
+func newBigIntFromHex(str string) *big.Int {
+	i, _ := new(big.Int).SetString(str, 16)
+	return i
+}
@@ -0,0 +1,62 @@
+package ltc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/btc"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/envelope"
+)
+
+func init() {
+	decoder.RegisterChain("ltc", func(config *decoder.ParserConfig) decoder.ChainParser {
+		return NewLTCParser(config)
+	}, "litecoin")
+}
+
+// LTCParser is the LTC chain parser
+type LTCParser struct {
+	config *decoder.ParserConfig
+}
+
+// NewLTCParser creates an LTC parser
+func NewLTCParser(config *decoder.ParserConfig) *LTCParser {
+	if config == nil {
+		config = decoder.DefaultConfig()
+	}
+	return &LTCParser{
+		config: config,
+	}
+}
+
+// GetChainName returns the chain name
+func (p *LTCParser) GetChainName() string {
+	return "ltc"
+}
+
+// ParseTransaction parses an LTC transaction
+func (p *LTCParser) ParseTransaction(txBytes []byte, chainParams interface{}) ([]*decoder.Pin, error) {
+	// Parse chainParams
+	params, ok := chainParams.(*chaincfg.Params)
+	if !ok && chainParams != nil {
+		return nil, fmt.Errorf("%w for LTC, expected *chaincfg.Params", decoder.ErrInvalidChainParams)
+	}
+	if params == nil {
+		params = &LTCMainNetParams
+	}
+
+	// Deserialize transaction, skipping MWEB extension data
+	msgTx, err := DeserializeTx(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", decoder.ErrInvalidTransaction, err)
+	}
+
+	// Litecoin inscriptions use the BTC Witness format, assigned to the first output as on BTC
+	pins := envelope.BTC.DecodeWitnessInputs(p.config, "ltc", msgTx, func() []envelope.Output {
+		return btc.Outputs(msgTx, params)
+	})
+
+	return decoder.ProcessPins(p.config, pins), nil
+}
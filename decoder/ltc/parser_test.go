@@ -0,0 +1,209 @@
+package ltc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+// buildRevealTx builds a Litecoin-shaped taproot reveal transaction carrying a metaid envelope
+func buildRevealTx(t *testing.T) *wire.MsgTx {
+	t.Helper()
+	script, err := txscript.NewScriptBuilder().
+		AddData(bytes.Repeat([]byte{0x02}, 32)).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_IF).
+		AddData([]byte("metaid")).
+		AddData([]byte("create")).
+		AddData([]byte("/protocols/simplebuzz")).
+		AddData([]byte("0")).
+		AddData([]byte("1.0.0")).
+		AddData([]byte("application/json")).
+		AddData([]byte(`{"content":"hello from litecoin"}`)).
+		AddOp(txscript.OP_ENDIF).
+		Script()
+	if err != nil {
+		t.Fatalf("Failed to build script: %v", err)
+	}
+
+	msgTx := wire.NewMsgTx(2)
+	prevHash := chainhash.Hash{0x01}
+	txIn := wire.NewTxIn(wire.NewOutPoint(&prevHash, 1), nil, nil)
+	txIn.Witness = wire.TxWitness{
+		bytes.Repeat([]byte{0x30}, 64),
+		script,
+		append([]byte{0xc0}, bytes.Repeat([]byte{0x03}, 32)...),
+	}
+	msgTx.AddTxIn(txIn)
+
+	// P2WPKH owner output
+	p2wpkh := append([]byte{txscript.OP_0, 0x14}, bytes.Repeat([]byte{0xab}, 20)...)
+	msgTx.AddTxOut(wire.NewTxOut(10000, p2wpkh))
+	return msgTx
+}
+
+// serialize serializes a transaction with optional MWEB extension data
+func serialize(t *testing.T, msgTx *wire.MsgTx, mweb []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+	raw := buf.Bytes()
+	if mweb == nil {
+		return raw
+	}
+	if raw[4] != 0x00 || raw[5] != 0x01 {
+		t.Fatal("Expected a segwit serialization")
+	}
+	raw[5] |= mwebFlag
+	locktime := append([]byte(nil), raw[len(raw)-4:]...)
+	out := append(append([]byte(nil), raw[:len(raw)-4]...), mweb...)
+	return append(out, locktime...)
+}
+
+func TestNewLTCParser(t *testing.T) {
+	// Test creating parser with default configuration
+	parser := NewLTCParser(nil)
+	if parser == nil {
+		t.Fatal("NewLTCParser returned nil")
+	}
+
+	if parser.config.ProtocolID != "6d6574616964" {
+		t.Errorf("Expected default protocol ID '6d6574616964', got '%s'", parser.config.ProtocolID)
+	}
+
+	// Test creating parser with custom configuration
+	customConfig := &decoder.ParserConfig{
+		ProtocolID: "746573746964",
+	}
+	parser = NewLTCParser(customConfig)
+	if parser.config.ProtocolID != "746573746964" {
+		t.Errorf("Expected custom protocol ID '746573746964', got '%s'", parser.config.ProtocolID)
+	}
+}
+
+func TestGetChainName(t *testing.T) {
+	parser := NewLTCParser(nil)
+	if parser.GetChainName() != "ltc" {
+		t.Errorf("Expected chain name 'ltc', got '%s'", parser.GetChainName())
+	}
+}
+
+func TestParseTransaction_InvalidData(t *testing.T) {
+	parser := NewLTCParser(nil)
+
+	// Test empty data
+	_, err := parser.ParseTransaction([]byte{}, nil)
+	if err == nil {
+		t.Error("Expected error for empty transaction data, got nil")
+	}
+
+	// Test invalid data
+	_, err = parser.ParseTransaction([]byte{0x01, 0x02, 0x03}, nil)
+	if err == nil {
+		t.Error("Expected error for invalid transaction data, got nil")
+	}
+
+	// Test invalid chainParams
+	_, err = parser.ParseTransaction(serialize(t, buildRevealTx(t), nil), "mainnet")
+	if err == nil {
+		t.Error("Expected error for invalid chainParams, got nil")
+	}
+}
+
+func TestParseTransaction_Witness(t *testing.T) {
+	msgTx := buildRevealTx(t)
+	parser := NewLTCParser(nil)
+
+	pins, err := parser.ParseTransaction(serialize(t, msgTx, nil), nil)
+	if err != nil {
+		t.Fatalf("ParseTransaction failed: %v", err)
+	}
+	if len(pins) != 1 {
+		t.Fatalf("Expected 1 pin, got %d", len(pins))
+	}
+
+	pin := pins[0]
+	txHash := msgTx.TxHash().String()
+	if pin.Id != txHash+"i0" || pin.TxID != txHash {
+		t.Errorf("Unexpected pin id %s / txid %s", pin.Id, pin.TxID)
+	}
+	if pin.ChainName != "ltc" {
+		t.Errorf("Expected chain name 'ltc', got '%s'", pin.ChainName)
+	}
+	if pin.Operation != "create" || pin.Path != "/protocols/simplebuzz" || pin.ContentType != "application/json" {
+		t.Errorf("Unexpected pin fields: %+v", pin)
+	}
+	if string(pin.ContentBody) != `{"content":"hello from litecoin"}` {
+		t.Errorf("Unexpected content body %q", pin.ContentBody)
+	}
+	if !strings.HasPrefix(pin.OwnerAddress, "ltc1") {
+		t.Errorf("Expected ltc1 owner address, got '%s'", pin.OwnerAddress)
+	}
+	if pin.OutputValue != 10000 {
+		t.Errorf("Expected output value 10000, got %d", pin.OutputValue)
+	}
+
+	// Testnet addresses use the tltc prefix
+	pins, err = parser.ParseTransaction(serialize(t, msgTx, nil), &LTCTestNetParams)
+	if err != nil || len(pins) != 1 {
+		t.Fatalf("ParseTransaction(testnet) = %v, %v", pins, err)
+	}
+	if !strings.HasPrefix(pins[0].OwnerAddress, "tltc1") {
+		t.Errorf("Expected tltc1 owner address, got '%s'", pins[0].OwnerAddress)
+	}
+}
+
+func TestParseTransaction_MWEB(t *testing.T) {
+	msgTx := buildRevealTx(t)
+	parser := NewLTCParser(nil)
+
+	// Opaque MWEB extension data between the witnesses and the locktime
+	mweb := append([]byte{0x01}, bytes.Repeat([]byte{0x5a}, 150)...)
+	raw := serialize(t, msgTx, mweb)
+	if !HasMWEB(raw) {
+		t.Fatal("Expected MWEB flag to be set")
+	}
+
+	pins, err := parser.ParseTransaction(raw, nil)
+	if err != nil {
+		t.Fatalf("ParseTransaction failed: %v", err)
+	}
+	if len(pins) != 1 {
+		t.Fatalf("Expected 1 pin, got %d", len(pins))
+	}
+	if pins[0].TxID != msgTx.TxHash().String() {
+		t.Errorf("MWEB data changed the txid: %s", pins[0].TxID)
+	}
+}
+
+func TestDeserializeTx(t *testing.T) {
+	msgTx := buildRevealTx(t)
+	msgTx.LockTime = 123456
+
+	decoded, err := DeserializeTx(serialize(t, msgTx, []byte{0x00}))
+	if err != nil {
+		t.Fatalf("DeserializeTx failed: %v", err)
+	}
+	if decoded.LockTime != 123456 || decoded.Version != 2 {
+		t.Errorf("Unexpected locktime %d / version %d", decoded.LockTime, decoded.Version)
+	}
+	if len(decoded.TxIn) != 1 || len(decoded.TxIn[0].Witness) != 3 || len(decoded.TxOut) != 1 {
+		t.Errorf("Unexpected transaction shape: %+v", decoded)
+	}
+
+	// Trailing data is only allowed with the MWEB flag
+	raw := serialize(t, msgTx, nil)
+	raw = append(append(raw[:len(raw)-4:len(raw)-4], 0xff), raw[len(raw)-4:]...)
+	if _, err := DeserializeTx(raw); err == nil {
+		t.Error("Expected error for trailing data without MWEB flag, got nil")
+	}
+}
@@ -0,0 +1,172 @@
+package ltc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Litecoin extends the BIP144 segwit serialization with MWEB (MimbleWimble Extension Blocks):
+//
+//	<version> [0x00 <flags>] <inputs> <outputs> [<witnesses> if flags&0x01] [<mweb tx> if flags&0x08] <locktime>
+//
+// The MWEB transaction sits between the witnesses and the locktime. It is not needed to
+// find PINs, so it is skipped rather than decoded.
+const (
+	witnessFlag = 0x01
+	mwebFlag    = 0x08
+
+	// maxItems bounds input, output and witness item counts to reject garbage early
+	maxItems = 1 << 20
+	// maxScriptSize bounds the size of a single script or witness item
+	maxScriptSize = 4_000_000
+)
+
+// DeserializeTx deserializes a Litecoin transaction, tolerating MWEB extension data
+func DeserializeTx(txBytes []byte) (*wire.MsgTx, error) {
+	if len(txBytes) < 10 {
+		return nil, errors.New("transaction too short")
+	}
+
+	// The locktime is always the last 4 bytes
+	body := txBytes[:len(txBytes)-4]
+	r := bytes.NewReader(body)
+	msgTx := &wire.MsgTx{}
+
+	var version int32
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, err
+	}
+	msgTx.Version = version
+
+	// Extended serialization: a zero input count marker followed by non-zero flags
+	var flags byte
+	if len(body) > 6 && body[4] == 0x00 && body[5] != 0x00 {
+		flags = body[5]
+		if flags&^(witnessFlag|mwebFlag) != 0 {
+			return nil, fmt.Errorf("unknown transaction flags 0x%02x", flags)
+		}
+		r.Seek(6, io.SeekStart)
+	}
+
+	inCount, err := readCount(r, "inputs")
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < inCount; i++ {
+		txIn, err := readTxIn(r)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		msgTx.TxIn = append(msgTx.TxIn, txIn)
+	}
+
+	outCount, err := readCount(r, "outputs")
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < outCount; i++ {
+		txOut, err := readTxOut(r)
+		if err != nil {
+			return nil, fmt.Errorf("output %d: %w", i, err)
+		}
+		msgTx.TxOut = append(msgTx.TxOut, txOut)
+	}
+
+	if flags&witnessFlag != 0 {
+		for i, txIn := range msgTx.TxIn {
+			witness, err := readWitness(r)
+			if err != nil {
+				return nil, fmt.Errorf("witness %d: %w", i, err)
+			}
+			txIn.Witness = witness
+		}
+	}
+
+	// Anything left before the locktime must be MWEB data
+	if r.Len() > 0 && flags&mwebFlag == 0 {
+		return nil, errors.New("too much transaction data")
+	}
+
+	msgTx.LockTime = binary.LittleEndian.Uint32(txBytes[len(txBytes)-4:])
+	return msgTx, nil
+}
+
+// HasMWEB checks whether a serialized transaction carries the MWEB flag
+func HasMWEB(txBytes []byte) bool {
+	return len(txBytes) > 6 && txBytes[4] == 0x00 && txBytes[5]&mwebFlag != 0
+}
+
+// readCount reads a varint count
+func readCount(r io.Reader, name string) (uint64, error) {
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return 0, err
+	}
+	if count > maxItems {
+		return 0, fmt.Errorf("too many %s: %d", name, count)
+	}
+	return count, nil
+}
+
+// readBytes reads a varint-prefixed byte slice
+func readBytes(r io.Reader) ([]byte, error) {
+	return wire.ReadVarBytes(r, 0, maxScriptSize, "script")
+}
+
+// readTxIn reads a transaction input
+func readTxIn(r io.Reader) (*wire.TxIn, error) {
+	var hash chainhash.Hash
+	if _, err := io.ReadFull(r, hash[:]); err != nil {
+		return nil, err
+	}
+	var index, sequence uint32
+	if err := binary.Read(r, binary.LittleEndian, &index); err != nil {
+		return nil, err
+	}
+	script, err := readBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &sequence); err != nil {
+		return nil, err
+	}
+	txIn := wire.NewTxIn(wire.NewOutPoint(&hash, index), script, nil)
+	txIn.Sequence = sequence
+	return txIn, nil
+}
+
+// readTxOut reads a transaction output
+func readTxOut(r io.Reader) (*wire.TxOut, error) {
+	var value int64
+	if err := binary.Read(r, binary.LittleEndian, &value); err != nil {
+		return nil, err
+	}
+	pkScript, err := readBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	return wire.NewTxOut(value, pkScript), nil
+}
+
+// readWitness reads the witness stack of an input
+func readWitness(r io.Reader) (wire.TxWitness, error) {
+	count, err := readCount(r, "witness items")
+	if err != nil {
+		return nil, err
+	}
+	witness := make(wire.TxWitness, 0, count)
+	for i := uint64(0); i < count; i++ {
+		item, err := readBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		witness = append(witness, item)
+	}
+	return witness, nil
+}
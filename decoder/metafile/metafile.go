@@ -0,0 +1,306 @@
+package metafile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/common"
+)
+
+const (
+	// IndexPath is the path of MetaFile index PINs
+	IndexPath = "/file/index"
+	// ChunkPath is the path of MetaFile chunk PINs
+	ChunkPath = "/file/_chunk"
+)
+
+// Index is the body of a MetaFile index PIN
+type Index struct {
+	PinId       string     `json:"-"`           // Index PIN ID
+	Sha256      string     `json:"sha256"`      // Hex sha256 of the complete file
+	FileSize    uint64     `json:"fileSize"`    // Size of the complete file
+	ChunkNumber int        `json:"chunkNumber"` // Number of chunks
+	ChunkSize   uint64     `json:"chunkSize"`   // Size of each chunk except the last
+	DataType    string     `json:"dataType"`    // Content type of the complete file
+	Name        string     `json:"name"`        // File name
+	ChunkList   []ChunkRef `json:"chunkList"`   // Chunks in file order
+}
+
+// ChunkRef references a chunk PIN from an index
+type ChunkRef struct {
+	Sha256 string `json:"sha256"` // Hex sha256 of the chunk body
+	PinId  string `json:"pinId"`  // Chunk PIN ID
+}
+
+// File is a reassembled MetaFile
+type File struct {
+	IndexPinId  string   `json:"indexPinId"`
+	Name        string   `json:"name"`
+	ContentType string   `json:"contentType"`
+	Sha256      string   `json:"sha256"`
+	Size        uint64   `json:"size"`
+	ChunkPinIds []string `json:"chunkPinIds"`
+	Data        []byte   `json:"data"`
+}
+
+// Report describes the assembly state of an index
+type Report struct {
+	IndexPinId   string   `json:"indexPinId"`
+	Total        int      `json:"total"`        // Number of chunks referenced by the index
+	Received     int      `json:"received"`     // Number of chunks received and verified
+	Missing      []string `json:"missing"`      // Chunk PIN IDs not received yet
+	Corrupt      []string `json:"corrupt"`      // Chunk PIN IDs whose sha256 does not match the index
+	FileMismatch bool     `json:"fileMismatch"` // All chunks verified but the file sha256 or size does not match
+}
+
+// Complete reports whether every chunk was received and verified
+func (r *Report) Complete() bool {
+	return len(r.Missing) == 0 && len(r.Corrupt) == 0
+}
+
+var (
+	// ErrUnknownIndex is returned when an index PIN has not been seen
+	ErrUnknownIndex = errors.New("metafile: unknown index")
+	// ErrInvalidIndex is returned when an index PIN body cannot be used
+	ErrInvalidIndex = errors.New("metafile: invalid index")
+)
+
+// ParseIndex parses the body of an index PIN
+func ParseIndex(pin *decoder.Pin) (*Index, error) {
+	var index Index
+	if err := json.Unmarshal(pin.Body(), &index); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIndex, err)
+	}
+	index.PinId = pin.Id
+	index.Sha256 = strings.ToLower(index.Sha256)
+
+	if len(index.ChunkList) == 0 {
+		return nil, fmt.Errorf("%w: empty chunk list", ErrInvalidIndex)
+	}
+	if index.ChunkNumber != 0 && index.ChunkNumber != len(index.ChunkList) {
+		return nil, fmt.Errorf("%w: chunkNumber %d does not match %d chunks", ErrInvalidIndex, index.ChunkNumber, len(index.ChunkList))
+	}
+	for i := range index.ChunkList {
+		if index.ChunkList[i].PinId == "" {
+			return nil, fmt.Errorf("%w: chunk %d has no pinId", ErrInvalidIndex, i)
+		}
+		index.ChunkList[i].Sha256 = strings.ToLower(index.ChunkList[i].Sha256)
+	}
+	return &index, nil
+}
+
+// IsIndex checks whether a PIN is a MetaFile index PIN
+func IsIndex(pin *decoder.Pin) bool {
+	return common.NormalizePath(pin.Path) == IndexPath
+}
+
+// IsChunk checks whether a PIN is a MetaFile chunk PIN
+func IsChunk(pin *decoder.Pin) bool {
+	return common.NormalizePath(pin.Path) == ChunkPath
+}
+
+// DefaultChunkTimeout is how long a chunk no index references is kept
+const DefaultChunkTimeout = 24 * time.Hour
+
+// Assembler reassembles MetaFiles from decoded index and chunk PINs
+// PINs can be added in any order; a file is emitted once its index and every chunk have been seen.
+// Chunks whose index does not arrive within the timeout are dropped by Expire.
+type Assembler struct {
+	store   ChunkStore
+	timeout time.Duration
+	now     func() time.Time
+}
+
+// NewAssembler creates an assembler backed by store
+// If store is nil, an in-memory store is used; if timeout is 0, DefaultChunkTimeout is used
+func NewAssembler(store ChunkStore, timeout time.Duration) *Assembler {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	if timeout <= 0 {
+		timeout = DefaultChunkTimeout
+	}
+	return &Assembler{
+		store:   store,
+		timeout: timeout,
+		now:     time.Now,
+	}
+}
+
+// Add feeds a decoded PIN to the assembler
+// Returns the files completed by this PIN; PINs that are not MetaFile PINs are ignored
+func (a *Assembler) Add(pin *decoder.Pin) ([]*File, error) {
+	if pin == nil || pin.Operation == "revoke" {
+		return nil, nil
+	}
+
+	switch {
+	case IsChunk(pin):
+		if err := a.store.PutChunk(pin.Id, pin.Body(), a.now()); err != nil {
+			return nil, err
+		}
+		indexIds, err := a.store.PendingIndexes(pin.Id)
+		if err != nil {
+			return nil, err
+		}
+		var files []*File
+		for _, indexId := range indexIds {
+			file, err := a.tryAssemble(indexId)
+			if err != nil {
+				return files, err
+			}
+			if file != nil {
+				files = append(files, file)
+			}
+		}
+		return files, nil
+
+	case IsIndex(pin):
+		index, err := ParseIndex(pin)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.store.PutIndex(index); err != nil {
+			return nil, err
+		}
+		file, err := a.tryAssemble(index.PinId)
+		if err != nil || file == nil {
+			return nil, err
+		}
+		return []*File{file}, nil
+	}
+
+	return nil, nil
+}
+
+// Expire removes the chunks that no index referenced within the timeout
+// Returns the IDs of the removed chunks
+func (a *Assembler) Expire() ([]string, error) {
+	ids, err := a.store.StaleChunks(a.now().Add(-a.timeout))
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range ids {
+		if err := a.store.DeleteChunk(id); err != nil {
+			return ids[:i], err
+		}
+	}
+	return ids, nil
+}
+
+// Status reports the missing and corrupt chunks of an index
+func (a *Assembler) Status(indexPinId string) (*Report, error) {
+	index, ok, err := a.store.GetIndex(indexPinId)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUnknownIndex
+	}
+	report, _, err := a.check(index)
+	return report, err
+}
+
+// tryAssemble emits the file of an index if all of its chunks are present and verified
+func (a *Assembler) tryAssemble(indexPinId string) (*File, error) {
+	index, ok, err := a.store.GetIndex(indexPinId)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	report, data, err := a.check(index)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Complete() || report.FileMismatch {
+		return nil, nil
+	}
+
+	file := &File{
+		IndexPinId:  index.PinId,
+		Name:        index.Name,
+		ContentType: common.NormalizeContentType(index.DataType),
+		Sha256:      index.Sha256,
+		Size:        uint64(len(data)),
+		Data:        data,
+	}
+	for _, ref := range index.ChunkList {
+		file.ChunkPinIds = append(file.ChunkPinIds, ref.PinId)
+	}
+
+	if err := a.release(index); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// check verifies the chunks of an index and concatenates them when all are present
+func (a *Assembler) check(index *Index) (*Report, []byte, error) {
+	report := &Report{
+		IndexPinId: index.PinId,
+		Total:      len(index.ChunkList),
+	}
+
+	var buf bytes.Buffer
+	for _, ref := range index.ChunkList {
+		chunk, ok, err := a.store.GetChunk(ref.PinId)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			report.Missing = append(report.Missing, ref.PinId)
+			continue
+		}
+		if ref.Sha256 != "" && sha256Hex(chunk) != ref.Sha256 {
+			report.Corrupt = append(report.Corrupt, ref.PinId)
+			continue
+		}
+		report.Received++
+		buf.Write(chunk)
+	}
+
+	if !report.Complete() {
+		return report, nil, nil
+	}
+
+	data := buf.Bytes()
+	if index.Sha256 != "" && sha256Hex(data) != index.Sha256 {
+		report.FileMismatch = true
+	}
+	if index.FileSize != 0 && index.FileSize != uint64(len(data)) {
+		report.FileMismatch = true
+	}
+	return report, data, nil
+}
+
+// release removes a completed index and the chunks no other index is waiting for
+func (a *Assembler) release(index *Index) error {
+	if err := a.store.DeleteIndex(index.PinId); err != nil {
+		return err
+	}
+	for _, ref := range index.ChunkList {
+		pending, err := a.store.PendingIndexes(ref.PinId)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			if err := a.store.DeleteChunk(ref.PinId); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sha256Hex returns the hex sha256 of data
+func sha256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
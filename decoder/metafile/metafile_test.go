@@ -0,0 +1,236 @@
+package metafile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+// buildFile splits data into chunk PINs and builds the matching index PIN
+func buildFile(t *testing.T, data []byte, chunkSize int) (*decoder.Pin, []*decoder.Pin) {
+	t.Helper()
+	var chunks []*decoder.Pin
+	index := Index{
+		Sha256:    sha256Hex(data),
+		FileSize:  uint64(len(data)),
+		ChunkSize: uint64(chunkSize),
+		DataType:  "image/png",
+		Name:      "cat.png",
+	}
+	for i := 0; i*chunkSize < len(data); i++ {
+		end := (i + 1) * chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := &decoder.Pin{
+			Id:          fmt.Sprintf("%064di0", i+1),
+			Operation:   "create",
+			Path:        ChunkPath,
+			ContentBody: data[i*chunkSize : end],
+		}
+		chunks = append(chunks, chunk)
+		index.ChunkList = append(index.ChunkList, ChunkRef{Sha256: sha256Hex(chunk.ContentBody), PinId: chunk.Id})
+	}
+	index.ChunkNumber = len(index.ChunkList)
+
+	body, err := json.Marshal(index)
+	if err != nil {
+		t.Fatalf("Failed to marshal index: %v", err)
+	}
+	indexPin := &decoder.Pin{
+		Id:          fmt.Sprintf("%064di0", 0),
+		Operation:   "create",
+		Path:        IndexPath,
+		ContentType: "metafile/index;utf-8",
+		ContentBody: body,
+	}
+	return indexPin, chunks
+}
+
+func TestAssembler_IndexFirst(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 130)
+	indexPin, chunks := buildFile(t, data, 520)
+	a := NewAssembler(nil, 0)
+
+	files, err := a.Add(indexPin)
+	if err != nil || len(files) != 0 {
+		t.Fatalf("Add(index) = %v, %v; expected no files", files, err)
+	}
+
+	report, err := a.Status(indexPin.Id)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if report.Total != 3 || len(report.Missing) != 3 {
+		t.Errorf("Expected 3 missing chunks, got %+v", report)
+	}
+
+	for i, chunk := range chunks {
+		files, err = a.Add(chunk)
+		if err != nil {
+			t.Fatalf("Add(chunk %d) failed: %v", i, err)
+		}
+		if i < len(chunks)-1 && len(files) != 0 {
+			t.Fatalf("File emitted before all chunks were seen")
+		}
+	}
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 file, got %d", len(files))
+	}
+	file := files[0]
+	if !bytes.Equal(file.Data, data) {
+		t.Error("Reassembled data does not match")
+	}
+	if file.ContentType != "image/png" || file.Name != "cat.png" || file.Size != uint64(len(data)) {
+		t.Errorf("Unexpected file metadata: %+v", file)
+	}
+	if len(file.ChunkPinIds) != 3 {
+		t.Errorf("Expected 3 chunk PIN IDs, got %d", len(file.ChunkPinIds))
+	}
+
+	// Completed indexes are released
+	if _, err := a.Status(indexPin.Id); !errors.Is(err, ErrUnknownIndex) {
+		t.Errorf("Expected ErrUnknownIndex after completion, got %v", err)
+	}
+}
+
+func TestAssembler_ChunksFirst(t *testing.T) {
+	data := []byte("hello metafile, this body spans more than one chunk")
+	indexPin, chunks := buildFile(t, data, 16)
+	a := NewAssembler(NewMemoryStore(), 0)
+
+	// Feed chunks out of order before the index
+	for i := len(chunks) - 1; i >= 0; i-- {
+		if files, err := a.Add(chunks[i]); err != nil || len(files) != 0 {
+			t.Fatalf("Add(chunk) = %v, %v; expected no files", files, err)
+		}
+	}
+	files, err := a.Add(indexPin)
+	if err != nil {
+		t.Fatalf("Add(index) failed: %v", err)
+	}
+	if len(files) != 1 || !bytes.Equal(files[0].Data, data) {
+		t.Fatalf("Expected reassembled file, got %v", files)
+	}
+}
+
+func TestAssembler_CorruptChunk(t *testing.T) {
+	data := []byte("abcdefghijklmnopqrstuvwxyz")
+	indexPin, chunks := buildFile(t, data, 10)
+	a := NewAssembler(nil, 0)
+
+	if _, err := a.Add(indexPin); err != nil {
+		t.Fatalf("Add(index) failed: %v", err)
+	}
+	chunks[1].ContentBody = []byte("tampered!!")
+	for _, chunk := range chunks {
+		files, err := a.Add(chunk)
+		if err != nil {
+			t.Fatalf("Add(chunk) failed: %v", err)
+		}
+		if len(files) != 0 {
+			t.Fatal("File emitted with a corrupt chunk")
+		}
+	}
+
+	report, err := a.Status(indexPin.Id)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if report.Complete() || len(report.Corrupt) != 1 || report.Corrupt[0] != chunks[1].Id {
+		t.Errorf("Expected chunk %s reported corrupt, got %+v", chunks[1].Id, report)
+	}
+	if len(report.Missing) != 0 || report.Received != 2 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
+
+func TestAssembler_FileMismatch(t *testing.T) {
+	data := []byte("abcdefghijklmnopqrstuvwxyz")
+	indexPin, chunks := buildFile(t, data, 10)
+
+	var index Index
+	if err := json.Unmarshal(indexPin.ContentBody, &index); err != nil {
+		t.Fatalf("Failed to unmarshal index: %v", err)
+	}
+	index.Sha256 = sha256Hex([]byte("something else"))
+	indexPin.ContentBody, _ = json.Marshal(index)
+
+	a := NewAssembler(nil, 0)
+	for _, pin := range append([]*decoder.Pin{indexPin}, chunks...) {
+		if files, err := a.Add(pin); err != nil || len(files) != 0 {
+			t.Fatalf("Add = %v, %v; expected no files", files, err)
+		}
+	}
+	report, err := a.Status(indexPin.Id)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if !report.Complete() || !report.FileMismatch {
+		t.Errorf("Expected file mismatch, got %+v", report)
+	}
+}
+
+func TestAssembler_IgnoresOtherPins(t *testing.T) {
+	a := NewAssembler(nil, 0)
+	files, err := a.Add(&decoder.Pin{Id: "x", Operation: "create", Path: "/protocols/simplebuzz"})
+	if err != nil || len(files) != 0 {
+		t.Errorf("Add(non-metafile) = %v, %v; expected nothing", files, err)
+	}
+}
+
+func TestParseIndex_Invalid(t *testing.T) {
+	bodies := []string{
+		`not json`,
+		`{"chunkList": []}`,
+		`{"chunkNumber": 2, "chunkList": [{"pinId": "a", "sha256": "00"}]}`,
+		`{"chunkList": [{"sha256": "00"}]}`,
+	}
+	for _, body := range bodies {
+		_, err := ParseIndex(&decoder.Pin{Id: "i", Path: IndexPath, ContentBody: []byte(body)})
+		if !errors.Is(err, ErrInvalidIndex) {
+			t.Errorf("ParseIndex(%s) = %v, expected ErrInvalidIndex", body, err)
+		}
+	}
+}
+
+func TestAssembler_Expire(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 130)
+	indexPin, chunks := buildFile(t, data, 520)
+	_, orphans := buildFile(t, []byte("orphan"), 6)
+	orphans[0].Id = fmt.Sprintf("%064di0", 99)
+
+	now := time.Unix(1700000000, 0)
+	a := NewAssembler(nil, time.Hour)
+	a.now = func() time.Time { return now }
+
+	a.Add(orphans[0])
+	a.Add(chunks[0])
+	a.Add(indexPin)
+	now = now.Add(2 * time.Hour)
+
+	// Only the chunk no index references is dropped
+	dropped, err := a.Expire()
+	if err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if len(dropped) != 1 || dropped[0] != orphans[0].Id {
+		t.Fatalf("Expected the orphan chunk to be dropped, got %v", dropped)
+	}
+
+	var files []*File
+	for _, chunk := range chunks[1:] {
+		files, err = a.Add(chunk)
+		if err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	if len(files) != 1 || !bytes.Equal(files[0].Data, data) {
+		t.Fatal("Expected the referenced chunks to survive Expire")
+	}
+}
@@ -0,0 +1,143 @@
+package metafile
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ChunkStore is the interface for chunk and index storage used by the assembler
+// Chunks are often published before their index, so the store also holds chunks no index
+// references yet; the assembler evicts them with StaleChunks once they time out.
+type ChunkStore interface {
+	// PutChunk stores the body of a chunk PIN received at t
+	PutChunk(pinId string, data []byte, received time.Time) error
+	// GetChunk returns the body of a chunk PIN
+	GetChunk(pinId string) ([]byte, bool, error)
+	// DeleteChunk removes a chunk PIN
+	DeleteChunk(pinId string) error
+
+	// PutIndex stores an index that is waiting for chunks
+	PutIndex(index *Index) error
+	// GetIndex returns a stored index
+	GetIndex(pinId string) (*Index, bool, error)
+	// DeleteIndex removes a stored index
+	DeleteIndex(pinId string) error
+	// PendingIndexes returns the IDs of stored indexes that reference a chunk
+	PendingIndexes(chunkPinId string) ([]string, error)
+	// StaleChunks returns the IDs of the chunks received before t that no stored index references
+	StaleChunks(before time.Time) ([]string, error)
+}
+
+// MemoryStore is an in-memory ChunkStore
+type MemoryStore struct {
+	mu      sync.RWMutex
+	chunks  map[string]storedChunk
+	indexes map[string]*Index
+	waiting map[string]map[string]bool // chunk PIN ID -> index PIN IDs
+}
+
+// storedChunk is a chunk body and the time it was received
+type storedChunk struct {
+	data     []byte
+	received time.Time
+}
+
+// NewMemoryStore creates an in-memory chunk store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		chunks:  make(map[string]storedChunk),
+		indexes: make(map[string]*Index),
+		waiting: make(map[string]map[string]bool),
+	}
+}
+
+// PutChunk implements ChunkStore
+func (s *MemoryStore) PutChunk(pinId string, data []byte, received time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chunks[pinId] = storedChunk{data: append([]byte(nil), data...), received: received}
+	return nil
+}
+
+// GetChunk implements ChunkStore
+func (s *MemoryStore) GetChunk(pinId string) ([]byte, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	chunk, ok := s.chunks[pinId]
+	return chunk.data, ok, nil
+}
+
+// DeleteChunk implements ChunkStore
+func (s *MemoryStore) DeleteChunk(pinId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.chunks, pinId)
+	return nil
+}
+
+// PutIndex implements ChunkStore
+func (s *MemoryStore) PutIndex(index *Index) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.indexes[index.PinId] = index
+	for _, ref := range index.ChunkList {
+		if s.waiting[ref.PinId] == nil {
+			s.waiting[ref.PinId] = make(map[string]bool)
+		}
+		s.waiting[ref.PinId][index.PinId] = true
+	}
+	return nil
+}
+
+// GetIndex implements ChunkStore
+func (s *MemoryStore) GetIndex(pinId string) (*Index, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	index, ok := s.indexes[pinId]
+	return index, ok, nil
+}
+
+// DeleteIndex implements ChunkStore
+func (s *MemoryStore) DeleteIndex(pinId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index, ok := s.indexes[pinId]
+	if !ok {
+		return nil
+	}
+	delete(s.indexes, pinId)
+	for _, ref := range index.ChunkList {
+		delete(s.waiting[ref.PinId], pinId)
+		if len(s.waiting[ref.PinId]) == 0 {
+			delete(s.waiting, ref.PinId)
+		}
+	}
+	return nil
+}
+
+// PendingIndexes implements ChunkStore
+func (s *MemoryStore) PendingIndexes(chunkPinId string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]string, 0, len(s.waiting[chunkPinId]))
+	for id := range s.waiting[chunkPinId] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// StaleChunks implements ChunkStore
+func (s *MemoryStore) StaleChunks(before time.Time) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var ids []string
+	for id, chunk := range s.chunks {
+		if chunk.received.Before(before) && len(s.waiting[id]) == 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
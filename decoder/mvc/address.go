@@ -0,0 +1,43 @@
+package mvc
+
+import (
+	"github.com/bitcoinsv/bsvd/chaincfg"
+	"github.com/bitcoinsv/bsvd/txscript"
+	"github.com/bitcoinsv/bsvutil"
+)
+
+// LegacyAddress extracts the base58 address of a locking script, using the legacy version bytes of params
+// It is shared with the other chains of the BSV family that dropped CashAddr, such as BSV
+// P2PKH, P2PK and P2SH scripts give their address, and bare multisig scripts the P2PKH address of their first key
+// Returns "" if the script has no address
+func LegacyAddress(pkScript []byte, params *chaincfg.Params) string {
+	class, addresses, _, err := txscript.ExtractPkScriptAddrs(pkScript, params)
+	if err != nil || len(addresses) == 0 {
+		return ""
+	}
+
+	var legacy bsvutil.Address
+	switch class {
+	case txscript.PubKeyHashTy:
+		legacy, err = bsvutil.NewLegacyAddressPubKeyHash(addresses[0].ScriptAddress(), params)
+	case txscript.PubKeyTy, txscript.MultiSigTy:
+		legacy, err = bsvutil.NewLegacyAddressPubKeyHash(bsvutil.Hash160(addresses[0].ScriptAddress()), params)
+	case txscript.ScriptHashTy:
+		legacy, err = bsvutil.NewLegacyAddressScriptHashFromHash(addresses[0].ScriptAddress(), params)
+	default:
+		return ""
+	}
+	if err != nil {
+		return ""
+	}
+	return legacy.EncodeAddress()
+}
+
+// holderAddress returns the P2PKH address of a MetaContract holder hash
+func holderAddress(holder []byte, params *chaincfg.Params) string {
+	addr, err := bsvutil.NewLegacyAddressPubKeyHash(holder, params)
+	if err != nil {
+		return ""
+	}
+	return addr.EncodeAddress()
+}
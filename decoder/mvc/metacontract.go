@@ -0,0 +1,127 @@
+package mvc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+
+	"github.com/bitcoinsv/bsvd/txscript"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder/envelope"
+)
+
+// MetaContract tokens (FT, NFT and unique contracts) lock their outputs with a contract
+// script whose data part ends with a fixed proto header:
+//
+//	<contract code> ... <type specific data> <proto version (4)> <proto type (4)> "metacontract"
+//
+// The type specific data has fixed-size fields, so the holder address is found at a fixed
+// offset from the end of the script:
+//
+//	FT:  <name (40)> <symbol (20)> <decimals (1)> <holder (20)> <amount (8)> <genesis hash (20)> <sensible id (36)>
+//	NFT: <metaid outpoint (36)> <is genesis (1)> <holder (20)> <total supply (8)> <token index (8)> <genesis hash (20)> <sensible id (36)>
+//
+// Integers are little-endian and the holder is the hash160 of the holder's public key.
+const (
+	// MetaContractFlag is the flag closing every MetaContract script
+	MetaContractFlag = "metacontract"
+
+	protoHeaderLen = 4 + 4 + len(MetaContractFlag)
+	sensibleIDLen  = 36
+	genesisHashLen = 20
+	holderLen      = 20
+)
+
+// MetaContractType is the proto type of a MetaContract
+type MetaContractType uint32
+
+// MetaContract proto types
+const (
+	MetaContractFT     MetaContractType = 1
+	MetaContractUnique MetaContractType = 2
+	MetaContractNFT    MetaContractType = 3
+)
+
+// Output classes of MetaContract outputs, recorded in Pin.OutputClass
+const (
+	ClassMetaContractFT     = "metacontract-ft"
+	ClassMetaContractNFT    = "metacontract-nft"
+	ClassMetaContractUnique = "metacontract-unique"
+	ClassMetaContract       = "metacontract" // prefix of the MetaContract classes
+)
+
+// MetaContract is the data part of a MetaContract locking script
+type MetaContract struct {
+	Type    MetaContractType
+	Version uint32
+	// Holder is the hash160 of the token holder, nil for contracts without a holder
+	Holder []byte
+	// Amount is the token amount of an FT output
+	Amount uint64
+	// TokenIndex is the token index of an NFT output
+	TokenIndex uint64
+}
+
+// Class returns the output class of the contract
+func (c *MetaContract) Class() string {
+	switch c.Type {
+	case MetaContractFT:
+		return ClassMetaContractFT
+	case MetaContractNFT:
+		return ClassMetaContractNFT
+	case MetaContractUnique:
+		return ClassMetaContractUnique
+	default:
+		return ClassMetaContract
+	}
+}
+
+// ParseMetaContract parses the data part of a MetaContract locking script
+// Returns nil if the script is not a MetaContract script: a contract starts with its code, so
+// scripts starting with OP_FALSE or OP_RETURN, such as metaid outputs, are never contracts, and
+// the proto header must carry a known type and a non-zero version.
+func ParseMetaContract(pkScript []byte) *MetaContract {
+	n := len(pkScript)
+	if n < protoHeaderLen || !bytes.Equal(pkScript[n-len(MetaContractFlag):], []byte(MetaContractFlag)) {
+		return nil
+	}
+	if pkScript[0] == txscript.OP_FALSE || pkScript[0] == txscript.OP_RETURN {
+		return nil
+	}
+	header := pkScript[n-protoHeaderLen:]
+	contract := &MetaContract{
+		Version: binary.LittleEndian.Uint32(header[0:4]),
+		Type:    MetaContractType(binary.LittleEndian.Uint32(header[4:8])),
+	}
+	if contract.Version == 0 || (contract.Type != MetaContractFT && contract.Type != MetaContractNFT && contract.Type != MetaContractUnique) {
+		return nil
+	}
+
+	// Start of the fields shared by FT and NFT: <genesis hash> <sensible id> <proto header>
+	tail := n - protoHeaderLen - sensibleIDLen - genesisHashLen
+	switch contract.Type {
+	case MetaContractFT:
+		// <holder (20)> <amount (8)>
+		start := tail - 8 - holderLen
+		if start < 1+20+40 {
+			return contract
+		}
+		contract.Holder = pkScript[start : start+holderLen]
+		contract.Amount = binary.LittleEndian.Uint64(pkScript[start+holderLen : tail])
+	case MetaContractNFT:
+		// <holder (20)> <total supply (8)> <token index (8)>
+		start := tail - 8 - 8 - holderLen
+		if start < 1+36 {
+			return contract
+		}
+		contract.Holder = pkScript[start : start+holderLen]
+		contract.TokenIndex = binary.LittleEndian.Uint64(pkScript[tail-8 : tail])
+	}
+	return contract
+}
+
+// IsTokenOutput checks whether an output is a MetaContract output paying to its holder
+// It is the owner preference of MVC PINs, see envelope.PreferredOwners
+func IsTokenOutput(out envelope.Output) bool {
+	return out.Address != "" && strings.HasPrefix(out.Class, ClassMetaContract)
+}
@@ -0,0 +1,38 @@
+package mvc
+
+import (
+	"github.com/bitcoinsv/bsvd/chaincfg"
+)
+
+// MVC keeps the legacy base58 address format of the BSV family. Only the address
+// fields are set, the params are meant for decoding, not for connecting to MVC nodes.
+
+// MVCMainNetParams defines the network parameters for the main MVC network.
+var MVCMainNetParams = chaincfg.Params{
+	Name:                   "mvc-mainnet",
+	CoinbaseMaturity:       100,
+	LegacyPubKeyHashAddrID: 0x00, // starts with 1
+	LegacyScriptHashAddrID: 0x05, // starts with 3
+	PrivateKeyID:           0x80, // starts with 5 (uncompressed) or K (compressed)
+	HDCoinType:             10001,
+}
+
+// MVCTestNetParams defines the network parameters for the test MVC network.
+var MVCTestNetParams = chaincfg.Params{
+	Name:                   "mvc-testnet",
+	CoinbaseMaturity:       100,
+	LegacyPubKeyHashAddrID: 0x6f, // starts with m or n
+	LegacyScriptHashAddrID: 0xc4, // starts with 2
+	PrivateKeyID:           0xef, // starts with 9 or c
+	HDCoinType:             1,
+}
+
+// MVCRegTestParams defines the network parameters for the regression test MVC network.
+var MVCRegTestParams = chaincfg.Params{
+	Name:                   "mvc-regtest",
+	CoinbaseMaturity:       100,
+	LegacyPubKeyHashAddrID: 0x6f, // starts with m or n
+	LegacyScriptHashAddrID: 0xc4, // starts with 2
+	PrivateKeyID:           0xef, // starts with 9 or c
+	HDCoinType:             1,
+}
@@ -8,19 +8,23 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"strings"
 
 	"github.com/bitcoinsv/bsvd/chaincfg"
 	"github.com/bitcoinsv/bsvd/txscript"
 	"github.com/bitcoinsv/bsvd/wire"
 
 	"github.com/metaid-developers/metaid-script-decoder/decoder"
-	"github.com/metaid-developers/metaid-script-decoder/decoder/common"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/envelope"
 
 	chaincfg2 "github.com/btcsuite/btcd/chaincfg"
-	txscript2 "github.com/btcsuite/btcd/txscript"
 )
 
+func init() {
+	decoder.RegisterChain("mvc", func(config *decoder.ParserConfig) decoder.ChainParser {
+		return NewMVCParser(config)
+	}, "microvisionchain")
+}
+
 // MVCParser is the MVC chain parser
 type MVCParser struct {
 	config *decoder.ParserConfig
@@ -46,201 +50,78 @@ func (p *MVCParser) ParseTransaction(txBytes []byte, chainParams interface{}) ([
 	// Parse chainParams
 	params, ok := chainParams.(*chaincfg.Params)
 	if !ok && chainParams != nil {
-		return nil, fmt.Errorf("invalid chainParams type for MVC, expected *chaincfg.Params")
+		return nil, fmt.Errorf("%w for MVC, expected *chaincfg.Params", decoder.ErrInvalidChainParams)
 	}
 	if params == nil {
-		params = &chaincfg.MainNetParams
+		params = &MVCMainNetParams
 	}
 
 	// Deserialize MVC transaction
 	msgTx := wire.NewMsgTx(2)
 	if err := msgTx.Deserialize(bytes.NewReader(txBytes)); err != nil {
-		return nil, fmt.Errorf("failed to deserialize transaction: %w", err)
+		return nil, fmt.Errorf("%w: %w", decoder.ErrInvalidTransaction, err)
 	}
 
-	var pins []*decoder.Pin
-
 	// Calculate MVC transaction hash (may differ from standard)
-	txHash, err := p.calculateTxHash(msgTx, txBytes)
+	txHash, err := TxID(msgTx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate tx hash: %w", err)
-	}
-
-	creatorInputLocation := ""
-	for _, in := range msgTx.TxIn {
-		creatorInputLocation = fmt.Sprintf("%s:%d", in.PreviousOutPoint.Hash.String(), in.PreviousOutPoint.Index)
+		return nil, fmt.Errorf("%w: failed to calculate tx hash: %w", decoder.ErrInvalidTransaction, err)
 	}
 
-	// MVC mainly uses OP_RETURN format
-	for i, out := range msgTx.TxOut {
-		class, _, _, _ := txscript.ExtractPkScriptAddrs(out.PkScript, params)
-		if class.String() == "nonstandard" {
-			pin := p.parseOpReturnScript(out.PkScript)
-			if pin == nil {
-				continue
-			}
-
-			// Get PIN owner address
-			address, vout, outValue, locationIdx := p.getOwner(msgTx, params)
-			if address == "" && len(msgTx.TxOut) == 1 {
-				vout = 0
-				outValue = out.Value
-				locationIdx = 0
-			}
-
-			pin.Id = fmt.Sprintf("%si%d", txHash, vout)
-			pin.TxID = txHash
-			pin.Vout = uint32(i)
-			pin.OwnerAddress = address
-			pin.OwnerMetaId = common.CalculateMetaId(address)
-			pin.ChainName = "mvc"
-			pin.InscriptionTxIndex = i
-			pin.CreatorInputLocation = creatorInputLocation
-
-			//// PIN location
-			pin.Location = fmt.Sprintf("%s:%d:%d", txHash, vout, locationIdx)
-			pin.Offset = uint64(vout)
-			pin.Output = fmt.Sprintf("%s:%d", txHash, vout)
-			pin.OutputValue = outValue
-			// pin.Timestamp = msgTx.Timestamp
-
-			pins = append(pins, pin)
-
-			break // Usually only one OP_RETURN
-		}
-	}
+	// MVC mainly uses OP_RETURN format, a transaction may carry several metaid outputs
+	// PINs go to the token outputs first, paying to their holder, then to the outputs paying to an address
+	tx := OpReturnTx(msgTx, txHash, func() []envelope.Output {
+		return Outputs(msgTx, params)
+	})
+	tx.PreferOwner = IsTokenOutput
+	pins := envelope.MVC.DecodeOpReturns(p.config, "mvc", tx)
 
-	return pins, nil
+	return decoder.ProcessPins(p.config, pins), nil
 }
 
-// parseOpReturnScript parses OP_RETURN scripts
-func (p *MVCParser) parseOpReturnScript(pkScript []byte) *decoder.Pin {
-	if len(pkScript) < 1 {
-		return nil
-	}
-
-	// Handle two formats:
-	// 1. OP_RETURN ... (direct)
-	// 2. OP_0 OP_RETURN ... (with OP_0 prefix)
-	offset := 0
-
-	// Check if starts with OP_0
-	if pkScript[0] == txscript.OP_0 || pkScript[0] == txscript.OP_FALSE {
-		offset = 1
-	}
-
-	// Check for OP_RETURN
-	if offset >= len(pkScript) || pkScript[offset] != txscript.OP_RETURN {
-		return nil
-	}
-
-	// Extract data pushes from the script after OP_RETURN
-	dataPushes, err := extractDataPushes(pkScript[offset+1:])
-	if err != nil || len(dataPushes) == 0 {
-		return nil
+// OpReturnTx reduces a transaction of the BSV family to what envelope.DecodeOpReturns needs
+func OpReturnTx(msgTx *wire.MsgTx, txHash string, outputs func() []envelope.Output) *envelope.OpReturnTx {
+	tx := &envelope.OpReturnTx{Hash: txHash, PkScripts: make([][]byte, len(msgTx.TxOut)), Outputs: outputs}
+	for i, out := range msgTx.TxOut {
+		tx.PkScripts[i] = out.PkScript
 	}
-
-	// Check protocol ID
-	if hex.EncodeToString(dataPushes[0]) != p.config.ProtocolID {
-		return nil
+	// The creator input of an OP_RETURN PIN is the first input
+	if len(msgTx.TxIn) > 0 {
+		prevOut := msgTx.TxIn[0].PreviousOutPoint
+		tx.FirstInput = &envelope.Outpoint{TxID: prevOut.Hash.String(), Vout: prevOut.Index}
 	}
-
-	return p.parseOnePin(dataPushes[1:])
+	return tx
 }
 
-// parseOnePin parses a single PIN data
-func (p *MVCParser) parseOnePin(infoList [][]byte) *decoder.Pin {
-	if len(infoList) < 1 {
-		return nil
-	}
-
-	pin := &decoder.Pin{}
-	pin.Operation = strings.ToLower(string(infoList[0]))
-
-	// revoke operation requires at least 5 fields
-	if pin.Operation == "revoke" && len(infoList) < 5 {
-		return nil
-	}
-
-	// Other operations require at least 6 fields
-	if len(infoList) < 6 && pin.Operation != "revoke" {
-		return nil
-	}
-
-	// Parse each field
-	pin.OriginalPath = string(infoList[1])
-
-	// Parse host and path from OriginalPath
-	// Format can be: "host:path" or just "path"
-	// For cases like "example.com:8080:/path", we need to find ":/" pattern
-	pathStr := pin.OriginalPath
-
-	// Look for ":/" pattern to identify the separator between host and path
-	colonSlashIndex := strings.Index(pathStr, ":/")
-	if colonSlashIndex > 0 {
-		// Found ":/", split into host and path
-		pin.Host = pathStr[:colonSlashIndex]
-		pin.Path = common.NormalizePath(pathStr[colonSlashIndex+1:]) // Skip the colon, keep the slash
-	} else {
-		// No ":/" pattern found, treat entire string as path
-		pin.Host = ""
-		pin.Path = common.NormalizePath(pathStr)
-	}
-	pin.ParentPath = pin.Path
-
-	encryption := "0"
-	if len(infoList) > 2 && infoList[2] != nil {
-		encryption = string(infoList[2])
-	}
-	pin.Encryption = encryption
-
-	version := "0"
-	if len(infoList) > 3 && infoList[3] != nil {
-		version = string(infoList[3])
-	}
-	pin.Version = version
-
-	contentType := "application/json"
-	if len(infoList) > 4 && infoList[4] != nil {
-		contentType = common.NormalizeContentType(string(infoList[4]))
-	}
-	pin.ContentType = contentType
-
-	// Merge remaining body data
-	var body []byte
-	for i := 5; i < len(infoList); i++ {
-		body = append(body, infoList[i]...)
-	}
-	pin.ContentBody = body
-	pin.ContentLength = uint64(len(body))
-
-	return pin
+// ParseOpReturnScript parses the metaid data of an OP_RETURN script
+func (p *MVCParser) ParseOpReturnScript(pkScript []byte) *decoder.Pin {
+	return envelope.MVC.Decode(p.config, envelope.OpReturnPushes(pkScript))
 }
 
-// getOwner gets the owner of the PIN
-func (p *MVCParser) getOwner(tx *wire.MsgTx, params *chaincfg.Params) (address string, vout int, outValue int64, locationIdx int64) {
+// Outputs lists the outputs of a transaction with their addresses and classes
+// Addresses use the legacy version bytes of params, and MetaContract
+// token outputs are classified with the address of their holder
+func Outputs(tx *wire.MsgTx, params *chaincfg.Params) []envelope.Output {
+	outputs := make([]envelope.Output, len(tx.TxOut))
 	for i, out := range tx.TxOut {
-		params2 := &chaincfg2.MainNetParams
-		if params == &chaincfg.TestNet3Params {
-			params2 = &chaincfg2.TestNet3Params
-		}
-		class, addresses, _, _ := txscript2.ExtractPkScriptAddrs(out.PkScript, params2)
-		if class.String() != "nulldata" && class.String() != "nonstandard" && len(addresses) > 0 {
-			address = addresses[0].EncodeAddress()
-			vout = i
-			outValue = out.Value
-			locationIdx = 0
-			// fmt.Println("address", address)
-			// fmt.Println("vout", vout)
-			return
+		outputs[i] = envelope.Output{Index: i, Value: out.Value}
+		if contract := ParseMetaContract(out.PkScript); contract != nil {
+			outputs[i].Class = contract.Class()
+			if contract.Holder != nil {
+				outputs[i].Address = holderAddress(contract.Holder, params)
+			}
+			continue
 		}
+		outputs[i].Class = txscript.GetScriptClass(out.PkScript).String()
+		outputs[i].Address = LegacyAddress(out.PkScript, params)
 	}
-	return "", 0, 0, 0
+	return outputs
 }
 
-// calculateTxHash calculates the MVC transaction hash
-// MVC may use a special transaction hash calculation method
-func (p *MVCParser) calculateTxHash(msgTx *wire.MsgTx, txBytes []byte) (string, error) {
+// TxID calculates the MVC transaction hash
+// Transactions with version >= 10 hash their inputs and outputs separately, so the
+// txid differs from the standard double sha256 of the serialized transaction
+func TxID(msgTx *wire.MsgTx) (string, error) {
 	// Serialize transaction
 	buffer := new(bytes.Buffer)
 	if err := msgTx.Serialize(buffer); err != nil {
@@ -476,69 +357,6 @@ func sha256Hash(message []byte) []byte {
 	return hash.Sum(nil)
 }
 
-// extractDataPushes extracts data pushes from a script
-func extractDataPushes(script []byte) ([][]byte, error) {
-	var dataPushes [][]byte
-	offset := 0
-
-	for offset < len(script) {
-		if offset >= len(script) {
-			break
-		}
-
-		opcode := script[offset]
-		offset++
-
-		var data []byte
-		var dataLen int
-
-		// Handle different opcodes
-		if opcode <= txscript.OP_PUSHDATA4 {
-			if opcode < txscript.OP_PUSHDATA1 {
-				// Direct push (0-75 bytes)
-				dataLen = int(opcode)
-			} else if opcode == txscript.OP_PUSHDATA1 {
-				// Next byte is the length
-				if offset >= len(script) {
-					return nil, errors.New("script truncated")
-				}
-				dataLen = int(script[offset])
-				offset++
-			} else if opcode == txscript.OP_PUSHDATA2 {
-				// Next 2 bytes are the length (little-endian)
-				if offset+1 >= len(script) {
-					return nil, errors.New("script truncated")
-				}
-				dataLen = int(binary.LittleEndian.Uint16(script[offset : offset+2]))
-				offset += 2
-			} else if opcode == txscript.OP_PUSHDATA4 {
-				// Next 4 bytes are the length (little-endian)
-				if offset+3 >= len(script) {
-					return nil, errors.New("script truncated")
-				}
-				dataLen = int(binary.LittleEndian.Uint32(script[offset : offset+4]))
-				offset += 4
-			}
-
-			// Extract the data
-			if offset+dataLen > len(script) {
-				return nil, errors.New("script truncated")
-			}
-			data = make([]byte, dataLen)
-			copy(data, script[offset:offset+dataLen])
-			offset += dataLen
-
-			dataPushes = append(dataPushes, data)
-		} else {
-			// For other opcodes, we skip them or could handle specially
-			// For OP_RETURN parsing, we typically only care about data pushes
-			continue
-		}
-	}
-
-	return dataPushes, nil
-}
-
 // getTxNewRawByte gets new transaction bytes (for transactions with version >= 10)
 func getTxNewRawByte(transaction *RawTransaction) []byte {
 	var (
@@ -572,34 +390,26 @@ func getTxNewRawByte(transaction *RawTransaction) []byte {
 	return newRawTxByte
 }
 
+// PkScriptToAddress extracts the legacy address of a hex encoded locking script
 func PkScriptToAddress(net *chaincfg.Params, pkScript string) (string, error) {
 	pkScriptByte, err := hex.DecodeString(pkScript)
 	if err != nil {
 		return "", err
 	}
-	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScriptByte, net)
-	if err != nil {
+	address := LegacyAddress(pkScriptByte, net)
+	if address == "" {
 		return "", errors.New("Extract address from pkScript. ")
 	}
-	if len(addrs) == 0 {
-		return "", errors.New("Extract address from pkScript. ")
-	}
-	address := addrs[0].EncodeAddress()
 	return address, nil
 }
 
+// PkScriptToAddres2 extracts the legacy address of a hex encoded locking script with btcd params
+//
+// Deprecated: use PkScriptToAddress with MVC params such as MVCMainNetParams.
 func PkScriptToAddres2(net *chaincfg2.Params, pkScript string) (string, error) {
-	pkScriptByte, err := hex.DecodeString(pkScript)
-	if err != nil {
-		return "", err
-	}
-	_, addrs, _, err := txscript2.ExtractPkScriptAddrs(pkScriptByte, net)
-	if err != nil {
-		return "", errors.New("Extract address from pkScript. ")
-	}
-	if len(addrs) == 0 {
-		return "", errors.New("Extract address from pkScript. ")
-	}
-	address := addrs[0].EncodeAddress()
-	return address, nil
+	return PkScriptToAddress(&chaincfg.Params{
+		Name:                   net.Name,
+		LegacyPubKeyHashAddrID: net.PubKeyHashAddrID,
+		LegacyScriptHashAddrID: net.ScriptHashAddrID,
+	}, pkScript)
 }
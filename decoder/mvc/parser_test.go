@@ -1,13 +1,82 @@
 package mvc
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"testing"
 
+	"github.com/bitcoinsv/bsvd/chaincfg"
+	"github.com/bitcoinsv/bsvd/chaincfg/chainhash"
+	"github.com/bitcoinsv/bsvd/txscript"
+	"github.com/bitcoinsv/bsvd/wire"
+	chaincfg2 "github.com/btcsuite/btcd/chaincfg"
+
 	"github.com/metaid-developers/metaid-script-decoder/decoder"
 )
 
+// opReturnScript builds an OP_FALSE OP_RETURN metaid script creating a simplebuzz PIN
+func opReturnScript(t *testing.T, body string) []byte {
+	t.Helper()
+	script, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_RETURN).
+		AddData([]byte("metaid")).
+		AddData([]byte("create")).
+		AddData([]byte("/protocols/simplebuzz")).
+		AddData([]byte("0")).
+		AddData([]byte("1.0.0")).
+		AddData([]byte("application/json")).
+		AddData([]byte(body)).
+		Script()
+	if err != nil {
+		t.Fatalf("Failed to build script: %v", err)
+	}
+	return script
+}
+
+// p2pkhScript builds a P2PKH script paying to a repeated byte hash
+func p2pkhScript(b byte) []byte {
+	return append([]byte{txscript.OP_DUP, txscript.OP_HASH160, 0x14}, append(bytes.Repeat([]byte{b}, 20), txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)...)
+}
+
+// serializeTx serializes an MVC transaction
+func serializeTx(t *testing.T, msgTx *wire.MsgTx) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// metaContractScript builds a MetaContract locking script: opaque contract code followed by the data part
+func metaContractScript(protoType MetaContractType, data []byte) []byte {
+	script := bytes.Repeat([]byte{txscript.OP_NOP}, 50)
+	script = append(script, txscript.OP_RETURN)
+	script = append(script, data...)
+	script = append(script, make([]byte, genesisHashLen+sensibleIDLen)...)
+	script = binary.LittleEndian.AppendUint32(script, 1)
+	script = binary.LittleEndian.AppendUint32(script, uint32(protoType))
+	return append(script, MetaContractFlag...)
+}
+
+// ftData builds the FT fields preceding the genesis hash
+func ftData(holder []byte, amount uint64) []byte {
+	data := make([]byte, 40+20+1)
+	data = append(data, holder...)
+	return binary.LittleEndian.AppendUint64(data, amount)
+}
+
+// nftData builds the NFT fields preceding the genesis hash
+func nftData(holder []byte, tokenIndex uint64) []byte {
+	data := make([]byte, 36+1)
+	data = append(data, holder...)
+	data = binary.LittleEndian.AppendUint64(data, 100)
+	return binary.LittleEndian.AppendUint64(data, tokenIndex)
+}
+
 func TestNewMVCParser(t *testing.T) {
 	// Test creating parser with default configuration
 	parser := NewMVCParser(nil)
@@ -74,3 +143,215 @@ func TestParseTransaction_ValidData(t *testing.T) {
 		fmt.Printf("Pin: %+v\n", pin)
 	}
 }
+
+func TestParseMetaContract(t *testing.T) {
+	holder := bytes.Repeat([]byte{0x11}, 20)
+
+	ft := ParseMetaContract(metaContractScript(MetaContractFT, ftData(holder, 12345)))
+	if ft == nil || ft.Type != MetaContractFT || ft.Version != 1 || ft.Class() != ClassMetaContractFT {
+		t.Fatalf("Unexpected FT contract %+v", ft)
+	}
+	if !bytes.Equal(ft.Holder, holder) || ft.Amount != 12345 {
+		t.Errorf("Unexpected FT holder %x / amount %d", ft.Holder, ft.Amount)
+	}
+
+	nft := ParseMetaContract(metaContractScript(MetaContractNFT, nftData(holder, 7)))
+	if nft == nil || nft.Class() != ClassMetaContractNFT || !bytes.Equal(nft.Holder, holder) || nft.TokenIndex != 7 {
+		t.Errorf("Unexpected NFT contract %+v", nft)
+	}
+
+	unique := ParseMetaContract(metaContractScript(MetaContractUnique, make([]byte, 10)))
+	if unique == nil || unique.Class() != ClassMetaContractUnique || unique.Holder != nil {
+		t.Errorf("Unexpected unique contract %+v", unique)
+	}
+
+	if ParseMetaContract([]byte{txscript.OP_RETURN}) != nil {
+		t.Error("Expected nil for a script without the metacontract flag")
+	}
+
+	// Scripts merely ending with the flag are not contracts
+	header := func(version uint32, protoType MetaContractType) []byte {
+		script := binary.LittleEndian.AppendUint32(bytes.Repeat([]byte{txscript.OP_NOP}, 10), version)
+		script = binary.LittleEndian.AppendUint32(script, uint32(protoType))
+		return append(script, MetaContractFlag...)
+	}
+	for name, script := range map[string][]byte{
+		"metaid body":   opReturnScript(t, `{"content":"hello"}`+string(header(1, MetaContractFT)[10:])),
+		"op_return":     append([]byte{txscript.OP_RETURN}, header(1, MetaContractFT)...),
+		"unknown type":  header(1, 9),
+		"version 0":     header(0, MetaContractFT),
+		"no proto data": []byte(MetaContractFlag),
+	} {
+		if contract := ParseMetaContract(script); contract != nil {
+			t.Errorf("%s: expected nil, got %+v", name, contract)
+		}
+	}
+}
+
+func TestParseTransaction_MetaContractOwner(t *testing.T) {
+	holder := bytes.Repeat([]byte{0x11}, 20)
+	change := append([]byte{txscript.OP_DUP, txscript.OP_HASH160, 0x14}, append(bytes.Repeat([]byte{0x22}, 20), txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)...)
+	opReturn := opReturnScript(t, `{"content":"hello"}`)
+
+	msgTx := wire.NewMsgTx(2)
+	prevHash := chainhash.Hash{0x01}
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), []byte{txscript.OP_TRUE}))
+	msgTx.AddTxOut(wire.NewTxOut(1, metaContractScript(MetaContractFT, ftData(holder, 1000))))
+	msgTx.AddTxOut(wire.NewTxOut(5000, change))
+	msgTx.AddTxOut(wire.NewTxOut(0, opReturn))
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	pins, err := NewMVCParser(nil).ParseTransaction(buf.Bytes(), nil)
+	if err != nil {
+		t.Fatalf("ParseTransaction failed: %v", err)
+	}
+	if len(pins) != 1 {
+		t.Fatalf("Expected 1 pin, got %d", len(pins))
+	}
+
+	// The token holder owns the PIN, not the change address
+	pin := pins[0]
+	if pin.Vout != 0 || pin.OutputValue != 1 || pin.OutputClass != ClassMetaContractFT {
+		t.Errorf("Expected the FT output to own the pin, got vout %d / value %d / class %s", pin.Vout, pin.OutputValue, pin.OutputClass)
+	}
+	if pin.OwnerAddress != "12ZEw5Hcv1hTb6YUQJ69y1V7uhcoDz92PH" {
+		t.Errorf("Expected the FT holder address, got '%s'", pin.OwnerAddress)
+	}
+
+	// Regtest addresses use the regtest version bytes
+	pins, err = NewMVCParser(nil).ParseTransaction(buf.Bytes(), &MVCRegTestParams)
+	if err != nil || len(pins) != 1 {
+		t.Fatalf("ParseTransaction(regtest) = %v, %v", pins, err)
+	}
+	if pins[0].OwnerAddress != "mh5CE8Nbj38iND267s4XnvhSmhDW7yWc6Q" {
+		t.Errorf("Expected the regtest FT holder address, got '%s'", pins[0].OwnerAddress)
+	}
+}
+
+func TestParseTransaction_MetaContractOwnerAfterChange(t *testing.T) {
+	holder := bytes.Repeat([]byte{0x11}, 20)
+	msgTx := wire.NewMsgTx(2)
+	prevHash := chainhash.Hash{0x01}
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), []byte{txscript.OP_TRUE}))
+	msgTx.AddTxOut(wire.NewTxOut(5000, p2pkhScript(0x22)))
+	msgTx.AddTxOut(wire.NewTxOut(1, metaContractScript(MetaContractNFT, nftData(holder, 3))))
+	msgTx.AddTxOut(wire.NewTxOut(0, opReturnScript(t, `{"content":"hello"}`)))
+
+	pins, err := NewMVCParser(nil).ParseTransaction(serializeTx(t, msgTx), nil)
+	if err != nil || len(pins) != 1 {
+		t.Fatalf("ParseTransaction = %v, %v", pins, err)
+	}
+	// The change output comes first, the NFT holder still owns the PIN
+	if pin := pins[0]; pin.Vout != 1 || pin.OutputClass != ClassMetaContractNFT || pin.OwnerAddress != "12ZEw5Hcv1hTb6YUQJ69y1V7uhcoDz92PH" {
+		t.Errorf("Expected the NFT output to own the pin, got vout %d / class %s / owner %s", pin.Vout, pin.OutputClass, pin.OwnerAddress)
+	}
+}
+
+func TestLegacyAddress(t *testing.T) {
+	generator, _ := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	p2pkh := append([]byte{txscript.OP_DUP, txscript.OP_HASH160, 0x14}, append(make([]byte, 20), txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)...)
+	p2sh := append([]byte{txscript.OP_HASH160, 0x14}, append(make([]byte, 20), txscript.OP_EQUAL)...)
+	multisig, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_1).AddData(generator).AddOp(txscript.OP_1).AddOp(txscript.OP_CHECKMULTISIG).Script()
+
+	tests := []struct {
+		name     string
+		pkScript []byte
+		params   *chaincfg.Params
+		expected string
+	}{
+		{"p2pkh mainnet", p2pkh, &MVCMainNetParams, "1111111111111111111114oLvT2"},
+		{"p2pkh testnet", p2pkh, &MVCTestNetParams, "mfWxJ45yp2SFn7UciZyNpvDKrzbhyfKrY8"},
+		{"p2pkh regtest", p2pkh, &MVCRegTestParams, "mfWxJ45yp2SFn7UciZyNpvDKrzbhyfKrY8"},
+		{"p2sh mainnet", p2sh, &MVCMainNetParams, "31h1vYVSYuKP6AhS86fbRdMw9XHieotbST"},
+		{"multisig mainnet", multisig, &MVCMainNetParams, "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"},
+		{"multisig regtest", multisig, &MVCRegTestParams, "mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r"},
+		{"op_return", []byte{txscript.OP_FALSE, txscript.OP_RETURN, 0x01, 0x01}, &MVCMainNetParams, ""},
+	}
+	for _, test := range tests {
+		if got := LegacyAddress(test.pkScript, test.params); got != test.expected {
+			t.Errorf("%s: LegacyAddress = '%s', expected '%s'", test.name, got, test.expected)
+		}
+	}
+
+	// The hex helpers share the same extraction path
+	if got, err := PkScriptToAddress(&MVCRegTestParams, hex.EncodeToString(p2pkh)); err != nil || got != "mfWxJ45yp2SFn7UciZyNpvDKrzbhyfKrY8" {
+		t.Errorf("PkScriptToAddress = %s, %v", got, err)
+	}
+	if got, err := PkScriptToAddres2(&chaincfg2.MainNetParams, hex.EncodeToString(p2sh)); err != nil || got != "31h1vYVSYuKP6AhS86fbRdMw9XHieotbST" {
+		t.Errorf("PkScriptToAddres2 = %s, %v", got, err)
+	}
+	if _, err := PkScriptToAddress(&MVCMainNetParams, "6a"); err == nil {
+		t.Error("Expected error for a script without address")
+	}
+}
+
+func TestParseTransaction_MultipleOpReturns(t *testing.T) {
+	msgTx := wire.NewMsgTx(2)
+	prevHash := chainhash.Hash{0x01}
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), []byte{txscript.OP_TRUE}))
+	msgTx.AddTxOut(wire.NewTxOut(0, opReturnScript(t, `{"content":"first"}`)))
+	msgTx.AddTxOut(wire.NewTxOut(1, p2pkhScript(0x11)))
+	msgTx.AddTxOut(wire.NewTxOut(0, opReturnScript(t, `{"content":"second"}`)))
+	msgTx.AddTxOut(wire.NewTxOut(2, p2pkhScript(0x22)))
+	msgTx.AddTxOut(wire.NewTxOut(0, opReturnScript(t, `{"content":"third"}`)))
+
+	pins, err := NewMVCParser(nil).ParseTransaction(serializeTx(t, msgTx), nil)
+	if err != nil {
+		t.Fatalf("ParseTransaction failed: %v", err)
+	}
+	if len(pins) != 3 {
+		t.Fatalf("Expected 3 pins, got %d", len(pins))
+	}
+
+	// The k-th PIN goes to the k-th address output, the third stays on its own OP_RETURN output
+	expected := []struct {
+		body       string
+		vout       uint32
+		value      int64
+		index      int
+		hasAddress bool
+	}{
+		{`{"content":"first"}`, 1, 1, 0, true},
+		{`{"content":"second"}`, 3, 2, 2, true},
+		{`{"content":"third"}`, 4, 0, 4, false},
+	}
+	for k, pin := range pins {
+		want := expected[k]
+		if string(pin.ContentBody) != want.body || pin.InscriptionTxIndex != want.index {
+			t.Errorf("pin %d: unexpected body %q / index %d", k, pin.ContentBody, pin.InscriptionTxIndex)
+		}
+		if pin.Vout != want.vout || pin.OutputValue != want.value || (pin.OwnerAddress != "") != want.hasAddress {
+			t.Errorf("pin %d: unexpected owner vout %d / value %d / address %q", k, pin.Vout, pin.OutputValue, pin.OwnerAddress)
+		}
+		if pin.Id != fmt.Sprintf("%si%d", pin.TxID, pin.Vout) || pin.Output != fmt.Sprintf("%s:%d", pin.TxID, pin.Vout) {
+			t.Errorf("pin %d: id %s and output %s disagree with vout %d", k, pin.Id, pin.Output, pin.Vout)
+		}
+	}
+}
+
+func TestParseTransaction_CreatorInput(t *testing.T) {
+	msgTx := wire.NewMsgTx(2)
+	firstHash := chainhash.Hash{0x01}
+	lastHash := chainhash.Hash{0x02}
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&firstHash, 2), []byte{txscript.OP_TRUE}))
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&lastHash, 0), []byte{txscript.OP_TRUE}))
+	msgTx.AddTxOut(wire.NewTxOut(0, opReturnScript(t, `{"content":"hello"}`)))
+	msgTx.AddTxOut(wire.NewTxOut(1, p2pkhScript(0x11)))
+
+	pins, err := NewMVCParser(nil).ParseTransaction(serializeTx(t, msgTx), nil)
+	if err != nil {
+		t.Fatalf("ParseTransaction failed: %v", err)
+	}
+	if len(pins) != 1 {
+		t.Fatalf("Expected 1 pin, got %d", len(pins))
+	}
+	// The creator input of an OP_RETURN PIN is the first input
+	want := firstHash.String() + ":2"
+	wantVin := firstHash.String() + ":0"
+	if pins[0].CreatorInputLocation != want || pins[0].CreatorInputTxVinLocation != wantVin {
+		t.Errorf("Expected creator input %s / %s, got %s / %s", want, wantVin, pins[0].CreatorInputLocation, pins[0].CreatorInputTxVinLocation)
+	}
+}
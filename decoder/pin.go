@@ -1,5 +1,9 @@
 package decoder
 
+import (
+	"github.com/metaid-developers/metaid-script-decoder/decoder/common"
+)
+
 // Pin represents the PIN data structure in the MetaID protocol
 type Pin struct {
 	Id string `json:"id"` // PIN ID
@@ -10,14 +14,15 @@ type Pin struct {
 	// PIN creator
 	CreatorAddress            string `json:"creatorAddress"`            // Creator address
 	CreatorMetaId             string `json:"creatorMetaId"`             // Creator MetaID
-	CreatorInputLocation      string `json:"creatorInputLocation"`      // Creator input location PreTxId:vout
-	CreatorInputTxVinLocation string `json:"creatorInputTxVinLocation"` // Creator input transaction vin location PreTxId:vin
+	CreatorInputLocation      string `json:"creatorInputLocation"`      // Outpoint spent by the creator input PreTxId:vout
+	CreatorInputTxVinLocation string `json:"creatorInputTxVinLocation"` // Creator input transaction vin location PreTxId:vin, vin being the index of the creator input
 
 	// PIN location
 	Offset      uint64 `json:"offset"`
 	Location    string `json:"location"`
 	Output      string `json:"output"`
 	OutputValue int64  `json:"outputValue"`
+	OutputClass string `json:"outputClass,omitempty"` // Class of the output holding the PIN, e.g. pubkeyhash, metacontract-ft
 	Timestamp   int64  `json:"timestamp"`
 
 	// Basic fields
@@ -34,6 +39,15 @@ type Pin struct {
 	ContentBody   []byte `json:"contentBody"`   // Content body
 	ContentLength uint64 `json:"contentLength"` // Content length
 
+	// Content encoding fields, only set when ParserConfig.DecodeContent is enabled
+	ContentEncoding    string `json:"contentEncoding,omitempty"`    // Content encoding: gzip, zlib, br, zstd
+	DecodedContentBody []byte `json:"decodedContentBody,omitempty"` // Decompressed content body, ContentBody keeps the raw bytes
+	ContentDecodeError string `json:"contentDecodeError,omitempty"` // Error encountered while decoding the content body
+
+	// Content sniffing fields, only set when ParserConfig.SniffContent is enabled
+	DetectedContentType string `json:"detectedContentType,omitempty"` // Content type detected from the body
+	ContentTypeMismatch bool   `json:"contentTypeMismatch,omitempty"` // Detected content type contradicts ContentType
+
 	// Blockchain-related fields
 	TxID string `json:"txId"` // Transaction ID
 	Vout uint32 `json:"vout"` // Output index
@@ -41,6 +55,24 @@ type Pin struct {
 	// Parsing metadata
 	ChainName          string `json:"chainName"`          // Chain name: btc, mvc, etc.
 	InscriptionTxIndex int    `json:"inscriptionTxIndex"` // Index position in transaction
+
+	// Validation result, only set when a PinValidator is configured
+	Validation *ValidationResult `json:"validation,omitempty"`
+}
+
+// Body returns the decoded content body if available, otherwise the raw content body
+func (p *Pin) Body() []byte {
+	if p.DecodedContentBody != nil {
+		return p.DecodedContentBody
+	}
+	return p.ContentBody
+}
+
+// ValidationResult represents the result of validating a PIN body
+type ValidationResult struct {
+	Schema string   `json:"schema"`           // Schema ID used for validation
+	Valid  bool     `json:"valid"`            // Whether the body matched the schema
+	Errors []string `json:"errors,omitempty"` // Validation errors
 }
 
 // ChainParser is the interface for chain parsers
@@ -60,15 +92,80 @@ type CreatorResolver interface {
 	ResolveCreator(chainName, txId string, vout uint32) (string, string, error)
 }
 
+// Outpoint identifies a transaction output
+type Outpoint struct {
+	TxId string
+	Vout uint32
+}
+
+// Creator is a resolved PIN creator
+type Creator struct {
+	Address string
+	MetaId  string
+}
+
+// BatchCreatorResolver is a creator resolver that can resolve many outpoints in one call
+// ProcessPins uses it to resolve all PINs of a transaction at once
+type BatchCreatorResolver interface {
+	CreatorResolver
+	// ResolveCreators resolves outpoints of one chain, results are in the order of outpoints
+	// A Creator with an empty Address means the outpoint could not be resolved
+	ResolveCreators(chainName string, outpoints []Outpoint) ([]Creator, error)
+}
+
+// PinValidator is the interface for PIN body validators
+// Validators attach their result to the PIN instead of dropping it
+type PinValidator interface {
+	// ValidatePin validates the PIN body
+	// Returns nil if the PIN is not covered by the validator
+	ValidatePin(pin *Pin) *ValidationResult
+}
+
 // ParserConfig represents the parser configuration
 type ParserConfig struct {
 	ProtocolID string // Protocol ID as hex string, default is "6d6574616964" (metaid)
 
+	// Operations is the set of accepted PIN operations and their required field counts
+	// If not provided, the standard operations (init, create, modify, revoke) are accepted
+	Operations common.OperationSet
+
 	// CreatorResolver is an optional creator address resolver
+	// If it also implements BatchCreatorResolver, the PINs of a transaction are resolved in one call
 	// If not provided, CreatorAddress and CreatorMetaId will be empty
 	CreatorResolver CreatorResolver
+	// ResolveCreators enables calling CreatorResolver from ProcessPins for every decoded PIN
+	// Off by default: a resolver usually queries a node or archive for each creator input
+	ResolveCreators bool
+
+	// Validator is an optional PIN body validator
+	// If not provided, Pin.Validation will be empty
+	Validator PinValidator
+
+	// DecodeContent enables decompression of PIN bodies carrying a content encoding marker
+	// such as "application/json;gzip", except encrypted bodies which pincrypto.Decrypt decodes
+	DecodeContent bool
+	// MaxDecodedSize caps the size of a decompressed body in bytes
+	// 0 means contentenc.DefaultMaxDecodedSize
+	MaxDecodedSize int64
+
+	// SniffContent sets how the real content type of PIN bodies is detected and enforced
+	// Default is SniffOff
+	SniffContent SniffPolicy
 }
 
+// SniffPolicy is the content-type sniffing policy
+type SniffPolicy int
+
+const (
+	// SniffOff disables content-type sniffing
+	SniffOff SniffPolicy = iota
+	// SniffRecord records DetectedContentType and ContentTypeMismatch on each PIN
+	SniffRecord
+	// SniffRejectDangerous records like SniffRecord and drops PINs whose body is active content
+	// (such as HTML or SVG) declared as a passive type (such as an image or plain text)
+	SniffRejectDangerous
+)
+
 // DefaultConfig returns the default configuration
 func DefaultConfig() *ParserConfig {
 	return &ParserConfig{
@@ -0,0 +1,389 @@
+package pincodec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+// CBOR major types
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+)
+
+// CBOR simple values
+const (
+	cborFalse = 0xf4
+	cborTrue  = 0xf5
+)
+
+// maxCBORDepth bounds the nesting of skipped unknown values
+const maxCBORDepth = 32
+
+// MarshalCBOR encodes a PIN as a CBOR map keyed by the field numbers of pin.proto
+// Zero fields are left out, except byte slices which are written when non-nil.
+// Strings that are not valid UTF-8 are written as byte strings, so they round-trip.
+func MarshalCBOR(pin *decoder.Pin) []byte {
+	var body []byte
+	count := 1
+	body = appendCBORUint(appendCBORUint(body, fieldSchemaVersion), SchemaVersion)
+	v := reflect.ValueOf(pin).Elem()
+	for _, f := range pinFields {
+		fv := v.Field(fieldIndex[f.num])
+		var value []byte
+		switch {
+		case fv.Kind() == reflect.String:
+			if fv.Len() > 0 {
+				value = appendCBORString(nil, fv.String())
+			}
+		case isBytes(fv):
+			if !fv.IsNil() {
+				value = appendCBORBytes(nil, fv.Bytes())
+			}
+		case fv.Kind() == reflect.Bool:
+			if fv.Bool() {
+				value = []byte{cborTrue}
+			}
+		case fv.CanUint():
+			if fv.Uint() != 0 {
+				value = appendCBORUint(nil, fv.Uint())
+			}
+		case fv.CanInt():
+			if fv.Int() != 0 {
+				value = appendCBORInt(nil, fv.Int())
+			}
+		}
+		if value != nil {
+			body = append(appendCBORUint(body, f.num), value...)
+			count++
+		}
+	}
+	if pin.Validation != nil {
+		body = appendCBORUint(body, fieldValidation)
+		body = appendCBORValidation(body, pin.Validation)
+		count++
+	}
+	return append(appendCBORHead(nil, majorMap, uint64(count)), body...)
+}
+
+// appendCBORValidation appends a validation result map
+func appendCBORValidation(b []byte, result *decoder.ValidationResult) []byte {
+	var body []byte
+	count := 0
+	if result.Schema != "" {
+		body = appendCBORString(appendCBORUint(body, validationSchema), result.Schema)
+		count++
+	}
+	if result.Valid {
+		body = append(appendCBORUint(body, validationValid), cborTrue)
+		count++
+	}
+	if result.Errors != nil {
+		body = appendCBORHead(appendCBORUint(body, validationErrors), majorArray, uint64(len(result.Errors)))
+		for _, e := range result.Errors {
+			body = appendCBORString(body, e)
+		}
+		count++
+	}
+	return append(appendCBORHead(b, majorMap, uint64(count)), body...)
+}
+
+// UnmarshalCBOR decodes a CBOR encoded PIN
+// Unknown keys are skipped.
+func UnmarshalCBOR(data []byte) (*decoder.Pin, error) {
+	r := &cborReader{data: data}
+	count, err := r.expect(majorMap)
+	if err != nil {
+		return nil, err
+	}
+	pin := &decoder.Pin{}
+	v := reflect.ValueOf(pin).Elem()
+	var version uint64
+	for i := uint64(0); i < count; i++ {
+		key, err := r.expect(majorUint)
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case fieldSchemaVersion:
+			if version, err = r.expect(majorUint); err != nil {
+				return nil, err
+			}
+		case fieldValidation:
+			if pin.Validation, err = r.validation(); err != nil {
+				return nil, err
+			}
+		default:
+			index, ok := fieldIndex[key]
+			if !ok {
+				if err := r.skip(0); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err := r.field(v.Field(index), key); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(r.data) != r.pos {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrMalformed, len(r.data)-r.pos)
+	}
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	return pin, nil
+}
+
+// cborReader reads CBOR items
+type cborReader struct {
+	data []byte
+	pos  int
+}
+
+// head reads the major type and argument of the next item
+// Indefinite lengths are not supported.
+func (r *cborReader) head() (byte, uint64, error) {
+	if r.pos >= len(r.data) {
+		return 0, 0, fmt.Errorf("%w: unexpected end of data", ErrMalformed)
+	}
+	initial := r.data[r.pos]
+	r.pos++
+	major, info := initial>>5, initial&0x1f
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+	if info > 27 {
+		return 0, 0, fmt.Errorf("%w: unsupported additional information %d", ErrMalformed, info)
+	}
+	size := 1 << (info - 24)
+	if len(r.data)-r.pos < size {
+		return 0, 0, fmt.Errorf("%w: unexpected end of data", ErrMalformed)
+	}
+	buf := make([]byte, 8)
+	copy(buf[8-size:], r.data[r.pos:r.pos+size])
+	r.pos += size
+	return major, binary.BigEndian.Uint64(buf), nil
+}
+
+// expect reads the head of an item of a major type
+func (r *cborReader) expect(major byte) (uint64, error) {
+	m, arg, err := r.head()
+	if err != nil {
+		return 0, err
+	}
+	if m != major {
+		return 0, fmt.Errorf("%w: expected major type %d, got %d", ErrMalformed, major, m)
+	}
+	return arg, nil
+}
+
+// bytes reads the content of a byte or text string of length n
+func (r *cborReader) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrMalformed)
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// str reads a text string, or a byte string holding a string that is not valid UTF-8
+func (r *cborReader) str() (string, error) {
+	major, n, err := r.head()
+	if err != nil {
+		return "", err
+	}
+	if major != majorText && major != majorBytes {
+		return "", fmt.Errorf("%w: expected a string, got major type %d", ErrMalformed, major)
+	}
+	b, err := r.bytes(n)
+	return string(b), err
+}
+
+// field reads the value of a Pin field
+func (r *cborReader) field(fv reflect.Value, key uint64) error {
+	switch {
+	case fv.Kind() == reflect.String:
+		s, err := r.str()
+		if err != nil {
+			return err
+		}
+		fv.SetString(s)
+	case isBytes(fv):
+		n, err := r.expect(majorBytes)
+		if err != nil {
+			return err
+		}
+		b, err := r.bytes(n)
+		if err != nil {
+			return err
+		}
+		fv.SetBytes(append([]byte{}, b...))
+	case fv.Kind() == reflect.Bool:
+		if r.pos >= len(r.data) || (r.data[r.pos] != cborTrue && r.data[r.pos] != cborFalse) {
+			return fmt.Errorf("%w: field %d is not a bool", ErrMalformed, key)
+		}
+		fv.SetBool(r.data[r.pos] == cborTrue)
+		r.pos++
+	case fv.CanUint():
+		x, err := r.expect(majorUint)
+		if err != nil {
+			return err
+		}
+		if fv.OverflowUint(x) {
+			return fmt.Errorf("%w: field %d overflows", ErrMalformed, key)
+		}
+		fv.SetUint(x)
+	case fv.CanInt():
+		major, x, err := r.head()
+		if err != nil {
+			return err
+		}
+		if (major != majorUint && major != majorNegInt) || x > math.MaxInt64 {
+			return fmt.Errorf("%w: field %d is not an integer", ErrMalformed, key)
+		}
+		n := int64(x)
+		if major == majorNegInt {
+			n = -1 - n
+		}
+		if fv.OverflowInt(n) {
+			return fmt.Errorf("%w: field %d overflows", ErrMalformed, key)
+		}
+		fv.SetInt(n)
+	}
+	return nil
+}
+
+// validation reads a validation result map
+func (r *cborReader) validation() (*decoder.ValidationResult, error) {
+	count, err := r.expect(majorMap)
+	if err != nil {
+		return nil, err
+	}
+	result := &decoder.ValidationResult{}
+	for i := uint64(0); i < count; i++ {
+		key, err := r.expect(majorUint)
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case validationSchema:
+			if result.Schema, err = r.str(); err != nil {
+				return nil, err
+			}
+		case validationValid:
+			valid := reflect.ValueOf(&result.Valid).Elem()
+			if err := r.field(valid, key); err != nil {
+				return nil, err
+			}
+		case validationErrors:
+			n, err := r.expect(majorArray)
+			if err != nil {
+				return nil, err
+			}
+			if n > uint64(len(r.data)-r.pos) {
+				return nil, fmt.Errorf("%w: unexpected end of data", ErrMalformed)
+			}
+			result.Errors = make([]string, 0, n)
+			for j := uint64(0); j < n; j++ {
+				e, err := r.str()
+				if err != nil {
+					return nil, err
+				}
+				result.Errors = append(result.Errors, e)
+			}
+		default:
+			if err := r.skip(0); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// skip skips the next item
+func (r *cborReader) skip(depth int) error {
+	if depth > maxCBORDepth {
+		return fmt.Errorf("%w: nesting too deep", ErrMalformed)
+	}
+	major, arg, err := r.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case majorBytes, majorText:
+		_, err = r.bytes(arg)
+		return err
+	case majorArray, majorMap:
+		items := arg
+		if major == majorMap {
+			items *= 2
+		}
+		if items > uint64(len(r.data)-r.pos) {
+			return fmt.Errorf("%w: unexpected end of data", ErrMalformed)
+		}
+		for i := uint64(0); i < items; i++ {
+			if err := r.skip(depth + 1); err != nil {
+				return err
+			}
+		}
+	case majorTag:
+		return r.skip(depth + 1)
+	}
+	return nil
+}
+
+// appendCBORHead appends the head of an item
+func appendCBORHead(b []byte, major byte, arg uint64) []byte {
+	m := major << 5
+	switch {
+	case arg < 24:
+		return append(b, m|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(b, m|24, byte(arg))
+	case arg <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, m|25), uint16(arg))
+	case arg <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, m|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, m|27), arg)
+	}
+}
+
+// appendCBORUint appends an unsigned integer
+func appendCBORUint(b []byte, x uint64) []byte {
+	return appendCBORHead(b, majorUint, x)
+}
+
+// appendCBORInt appends a signed integer
+func appendCBORInt(b []byte, n int64) []byte {
+	if n < 0 {
+		return appendCBORHead(b, majorNegInt, uint64(-1-n))
+	}
+	return appendCBORHead(b, majorUint, uint64(n))
+}
+
+// appendCBORBytes appends a byte string
+func appendCBORBytes(b, data []byte) []byte {
+	return append(appendCBORHead(b, majorBytes, uint64(len(data))), data...)
+}
+
+// appendCBORString appends a text string, or a byte string if s is not valid UTF-8
+func appendCBORString(b []byte, s string) []byte {
+	major := byte(majorText)
+	if !utf8.ValidString(s) {
+		major = majorBytes
+	}
+	return append(appendCBORHead(b, major, uint64(len(s))), s...)
+}
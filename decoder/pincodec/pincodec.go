@@ -0,0 +1,104 @@
+// Package pincodec encodes decoder.Pin in compact binary formats for message buses:
+// Protocol Buffers, following pin.proto, and CBOR.
+//
+// Unlike JSON, both carry bodies as raw bytes. Records start with a schema version;
+// the version only changes on incompatible changes, new fields get new numbers and are
+// skipped by older decoders, and decoders reject versions newer than SchemaVersion.
+package pincodec
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+// SchemaVersion is the schema version written in every record
+const SchemaVersion = 1
+
+// Errors returned by the decoders
+var (
+	// ErrMalformed is returned for records that cannot be decoded
+	ErrMalformed = errors.New("pincodec: malformed record")
+	// ErrUnsupportedVersion is returned for records without a schema version or with a newer one
+	ErrUnsupportedVersion = errors.New("pincodec: unsupported schema version")
+)
+
+// Field numbers, shared by the protobuf fields and the CBOR map keys, see pin.proto
+const (
+	fieldSchemaVersion = 1
+	fieldValidation    = 34
+
+	validationSchema = 1
+	validationValid  = 2
+	validationErrors = 3
+)
+
+// pinFields numbers the scalar fields of decoder.Pin
+// Numbers are never reused; a removed field keeps its number reserved in pin.proto.
+var pinFields = []struct {
+	num  uint64
+	name string
+}{
+	{2, "Id"},
+	{3, "OwnerAddress"},
+	{4, "OwnerMetaId"},
+	{5, "CreatorAddress"},
+	{6, "CreatorMetaId"},
+	{7, "CreatorInputLocation"},
+	{8, "CreatorInputTxVinLocation"},
+	{9, "Offset"},
+	{10, "Location"},
+	{11, "Output"},
+	{12, "OutputValue"},
+	{13, "OutputClass"},
+	{14, "Timestamp"},
+	{15, "Operation"},
+	{16, "OriginalPath"},
+	{17, "Path"},
+	{18, "ParentPath"},
+	{19, "Host"},
+	{20, "Encryption"},
+	{21, "Version"},
+	{22, "ContentType"},
+	{23, "ContentBody"},
+	{24, "ContentLength"},
+	{25, "ContentEncoding"},
+	{26, "DecodedContentBody"},
+	{27, "ContentDecodeError"},
+	{28, "DetectedContentType"},
+	{29, "ContentTypeMismatch"},
+	{30, "TxID"},
+	{31, "Vout"},
+	{32, "ChainName"},
+	{33, "InscriptionTxIndex"},
+}
+
+// fieldIndex maps field numbers to the struct field index in decoder.Pin
+var fieldIndex = func() map[uint64]int {
+	pinType := reflect.TypeOf(decoder.Pin{})
+	index := make(map[uint64]int, len(pinFields))
+	for _, f := range pinFields {
+		sf, ok := pinType.FieldByName(f.name)
+		if !ok {
+			panic(fmt.Sprintf("pincodec: decoder.Pin has no field %s", f.name))
+		}
+		index[f.num] = sf.Index[0]
+	}
+	return index
+}()
+
+// isBytes reports whether a struct field is a byte slice
+// Byte slices are written when non-nil, even if empty, so nil and empty bodies round-trip.
+func isBytes(v reflect.Value) bool {
+	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8
+}
+
+// checkVersion checks the schema version of a decoded record
+func checkVersion(version uint64) error {
+	if version == 0 || version > SchemaVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package pincodec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+// codecs lists the encodings under test
+var codecs = []struct {
+	name      string
+	marshal   func(*decoder.Pin) []byte
+	unmarshal func([]byte) (*decoder.Pin, error)
+}{
+	{"proto", MarshalProto, UnmarshalProto},
+	{"cbor", MarshalCBOR, UnmarshalCBOR},
+}
+
+// fullPin sets every field of a PIN
+func fullPin() *decoder.Pin {
+	return &decoder.Pin{
+		Id:                        "1111111111111111111111111111111111111111111111111111111111111111i0",
+		OwnerAddress:              "12ZEw5Hcv1hTb6YUQJ69y1V7uhcoDz92PH",
+		OwnerMetaId:               "owner",
+		CreatorAddress:            "1111111111111111111114oLvT2",
+		CreatorMetaId:             "creator",
+		CreatorInputLocation:      "aa:1",
+		CreatorInputTxVinLocation: "aa:1",
+		Offset:                    1 << 40,
+		Location:                  "11:0:0",
+		Output:                    "11:0",
+		OutputValue:               -546,
+		OutputClass:               "pubkeyhash",
+		Timestamp:                 1713571767,
+		Operation:                 "create",
+		OriginalPath:              "host:/protocols/simplebuzz",
+		Path:                      "/protocols/simplebuzz",
+		ParentPath:                "/protocols",
+		Host:                      "host\xff", // not UTF-8
+		Encryption:                "0",
+		Version:                   "1.0.0",
+		ContentType:               "image/png;gzip",
+		ContentBody:               bytes.Repeat([]byte{0x89, 0x50, 0x00}, 100),
+		ContentLength:             300,
+		ContentEncoding:           "gzip",
+		DecodedContentBody:        []byte{},
+		ContentDecodeError:        "none",
+		DetectedContentType:       "image/png",
+		ContentTypeMismatch:       true,
+		TxID:                      "11",
+		Vout:                      4294967295,
+		ChainName:                 "btc",
+		InscriptionTxIndex:        -1,
+		Validation:                &decoder.ValidationResult{Schema: "/protocols/simplebuzz", Valid: true, Errors: []string{"a", "b"}},
+	}
+}
+
+func TestPinFields(t *testing.T) {
+	// Every Pin field has a number, so a new field cannot be silently dropped
+	numbered := map[string]bool{"Validation": true}
+	seen := map[uint64]bool{fieldSchemaVersion: true, fieldValidation: true}
+	for _, f := range pinFields {
+		if seen[f.num] {
+			t.Errorf("Field number %d is used twice", f.num)
+		}
+		seen[f.num] = true
+		numbered[f.name] = true
+	}
+	pinType := reflect.TypeOf(decoder.Pin{})
+	for i := 0; i < pinType.NumField(); i++ {
+		if name := pinType.Field(i).Name; !numbered[name] {
+			t.Errorf("decoder.Pin.%s has no field number, add it to pinFields and pin.proto", name)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	pins := map[string]*decoder.Pin{
+		"full":  fullPin(),
+		"empty": {},
+		"nil body": {
+			Id:        "x",
+			Operation: "revoke",
+		},
+	}
+	for _, codec := range codecs {
+		for name, pin := range pins {
+			t.Run(codec.name+"/"+name, func(t *testing.T) {
+				got, err := codec.unmarshal(codec.marshal(pin))
+				if err != nil {
+					t.Fatalf("Unmarshal failed: %v", err)
+				}
+				if !reflect.DeepEqual(got, pin) {
+					t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", got, pin)
+				}
+			})
+		}
+	}
+}
+
+func TestSize(t *testing.T) {
+	pin := fullPin()
+	pin.ContentBody = bytes.Repeat([]byte{0xab}, 30000)
+	jsonData, _ := json.Marshal(pin)
+	for _, codec := range codecs {
+		data := codec.marshal(pin)
+		if len(data) > len(pin.ContentBody)+1000 || len(data) >= len(jsonData)*3/4 {
+			t.Errorf("%s: %d bytes for a %d byte body, JSON takes %d", codec.name, len(data), len(pin.ContentBody), len(jsonData))
+		}
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	pin := &decoder.Pin{Id: "a", Vout: 1, OutputValue: -1, ContentBody: []byte{}}
+	tests := []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		// schema_version=1, id="a", content_body=empty, output_value=-1 as a 10 byte varint, vout=1
+		{"proto", MarshalProto(pin), "0801" + "120161" + "60ffffffffffffffffff01" + "ba0100" + "f80101"},
+		// {1: 1, 2: "a", 12: -1, 23: h'', 31: 1}
+		{"cbor", MarshalCBOR(pin), "a5" + "0101" + "026161" + "0c20" + "1740" + "181f01"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(tt.got); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	pin := &decoder.Pin{Id: "a", Path: "/p"}
+
+	// A newer writer added field 99 as a string, a varint and a fixed64
+	data := MarshalProto(pin)
+	data = appendBytesField(data, 99, []byte("new"))
+	data = appendVarintField(data, 100, 7)
+	data = append(appendVarint(data, 101<<3|wireFixed64), make([]byte, 8)...)
+	got, err := UnmarshalProto(data)
+	if err != nil || !reflect.DeepEqual(got, pin) {
+		t.Errorf("proto: got %+v, %v", got, err)
+	}
+
+	// {1: 1, 2: "a", 17: "/p", 99: {"k": [1, h'00']}, 100: 55799("x")}
+	data, _ = hex.DecodeString("a5" + "0101" + "026161" + "1162" + "2f70" + "1863a1616b820141" + "00" + "1864d9d9f76178")
+	got, err = UnmarshalCBOR(data)
+	if err != nil || !reflect.DeepEqual(got, pin) {
+		t.Errorf("cbor: got %+v, %v", got, err)
+	}
+}
+
+func TestSchemaVersion(t *testing.T) {
+	newer := appendVarintField(nil, fieldSchemaVersion, SchemaVersion+1)
+	if _, err := UnmarshalProto(newer); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("proto: expected ErrUnsupportedVersion for a newer version, got %v", err)
+	}
+	if _, err := UnmarshalProto(appendBytesField(nil, 2, []byte("a"))); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("proto: expected ErrUnsupportedVersion without a version, got %v", err)
+	}
+	if _, err := UnmarshalCBOR([]byte{0xa1, 0x01, 0x02}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("cbor: expected ErrUnsupportedVersion for a newer version, got %v", err)
+	}
+	if _, err := UnmarshalCBOR([]byte{0xa0}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("cbor: expected ErrUnsupportedVersion without a version, got %v", err)
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	for _, codec := range codecs {
+		data := codec.marshal(fullPin())
+		for _, n := range []int{1, 10, len(data) / 2, len(data) - 1} {
+			if _, err := codec.unmarshal(data[:n]); !errors.Is(err, ErrMalformed) {
+				t.Errorf("%s: expected ErrMalformed for %d of %d bytes, got %v", codec.name, n, len(data), err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		unmarshal func([]byte) (*decoder.Pin, error)
+		data      string
+	}{
+		{"proto string as varint", UnmarshalProto, "0801" + "1001"},
+		{"proto vout overflow", UnmarshalProto, "0801" + "f8018080808010"},
+		{"proto group", UnmarshalProto, "0801" + "13"},
+		{"cbor not a map", UnmarshalCBOR, "80"},
+		{"cbor text key", UnmarshalCBOR, "a1" + "6161" + "01"},
+		{"cbor bool as int", UnmarshalCBOR, "a2" + "0101" + "181d01"},
+		{"cbor indefinite", UnmarshalCBOR, "bf"},
+		{"cbor trailing", UnmarshalCBOR, "a1" + "0101" + "00"},
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.data)
+		if _, err := tt.unmarshal(data); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%s: expected ErrMalformed, got %v", tt.name, err)
+		}
+	}
+}
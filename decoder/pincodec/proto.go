@@ -0,0 +1,234 @@
+package pincodec
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// MarshalProto encodes a PIN as a metaid.v1.Pin protobuf message, see pin.proto
+func MarshalProto(pin *decoder.Pin) []byte {
+	b := appendVarintField(nil, fieldSchemaVersion, SchemaVersion)
+	v := reflect.ValueOf(pin).Elem()
+	for _, f := range pinFields {
+		fv := v.Field(fieldIndex[f.num])
+		switch {
+		case fv.Kind() == reflect.String:
+			if fv.Len() > 0 {
+				b = appendBytesField(b, f.num, []byte(fv.String()))
+			}
+		case isBytes(fv):
+			if !fv.IsNil() {
+				b = appendBytesField(b, f.num, fv.Bytes())
+			}
+		case fv.Kind() == reflect.Bool:
+			if fv.Bool() {
+				b = appendVarintField(b, f.num, 1)
+			}
+		case fv.CanUint():
+			if fv.Uint() != 0 {
+				b = appendVarintField(b, f.num, fv.Uint())
+			}
+		case fv.CanInt():
+			if fv.Int() != 0 {
+				b = appendVarintField(b, f.num, uint64(fv.Int()))
+			}
+		}
+	}
+	if pin.Validation != nil {
+		var m []byte
+		if pin.Validation.Schema != "" {
+			m = appendBytesField(m, validationSchema, []byte(pin.Validation.Schema))
+		}
+		if pin.Validation.Valid {
+			m = appendVarintField(m, validationValid, 1)
+		}
+		for _, e := range pin.Validation.Errors {
+			m = appendBytesField(m, validationErrors, []byte(e))
+		}
+		b = appendBytesField(b, fieldValidation, m)
+	}
+	return b
+}
+
+// UnmarshalProto decodes a metaid.v1.Pin protobuf message
+// Unknown fields are skipped.
+func UnmarshalProto(data []byte) (*decoder.Pin, error) {
+	pin := &decoder.Pin{}
+	v := reflect.ValueOf(pin).Elem()
+	var version uint64
+	err := readFields(data, func(num uint64, wireType int, x uint64, body []byte) error {
+		if num == fieldSchemaVersion {
+			if wireType != wireVarint {
+				return fmt.Errorf("%w: schema version is not a varint", ErrMalformed)
+			}
+			version = x
+			return nil
+		}
+		if num == fieldValidation {
+			if wireType != wireBytes {
+				return fmt.Errorf("%w: validation is not a message", ErrMalformed)
+			}
+			validation, err := unmarshalValidation(body)
+			pin.Validation = validation
+			return err
+		}
+		index, ok := fieldIndex[num]
+		if !ok {
+			return nil
+		}
+		return setField(v.Field(index), num, wireType == wireBytes, x, body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	return pin, nil
+}
+
+// unmarshalValidation decodes a metaid.v1.ValidationResult message
+func unmarshalValidation(data []byte) (*decoder.ValidationResult, error) {
+	result := &decoder.ValidationResult{}
+	err := readFields(data, func(num uint64, wireType int, x uint64, body []byte) error {
+		switch {
+		case num == validationSchema && wireType == wireBytes:
+			result.Schema = string(body)
+		case num == validationValid && wireType == wireVarint:
+			result.Valid = x != 0
+		case num == validationErrors && wireType == wireBytes:
+			result.Errors = append(result.Errors, string(body))
+		case num <= validationErrors:
+			return fmt.Errorf("%w: validation field %d has wire type %d", ErrMalformed, num, wireType)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// setField sets a Pin field from a decoded value, isBytesValue tells a length-delimited value
+// The value of x or body is used by integer and bool fields or by string and bytes fields.
+func setField(fv reflect.Value, num uint64, isBytesValue bool, x uint64, body []byte) error {
+	switch {
+	case fv.Kind() == reflect.String:
+		if !isBytesValue {
+			return fmt.Errorf("%w: field %d is not a string", ErrMalformed, num)
+		}
+		fv.SetString(string(body))
+	case isBytes(fv):
+		if !isBytesValue {
+			return fmt.Errorf("%w: field %d is not bytes", ErrMalformed, num)
+		}
+		fv.SetBytes(append([]byte{}, body...))
+	case isBytesValue:
+		return fmt.Errorf("%w: field %d is not a number", ErrMalformed, num)
+	case fv.Kind() == reflect.Bool:
+		fv.SetBool(x != 0)
+	case fv.CanUint():
+		if fv.OverflowUint(x) {
+			return fmt.Errorf("%w: field %d overflows", ErrMalformed, num)
+		}
+		fv.SetUint(x)
+	case fv.CanInt():
+		if fv.OverflowInt(int64(x)) {
+			return fmt.Errorf("%w: field %d overflows", ErrMalformed, num)
+		}
+		fv.SetInt(int64(x))
+	}
+	return nil
+}
+
+// readFields calls fn with each field of a message
+// x is the value of varint fields, body the value of length-delimited fields
+func readFields(data []byte, fn func(num uint64, wireType int, x uint64, body []byte) error) error {
+	for len(data) > 0 {
+		tag, n := readVarint(data)
+		if n == 0 {
+			return fmt.Errorf("%w: invalid field tag", ErrMalformed)
+		}
+		data = data[n:]
+		num, wireType := tag>>3, int(tag&7)
+		if num == 0 {
+			return fmt.Errorf("%w: field number 0", ErrMalformed)
+		}
+		var x uint64
+		var body []byte
+		switch wireType {
+		case wireVarint:
+			x, n = readVarint(data)
+			if n == 0 {
+				return fmt.Errorf("%w: invalid varint in field %d", ErrMalformed, num)
+			}
+			data = data[n:]
+		case wireBytes:
+			size, n := readVarint(data)
+			if n == 0 || size > uint64(len(data)-n) {
+				return fmt.Errorf("%w: truncated field %d", ErrMalformed, num)
+			}
+			body = data[n : n+int(size)]
+			data = data[n+int(size):]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if wireType == wireFixed32 {
+				size = 4
+			}
+			if len(data) < size {
+				return fmt.Errorf("%w: truncated field %d", ErrMalformed, num)
+			}
+			data = data[size:]
+			continue // no field of this package uses fixed-size values
+		default:
+			return fmt.Errorf("%w: unsupported wire type %d in field %d", ErrMalformed, wireType, num)
+		}
+		if err := fn(num, wireType, x, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readVarint reads a base 128 varint, returning 0 bytes read on error
+func readVarint(data []byte) (uint64, int) {
+	var x uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		b := data[i]
+		if i == 9 && b > 1 {
+			return 0, 0
+		}
+		x |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return x, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// appendVarint appends a base 128 varint
+func appendVarint(b []byte, x uint64) []byte {
+	for x >= 0x80 {
+		b = append(b, byte(x)|0x80)
+		x >>= 7
+	}
+	return append(b, byte(x))
+}
+
+// appendVarintField appends a varint field
+func appendVarintField(b []byte, num, x uint64) []byte {
+	return appendVarint(appendVarint(b, num<<3|wireVarint), x)
+}
+
+// appendBytesField appends a length-delimited field
+func appendBytesField(b []byte, num uint64, body []byte) []byte {
+	b = appendVarint(appendVarint(b, num<<3|wireBytes), uint64(len(body)))
+	return append(b, body...)
+}
@@ -0,0 +1,54 @@
+package pincrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// AES ciphertexts are laid out as nonce (12 bytes) || AES-GCM ciphertext and tag
+
+// EncryptAES encrypts plaintext with a 16, 24 or 32 byte key
+// random is the source of the nonce, nil means crypto/rand
+func EncryptAES(random io.Reader, key, plaintext []byte) ([]byte, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// DecryptAES decrypts an AES ciphertext with a 16, 24 or 32 byte key
+func DecryptAES(key, data []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
+
+// newGCM creates an AES-GCM cipher
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("pincrypto: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
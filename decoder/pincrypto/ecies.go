@@ -0,0 +1,140 @@
+package pincrypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// ECIES follows the Electrum BIE1 layout used by BSV-family wallets:
+// "BIE1" || ephemeral pubkey (33 bytes, compressed) || AES-128-CBC ciphertext || HMAC-SHA256 (32 bytes)
+// iv, encryption key and MAC key are sha512(compressed ECDH point) split into 16, 16 and 32 bytes
+
+var eciesMagic = []byte("BIE1")
+
+const (
+	eciesPubKeyLen = 33
+	eciesMacLen    = sha256.Size
+)
+
+// EncryptECIES encrypts plaintext to pub
+// random is the source of the ephemeral key, nil means crypto/rand
+func EncryptECIES(random io.Reader, pub *btcec.PublicKey, plaintext []byte) ([]byte, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	ephemeral, err := newPrivateKey(random)
+	if err != nil {
+		return nil, err
+	}
+
+	iv, keyE, keyM := eciesKeys(ephemeral, pub)
+	block, err := aes.NewCipher(keyE)
+	if err != nil {
+		return nil, err
+	}
+	padded := pkcs7Pad(plaintext, aes.BlockSize)
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+	var out bytes.Buffer
+	out.Write(eciesMagic)
+	out.Write(ephemeral.PubKey().SerializeCompressed())
+	out.Write(ciphertext)
+	mac := hmac.New(sha256.New, keyM)
+	mac.Write(out.Bytes())
+	out.Write(mac.Sum(nil))
+	return out.Bytes(), nil
+}
+
+// DecryptECIES decrypts an ECIES ciphertext with priv
+func DecryptECIES(priv *btcec.PrivateKey, data []byte) ([]byte, error) {
+	minLen := len(eciesMagic) + eciesPubKeyLen + aes.BlockSize + eciesMacLen
+	if len(data) < minLen || !bytes.Equal(data[:len(eciesMagic)], eciesMagic) {
+		return nil, ErrInvalidCiphertext
+	}
+
+	pubStart := len(eciesMagic)
+	ephemeral, err := btcec.ParsePubKey(data[pubStart : pubStart+eciesPubKeyLen])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCiphertext, err)
+	}
+
+	iv, keyE, keyM := eciesKeys(priv, ephemeral)
+	macStart := len(data) - eciesMacLen
+	mac := hmac.New(sha256.New, keyM)
+	mac.Write(data[:macStart])
+	if !hmac.Equal(mac.Sum(nil), data[macStart:]) {
+		return nil, ErrAuthentication
+	}
+
+	ciphertext := data[pubStart+eciesPubKeyLen : macStart]
+	if len(ciphertext)%aes.BlockSize != 0 {
+		return nil, ErrInvalidCiphertext
+	}
+	block, err := aes.NewCipher(keyE)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+	return pkcs7Unpad(plaintext, aes.BlockSize)
+}
+
+// eciesKeys derives the iv, encryption key and MAC key from an ECDH exchange
+func eciesKeys(priv *btcec.PrivateKey, pub *btcec.PublicKey) (iv, keyE, keyM []byte) {
+	var point, result btcec.JacobianPoint
+	pub.AsJacobian(&point)
+	btcec.ScalarMultNonConst(&priv.Key, &point, &result)
+	result.ToAffine()
+	shared := btcec.NewPublicKey(&result.X, &result.Y).SerializeCompressed()
+
+	hash := sha512.Sum512(shared)
+	return hash[0:16], hash[16:32], hash[32:64]
+}
+
+// newPrivateKey reads a private key from random
+func newPrivateKey(random io.Reader) (*btcec.PrivateKey, error) {
+	var buf [32]byte
+	for {
+		if _, err := io.ReadFull(random, buf[:]); err != nil {
+			return nil, err
+		}
+		var scalar btcec.ModNScalar
+		overflow := scalar.SetBytes(&buf)
+		if overflow == 0 && !scalar.IsZero() {
+			return btcec.PrivKeyFromScalar(&scalar), nil
+		}
+	}
+}
+
+// pkcs7Pad pads data to a multiple of blockSize
+func pkcs7Pad(data []byte, blockSize int) []byte {
+	padding := blockSize - len(data)%blockSize
+	return append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
+
+// pkcs7Unpad removes PKCS#7 padding
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, ErrInvalidCiphertext
+	}
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > blockSize {
+		return nil, ErrInvalidCiphertext
+	}
+	for _, b := range data[len(data)-padding:] {
+		if int(b) != padding {
+			return nil, ErrInvalidCiphertext
+		}
+	}
+	return data[:len(data)-padding], nil
+}
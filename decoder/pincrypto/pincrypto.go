@@ -0,0 +1,148 @@
+package pincrypto
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/contentenc"
+)
+
+// Values of Pin.Encryption, as defined by the encryption field of the MetaID PIN specification
+// (https://metaid.io): 0 is plain, 1 is ECIES with the recipient's key, and 2 is symmetric
+// encryption with a key shared by the parties, such as an ECDH secret
+const (
+	EncryptionNone  = "0" // Plain content
+	EncryptionECIES = "1" // ECIES (Electrum BIE1) with the recipient's secp256k1 key
+	EncryptionAES   = "2" // AES-GCM with a shared symmetric key
+)
+
+var (
+	// ErrNotEncrypted is returned when decrypting a PIN whose Encryption is "0"
+	ErrNotEncrypted = errors.New("pincrypto: pin is not encrypted")
+	// ErrUnknownScheme is returned for unknown Encryption values
+	ErrUnknownScheme = errors.New("pincrypto: unknown encryption scheme")
+	// ErrNoKey is returned when the key provider has no key for a PIN
+	ErrNoKey = errors.New("pincrypto: no key for pin")
+	// ErrInvalidCiphertext is returned for malformed ciphertexts
+	ErrInvalidCiphertext = errors.New("pincrypto: invalid ciphertext")
+	// ErrAuthentication is returned when a ciphertext fails its integrity check
+	ErrAuthentication = errors.New("pincrypto: authentication failed")
+)
+
+// KeyProvider is the interface for key lookup
+// External implementations can select keys based on the PIN owner, path or host
+type KeyProvider interface {
+	// PrivateKey returns the private key used to decrypt an ECIES PIN
+	// Returns ErrNoKey if no key is available
+	PrivateKey(pin *decoder.Pin) (*btcec.PrivateKey, error)
+
+	// SymmetricKey returns the AES key (16, 24 or 32 bytes) used to decrypt an AES PIN
+	// Returns ErrNoKey if no key is available
+	SymmetricKey(pin *decoder.Pin) ([]byte, error)
+}
+
+// StaticKeys is a KeyProvider returning the same keys for every PIN
+type StaticKeys struct {
+	Private   *btcec.PrivateKey
+	Symmetric []byte
+}
+
+// PrivateKey implements KeyProvider
+func (k *StaticKeys) PrivateKey(pin *decoder.Pin) (*btcec.PrivateKey, error) {
+	if k == nil || k.Private == nil {
+		return nil, ErrNoKey
+	}
+	return k.Private, nil
+}
+
+// SymmetricKey implements KeyProvider
+func (k *StaticKeys) SymmetricKey(pin *decoder.Pin) ([]byte, error) {
+	if k == nil || len(k.Symmetric) == 0 {
+		return nil, ErrNoKey
+	}
+	return k.Symmetric, nil
+}
+
+// IsEncrypted checks whether a PIN body is encrypted
+func IsEncrypted(pin *decoder.Pin) bool {
+	return pin.Encryption != "" && pin.Encryption != EncryptionNone
+}
+
+// Decrypt decrypts the raw PIN body, ContentBody, according to its Encryption field
+// Bodies are encoded before they are encrypted, so the content encoding marked by the content
+// type or version is decoded from the plaintext, up to maxDecodedSize bytes. Pass the
+// ParserConfig.MaxDecodedSize the PIN was parsed with; 0 means contentenc.DefaultMaxDecodedSize.
+// Returns ErrNoKey if keys is nil.
+func Decrypt(pin *decoder.Pin, keys KeyProvider, maxDecodedSize int64) ([]byte, error) {
+	switch pin.Encryption {
+	case "", EncryptionNone:
+		return nil, ErrNotEncrypted
+	case EncryptionECIES, EncryptionAES:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownScheme, pin.Encryption)
+	}
+	if keys == nil {
+		return nil, ErrNoKey
+	}
+
+	plaintext, err := decrypt(pin, keys)
+	if err != nil {
+		return nil, err
+	}
+	encoding := contentenc.Detect(pin.ContentType, pin.Version)
+	if encoding == contentenc.Identity {
+		return plaintext, nil
+	}
+	decoded, err := contentenc.Decode(encoding, plaintext, maxDecodedSize)
+	if err != nil {
+		return nil, fmt.Errorf("pincrypto: failed to decode %s plaintext: %w", encoding, err)
+	}
+	return decoded, nil
+}
+
+// decrypt decrypts ContentBody with the key of its scheme
+func decrypt(pin *decoder.Pin, keys KeyProvider) ([]byte, error) {
+	if pin.Encryption == EncryptionECIES {
+		priv, err := keys.PrivateKey(pin)
+		if err != nil {
+			return nil, err
+		}
+		return DecryptECIES(priv, pin.ContentBody)
+	}
+	key, err := keys.SymmetricKey(pin)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptAES(key, pin.ContentBody)
+}
+
+// Encryption holds the keys used when building an encrypted PIN body
+type Encryption struct {
+	Scheme    string           // EncryptionECIES or EncryptionAES
+	PublicKey *btcec.PublicKey // Recipient key for ECIES
+	Symmetric []byte           // Shared key for AES
+	Rand      io.Reader        // Randomness source, nil means crypto/rand
+}
+
+// Encrypt encrypts a PIN body; the result goes into ContentBody with Encryption set to e.Scheme
+func (e *Encryption) Encrypt(plaintext []byte) ([]byte, error) {
+	switch e.Scheme {
+	case EncryptionECIES:
+		if e.PublicKey == nil {
+			return nil, ErrNoKey
+		}
+		return EncryptECIES(e.Rand, e.PublicKey, plaintext)
+	case EncryptionAES:
+		if len(e.Symmetric) == 0 {
+			return nil, ErrNoKey
+		}
+		return EncryptAES(e.Rand, e.Symmetric, plaintext)
+	case "", EncryptionNone:
+		return nil, ErrNotEncrypted
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownScheme, e.Scheme)
+}
@@ -0,0 +1,258 @@
+package pincrypto
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+// Fixed key vectors
+const (
+	testPrivKeyHex  = "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"
+	testPubKeyHex   = "0339a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c2"
+	testAESKeyHex   = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	testPlaintext   = `{"content":"hello"}`
+	testECIESVector = "42494531034f355bdcb7cc0af728ef3cceb9615d90684bb5b2ca5f859ab0f0b704075871aa5a84dc266a49360619ea0fd422e8d4e0f3fe9edc9be91e73963d67bc54b549a3a1d3e599ef0144d5a0fcb9cd8800382b70f0e32045c8ad1407a6394a01d53754"
+	testAESVector   = "22222222222222222222222206365da90084ff74f3bfb588e79c8e11a9441a5787ba34470dda0485ed5658cd2018af"
+)
+
+// BIE1 vector computed outside this package, with pure Python secp256k1 arithmetic and OpenSSL
+// AES-128-CBC following Electrum's ecc.ECPubkey.encrypt_message
+const (
+	bie1PrivKeyHex = "360fe5c02b8a2433c4406aee82a64f27f178c25e30ad69beba30a14ee8496675"
+	bie1Plaintext  = "Hello, MetaID!"
+	bie1Base64     = "QklFMQM6uFj+NtpCUXLvS5ScF8hW40/xvRnRDXq1tERdCi3lHe3ZgSw5ba2f1dKwh6IIHLvTNzImJf7e1f+mTk3OYZ+t3fqtYjZp25zgecgme4MTmw=="
+)
+
+func testKeys(t *testing.T) (*btcec.PrivateKey, *btcec.PublicKey, []byte) {
+	t.Helper()
+	privBytes, _ := hex.DecodeString(testPrivKeyHex)
+	priv, pub := btcec.PrivKeyFromBytes(privBytes)
+	if hex.EncodeToString(pub.SerializeCompressed()) != testPubKeyHex {
+		t.Fatalf("Unexpected public key %x", pub.SerializeCompressed())
+	}
+	key, _ := hex.DecodeString(testAESKeyHex)
+	return priv, pub, key
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestEncryptECIES_Vector(t *testing.T) {
+	_, pub, _ := testKeys(t)
+	ciphertext, err := EncryptECIES(bytes.NewReader(bytes.Repeat([]byte{0x11}, 32)), pub, []byte(testPlaintext))
+	if err != nil {
+		t.Fatalf("EncryptECIES failed: %v", err)
+	}
+	if hex.EncodeToString(ciphertext) != testECIESVector {
+		t.Errorf("EncryptECIES = %x, expected %s", ciphertext, testECIESVector)
+	}
+}
+
+func TestEncryptAES_Vector(t *testing.T) {
+	_, _, key := testKeys(t)
+	ciphertext, err := EncryptAES(bytes.NewReader(bytes.Repeat([]byte{0x22}, 12)), key, []byte(testPlaintext))
+	if err != nil {
+		t.Fatalf("EncryptAES failed: %v", err)
+	}
+	if hex.EncodeToString(ciphertext) != testAESVector {
+		t.Errorf("EncryptAES = %x, expected %s", ciphertext, testAESVector)
+	}
+}
+
+func TestDecrypt_Vectors(t *testing.T) {
+	priv, _, key := testKeys(t)
+	keys := &StaticKeys{Private: priv, Symmetric: key}
+
+	tests := []struct {
+		encryption string
+		body       string
+	}{
+		{EncryptionECIES, testECIESVector},
+		{EncryptionAES, testAESVector},
+	}
+	for _, test := range tests {
+		pin := &decoder.Pin{Encryption: test.encryption, ContentBody: mustHex(test.body)}
+		plaintext, err := Decrypt(pin, keys, 0)
+		if err != nil {
+			t.Errorf("Decrypt(%s) failed: %v", test.encryption, err)
+			continue
+		}
+		if string(plaintext) != testPlaintext {
+			t.Errorf("Decrypt(%s) = %q, expected %q", test.encryption, plaintext, testPlaintext)
+		}
+	}
+}
+
+func TestDecryptECIES_IndependentVector(t *testing.T) {
+	priv, _ := btcec.PrivKeyFromBytes(mustHex(bie1PrivKeyHex))
+	body, err := base64.StdEncoding.DecodeString(bie1Base64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := Decrypt(&decoder.Pin{Encryption: EncryptionECIES, ContentBody: body}, &StaticKeys{Private: priv}, 0)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if string(plaintext) != bie1Plaintext {
+		t.Errorf("Decrypt = %q, expected %q", plaintext, bie1Plaintext)
+	}
+}
+
+func TestDecrypt_ContentEncoding(t *testing.T) {
+	priv, pub, _ := testKeys(t)
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(testPlaintext))
+	zw.Close()
+	ciphertext, err := EncryptECIES(nil, pub, compressed.Bytes())
+	if err != nil {
+		t.Fatalf("EncryptECIES failed: %v", err)
+	}
+
+	// Content decoding cannot run on the ciphertext, Decrypt decodes the plaintext
+	config := decoder.DefaultConfig()
+	config.DecodeContent = true
+	pins := decoder.ProcessPins(config, []*decoder.Pin{{Encryption: EncryptionECIES, ContentType: "application/json;gzip", ContentBody: ciphertext}})
+	if len(pins) != 1 || pins[0].ContentEncoding != "gzip" || pins[0].DecodedContentBody != nil || pins[0].ContentDecodeError != "" {
+		t.Fatalf("Unexpected processed pin %+v", pins)
+	}
+	pins[0].DecodedContentBody = []byte("stale")
+	plaintext, err := Decrypt(pins[0], &StaticKeys{Private: priv}, 0)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if string(plaintext) != testPlaintext {
+		t.Errorf("Decrypt = %q, expected %q", plaintext, testPlaintext)
+	}
+}
+
+func TestDecrypt_MaxDecodedSize(t *testing.T) {
+	priv, pub, _ := testKeys(t)
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write(bytes.Repeat([]byte("a"), 1024))
+	zw.Close()
+	ciphertext, err := EncryptECIES(nil, pub, compressed.Bytes())
+	if err != nil {
+		t.Fatalf("EncryptECIES failed: %v", err)
+	}
+	pin := &decoder.Pin{Encryption: EncryptionECIES, ContentType: "text/plain;gzip", ContentBody: ciphertext}
+	keys := &StaticKeys{Private: priv}
+
+	// The caller's limit applies to the decoded plaintext
+	if _, err := Decrypt(pin, keys, 16); err == nil {
+		t.Error("Expected a plaintext over the limit to be rejected")
+	}
+	if plaintext, err := Decrypt(pin, keys, 1024); err != nil || len(plaintext) != 1024 {
+		t.Errorf("Decrypt within the limit = %d bytes, %v", len(plaintext), err)
+	}
+}
+
+func TestEncryption_RoundTrip(t *testing.T) {
+	priv, pub, key := testKeys(t)
+	keys := &StaticKeys{Private: priv, Symmetric: key}
+
+	for _, enc := range []*Encryption{
+		{Scheme: EncryptionECIES, PublicKey: pub},
+		{Scheme: EncryptionAES, Symmetric: key},
+	} {
+		for _, plaintext := range [][]byte{{}, []byte("x"), bytes.Repeat([]byte("metaid"), 100)} {
+			ciphertext, err := enc.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt(%s) failed: %v", enc.Scheme, err)
+			}
+			pin := &decoder.Pin{Encryption: enc.Scheme, ContentBody: ciphertext}
+			decrypted, err := Decrypt(pin, keys, 0)
+			if err != nil {
+				t.Fatalf("Decrypt(%s) failed: %v", enc.Scheme, err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("Round trip (%s) mismatch", enc.Scheme)
+			}
+		}
+	}
+}
+
+func TestDecrypt_Errors(t *testing.T) {
+	priv, _, key := testKeys(t)
+	keys := &StaticKeys{Private: priv, Symmetric: key}
+
+	// Not encrypted
+	if _, err := Decrypt(&decoder.Pin{Encryption: "0"}, keys, 0); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("Expected ErrNotEncrypted, got %v", err)
+	}
+
+	// Unknown scheme
+	if _, err := Decrypt(&decoder.Pin{Encryption: "9"}, keys, 0); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("Expected ErrUnknownScheme, got %v", err)
+	}
+
+	// No key provider
+	for _, encryption := range []string{EncryptionECIES, EncryptionAES} {
+		if _, err := Decrypt(&decoder.Pin{Encryption: encryption}, nil, 0); !errors.Is(err, ErrNoKey) {
+			t.Errorf("Decrypt(%s) with nil keys = %v, expected ErrNoKey", encryption, err)
+		}
+	}
+	var noKeys *StaticKeys
+	if _, err := Decrypt(&decoder.Pin{Encryption: EncryptionAES}, noKeys, 0); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Expected ErrNoKey from nil *StaticKeys, got %v", err)
+	}
+
+	// Missing key
+	if _, err := Decrypt(&decoder.Pin{Encryption: EncryptionECIES, ContentBody: mustHex(testECIESVector)}, &StaticKeys{}, 0); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Expected ErrNoKey, got %v", err)
+	}
+
+	// Tampered ciphertexts
+	for _, test := range []struct {
+		encryption string
+		body       string
+	}{
+		{EncryptionECIES, testECIESVector},
+		{EncryptionAES, testAESVector},
+	} {
+		body := mustHex(test.body)
+		body[len(body)-40] ^= 0x01
+		_, err := Decrypt(&decoder.Pin{Encryption: test.encryption, ContentBody: body}, keys, 0)
+		if !errors.Is(err, ErrAuthentication) {
+			t.Errorf("Decrypt(%s) of tampered body = %v, expected ErrAuthentication", test.encryption, err)
+		}
+	}
+
+	// Wrong private key
+	other, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x07}, 32))
+	_, err := Decrypt(&decoder.Pin{Encryption: EncryptionECIES, ContentBody: mustHex(testECIESVector)}, &StaticKeys{Private: other}, 0)
+	if !errors.Is(err, ErrAuthentication) {
+		t.Errorf("Expected ErrAuthentication with wrong key, got %v", err)
+	}
+
+	// Truncated ciphertexts
+	if _, err := DecryptECIES(priv, []byte("BIE1")); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("Expected ErrInvalidCiphertext, got %v", err)
+	}
+	if _, err := DecryptAES(key, []byte{0x01}); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("Expected ErrInvalidCiphertext, got %v", err)
+	}
+}
+
+func TestIsEncrypted(t *testing.T) {
+	tests := map[string]bool{"": false, "0": false, "1": true, "2": true}
+	for encryption, expected := range tests {
+		if IsEncrypted(&decoder.Pin{Encryption: encryption}) != expected {
+			t.Errorf("IsEncrypted(%q) != %v", encryption, expected)
+		}
+	}
+}
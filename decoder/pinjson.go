@@ -0,0 +1,152 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder/common"
+)
+
+// BodyMode selects how PIN bodies are rendered in JSON
+type BodyMode int
+
+const (
+	// BodyModeBase64 renders bodies as base64 strings, like encoding/json
+	BodyModeBase64 BodyMode = iota
+	// BodyModeAuto embeds bodies of a JSON content-type as raw JSON, other UTF-8 bodies as
+	// strings and the rest as base64
+	BodyModeAuto
+)
+
+// Body encodings named by the contentBodyEncoding and decodedContentBodyEncoding fields
+const (
+	BodyEncodingBase64 = "base64"
+	BodyEncodingText   = "text"
+	BodyEncodingJSON   = "json"
+)
+
+// pinFields is Pin without its methods, so its fields can be embedded in the JSON forms below
+type pinFields Pin
+
+// pinJSON is the JSON form of a PIN written in a body mode
+// Its body fields shadow the []byte fields of the embedded Pin.
+type pinJSON struct {
+	*pinFields
+	ContentBody                interface{} `json:"contentBody"`
+	ContentBodyEncoding        string      `json:"contentBodyEncoding,omitempty"`
+	DecodedContentBody         interface{} `json:"decodedContentBody,omitempty"`
+	DecodedContentBodyEncoding string      `json:"decodedContentBodyEncoding,omitempty"`
+}
+
+// rawPinJSON is the JSON form of a PIN being read in any body mode
+type rawPinJSON struct {
+	*pinFields
+	ContentBody                json.RawMessage `json:"contentBody"`
+	ContentBodyEncoding        string          `json:"contentBodyEncoding"`
+	DecodedContentBody         json.RawMessage `json:"decodedContentBody"`
+	DecodedContentBodyEncoding string          `json:"decodedContentBodyEncoding"`
+}
+
+// PinEncoder writes PINs as JSON values with their bodies rendered in a body mode
+// Each body is followed by a field naming its encoding, e.g. "contentBodyEncoding": "json".
+type PinEncoder struct {
+	enc  *json.Encoder
+	mode BodyMode
+}
+
+// NewPinEncoder creates a PIN encoder writing to w
+func NewPinEncoder(w io.Writer, mode BodyMode) *PinEncoder {
+	enc := json.NewEncoder(w)
+	// Escaping would change the bytes of raw JSON bodies
+	enc.SetEscapeHTML(false)
+	return &PinEncoder{enc: enc, mode: mode}
+}
+
+// Encode writes a PIN followed by a newline
+func (e *PinEncoder) Encode(pin *Pin) error {
+	isJSON := common.IsJSONContentType(pin.ContentType)
+	contentBody, contentEncoding := renderBody(pin.ContentBody, isJSON, e.mode)
+	decodedBody, decodedEncoding := renderBody(pin.DecodedContentBody, isJSON, e.mode)
+	return e.enc.Encode(pinJSON{
+		pinFields:                  (*pinFields)(pin),
+		ContentBody:                contentBody,
+		ContentBodyEncoding:        contentEncoding,
+		DecodedContentBody:         decodedBody,
+		DecodedContentBodyEncoding: decodedEncoding,
+	})
+}
+
+// MarshalPin returns the JSON encoding of a PIN with its bodies rendered in a body mode
+func MarshalPin(pin *Pin, mode BodyMode) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := NewPinEncoder(&buf, mode).Encode(pin); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// UnmarshalPin parses a PIN written in any body mode, or by encoding/json
+// Bodies without an encoding field are base64.
+func UnmarshalPin(data []byte) (*Pin, error) {
+	pin := &Pin{}
+	raw := rawPinJSON{pinFields: (*pinFields)(pin)}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	var err error
+	if pin.ContentBody, err = parseBody(raw.ContentBody, raw.ContentBodyEncoding); err != nil {
+		return nil, fmt.Errorf("contentBody: %w", err)
+	}
+	if pin.DecodedContentBody, err = parseBody(raw.DecodedContentBody, raw.DecodedContentBodyEncoding); err != nil {
+		return nil, fmt.Errorf("decodedContentBody: %w", err)
+	}
+	return pin, nil
+}
+
+// renderBody returns the JSON value of a body and its encoding
+// Bodies of a JSON content-type are only embedded when already compact, so that they round-trip
+// byte for byte, and a null body is text so it is not mistaken for a missing one.
+func renderBody(body []byte, isJSON bool, mode BodyMode) (interface{}, string) {
+	switch {
+	case body == nil:
+		return nil, ""
+	case mode != BodyModeAuto:
+		return body, BodyEncodingBase64
+	case isJSON && len(body) > 0 && string(body) != "null" && json.Valid(body) && isCompact(body):
+		return json.RawMessage(body), BodyEncodingJSON
+	case utf8.Valid(body):
+		return string(body), BodyEncodingText
+	default:
+		return body, BodyEncodingBase64
+	}
+}
+
+// isCompact checks whether a JSON document has no insignificant whitespace
+func isCompact(body []byte) bool {
+	var buf bytes.Buffer
+	return json.Compact(&buf, body) == nil && bytes.Equal(buf.Bytes(), body)
+}
+
+// parseBody decodes a body value of an encoding, empty meaning base64
+func parseBody(value json.RawMessage, encoding string) ([]byte, error) {
+	if len(value) == 0 || string(value) == "null" {
+		return nil, nil
+	}
+	switch encoding {
+	case "", BodyEncodingBase64:
+		var body []byte
+		err := json.Unmarshal(value, &body)
+		return body, err
+	case BodyEncodingText:
+		var text string
+		err := json.Unmarshal(value, &text)
+		return []byte(text), err
+	case BodyEncodingJSON:
+		return append([]byte{}, value...), nil
+	default:
+		return nil, fmt.Errorf("unknown body encoding %q", encoding)
+	}
+}
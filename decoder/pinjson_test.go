@@ -0,0 +1,116 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshalPin_Auto(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		encoding    string
+		rendered    string
+	}{
+		{"json", "application/json", []byte(`{"content":"<b>hi</b> & more"}`), BodyEncodingJSON, `"contentBody":{"content":"<b>hi</b> & more"}`},
+		{"json array", "application/json;utf-8", []byte(`[1,2]`), BodyEncodingJSON, `"contentBody":[1,2]`},
+		{"json suffix", "application/ld+json", []byte(`{"@id":"x"}`), BodyEncodingJSON, `"contentBody":{"@id":"x"}`},
+		{"default content-type", "", []byte(`{"a":1}`), BodyEncodingJSON, `"contentBody":{"a":1}`},
+		{"indented json", "application/json", []byte("{\n  \"a\": 1\n}"), BodyEncodingText, `"contentBody":"{\n  \"a\": 1\n}"`},
+		{"null", "application/json", []byte("null"), BodyEncodingText, `"contentBody":"null"`},
+		{"text", "application/json", []byte("héllo world"), BodyEncodingText, `"contentBody":"héllo world"`},
+		{"text number", "text/plain", []byte("123"), BodyEncodingText, `"contentBody":"123"`},
+		{"text bool", "text/plain;utf-8", []byte("true"), BodyEncodingText, `"contentBody":"true"`},
+		{"text json string", "text/markdown", []byte(`"x"`), BodyEncodingText, `"contentBody":"\"x\""`},
+		{"empty", "application/json", []byte{}, BodyEncodingText, `"contentBody":""`},
+		{"binary", "image/png", []byte{0x89, 'P', 'N', 'G', 0xff}, BodyEncodingBase64, `"contentBody":"iVBOR/8="`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pin := &Pin{Id: "a", ContentType: tt.contentType, ContentBody: tt.body}
+			data, err := MarshalPin(pin, BodyModeAuto)
+			if err != nil {
+				t.Fatalf("MarshalPin failed: %v", err)
+			}
+			if !bytes.Contains(data, []byte(tt.rendered)) || !bytes.Contains(data, []byte(`"contentBodyEncoding":"`+tt.encoding+`"`)) {
+				t.Errorf("Expected %s as %s, got %s", tt.rendered, tt.encoding, data)
+			}
+			if !json.Valid(data) {
+				t.Fatalf("Invalid JSON %s", data)
+			}
+			got, err := UnmarshalPin(data)
+			if err != nil {
+				t.Fatalf("UnmarshalPin failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, pin) {
+				t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", got, pin)
+			}
+		})
+	}
+}
+
+func TestMarshalPin_Modes(t *testing.T) {
+	pins := []*Pin{
+		{Id: "a", Path: "/p", ContentBody: []byte(`{"a":1}`), DecodedContentBody: []byte("plain")},
+		{Id: "b", ContentBody: []byte{0x00, 0x01}, DecodedContentBody: []byte(`["x"]`), Validation: &ValidationResult{Schema: "/p", Valid: true}},
+		{Id: "c", Operation: "revoke"},
+	}
+	for _, mode := range []BodyMode{BodyModeBase64, BodyModeAuto} {
+		var buf bytes.Buffer
+		enc := NewPinEncoder(&buf, mode)
+		for _, pin := range pins {
+			if err := enc.Encode(pin); err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != len(pins) {
+			t.Fatalf("Mode %d: expected %d lines, got %d", mode, len(pins), len(lines))
+		}
+		for i, line := range lines {
+			got, err := UnmarshalPin([]byte(line))
+			if err != nil {
+				t.Fatalf("Mode %d: UnmarshalPin failed: %v", mode, err)
+			}
+			if !reflect.DeepEqual(got, pins[i]) {
+				t.Errorf("Mode %d: round trip mismatch:\n got %+v\nwant %+v", mode, got, pins[i])
+			}
+		}
+	}
+
+	// Base64 mode renders bodies like encoding/json and names the encoding
+	data, _ := MarshalPin(pins[0], BodyModeBase64)
+	plain, _ := json.Marshal(pins[0])
+	if !bytes.Contains(data, []byte(`"contentBody":"eyJhIjoxfQ==","contentBodyEncoding":"base64"`)) || !bytes.Contains(plain, []byte(`"contentBody":"eyJhIjoxfQ=="`)) {
+		t.Errorf("Unexpected base64 rendering %s", data)
+	}
+	// A PIN without body has no encoding field
+	if data, _ := MarshalPin(pins[2], BodyModeAuto); bytes.Contains(data, []byte("Encoding")) || !bytes.Contains(data, []byte(`"contentBody":null`)) {
+		t.Errorf("Unexpected rendering of a PIN without body %s", data)
+	}
+}
+
+func TestUnmarshalPin(t *testing.T) {
+	// Output of encoding/json has no encoding fields and base64 bodies
+	pin := &Pin{Id: "a", ContentBody: []byte("body"), ContentLength: 4}
+	data, _ := json.Marshal(pin)
+	got, err := UnmarshalPin(data)
+	if err != nil || !reflect.DeepEqual(got, pin) {
+		t.Errorf("Expected %+v, got %+v, %v", pin, got, err)
+	}
+
+	for _, data := range []string{
+		`{"contentBody":"x","contentBodyEncoding":"hex"}`,
+		`{"contentBody":1,"contentBodyEncoding":"text"}`,
+		`{"contentBody":"!!","contentBodyEncoding":"base64"}`,
+		`{"contentBody":`,
+	} {
+		if _, err := UnmarshalPin([]byte(data)); err == nil {
+			t.Errorf("Expected an error for %s", data)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package decoder
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder/common"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/contentenc"
+)
+
+// ProcessPins runs the optional post-decoding stages configured in config:
+// creator resolution (when ResolveCreators is set), content decoding, sniffing and validation
+// Chain parsers call it on the PINs found in a transaction before returning them
+// PINs rejected by a stage are removed from the result
+func ProcessPins(config *ParserConfig, pins []*Pin) []*Pin {
+	if config == nil {
+		return pins
+	}
+	resolver := config.CreatorResolver
+	if !config.ResolveCreators {
+		resolver = nil
+	}
+	batch, isBatch := resolver.(BatchCreatorResolver)
+	if isBatch {
+		resolveCreators(pins, batch)
+	}
+	processed := pins[:0]
+	for _, pin := range pins {
+		if resolver != nil && !isBatch {
+			resolveCreator(pin, resolver)
+		}
+		if config.DecodeContent {
+			decodeContent(pin, config.MaxDecodedSize)
+		}
+		if config.SniffContent != SniffOff {
+			sniffContent(pin)
+			if config.SniffContent == SniffRejectDangerous && common.IsDangerousMismatch(pin.ContentType, pin.DetectedContentType) {
+				continue
+			}
+		}
+		if config.Validator != nil {
+			pin.Validation = config.Validator.ValidatePin(pin)
+		}
+		processed = append(processed, pin)
+	}
+	return processed
+}
+
+// resolveCreator fills the creator of the PIN from the outpoint spent by its creator input
+// The creator stays empty if the location is missing or the resolver fails
+func resolveCreator(pin *Pin, resolver CreatorResolver) {
+	txId, vout, ok := splitOutpoint(pin.CreatorInputLocation)
+	if !ok {
+		return
+	}
+	address, metaId, err := resolver.ResolveCreator(pin.ChainName, txId, vout)
+	if err != nil {
+		return
+	}
+	setCreator(pin, address, metaId)
+}
+
+// resolveCreators fills the creators of the PINs with one batch call per chain
+// Creators stay empty for missing locations and unresolved outpoints, or all of them if the call fails
+func resolveCreators(pins []*Pin, resolver BatchCreatorResolver) {
+	var chains []string
+	byChain := make(map[string][]*Pin)
+	for _, pin := range pins {
+		if _, _, ok := splitOutpoint(pin.CreatorInputLocation); !ok {
+			continue
+		}
+		if _, seen := byChain[pin.ChainName]; !seen {
+			chains = append(chains, pin.ChainName)
+		}
+		byChain[pin.ChainName] = append(byChain[pin.ChainName], pin)
+	}
+
+	for _, chainName := range chains {
+		chainPins := byChain[chainName]
+		outpoints := make([]Outpoint, len(chainPins))
+		for i, pin := range chainPins {
+			outpoints[i].TxId, outpoints[i].Vout, _ = splitOutpoint(pin.CreatorInputLocation)
+		}
+		creators, err := resolver.ResolveCreators(chainName, outpoints)
+		if err != nil || len(creators) != len(chainPins) {
+			continue
+		}
+		for i, pin := range chainPins {
+			setCreator(pin, creators[i].Address, creators[i].MetaId)
+		}
+	}
+}
+
+// setCreator sets the creator of the PIN, computing the MetaID from the address if needed
+func setCreator(pin *Pin, address, metaId string) {
+	if address == "" {
+		return
+	}
+	if metaId == "" {
+		metaId = common.CalculateMetaId(address)
+	}
+	pin.CreatorAddress = address
+	pin.CreatorMetaId = metaId
+}
+
+// splitOutpoint splits a "txId:vout" location
+func splitOutpoint(location string) (txId string, vout uint32, ok bool) {
+	i := strings.LastIndex(location, ":")
+	if i <= 0 {
+		return "", 0, false
+	}
+	n, err := strconv.ParseUint(location[i+1:], 10, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return location[:i], uint32(n), true
+}
+
+// decodeContent decompresses the PIN body if its content-type or version carries an encoding marker
+// Encrypted bodies were encoded before encryption, so only their encoding is recorded;
+// pincrypto.Decrypt decodes the plaintext
+func decodeContent(pin *Pin, maxSize int64) {
+	encoding := contentenc.Detect(pin.ContentType, pin.Version)
+	if encoding == contentenc.Identity {
+		return
+	}
+	pin.ContentEncoding = encoding
+	if pin.Encryption != "" && pin.Encryption != "0" {
+		return
+	}
+	decoded, err := contentenc.Decode(encoding, pin.ContentBody, maxSize)
+	if err != nil {
+		pin.ContentDecodeError = err.Error()
+		return
+	}
+	pin.DecodedContentBody = decoded
+}
+
+// sniffContent detects the real content type of the PIN body
+// Encrypted bodies and bodies that failed to decode are not sniffed
+func sniffContent(pin *Pin) {
+	if (pin.Encryption != "" && pin.Encryption != "0") || pin.ContentDecodeError != "" {
+		return
+	}
+	pin.DetectedContentType = common.SniffContentType(pin.Body())
+	pin.ContentTypeMismatch = common.ContentTypeMismatch(pin.ContentType, pin.DetectedContentType)
+}
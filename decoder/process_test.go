@@ -0,0 +1,176 @@
+package decoder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// fakeResolver resolves outpoints from a map keyed by "txId:vout"
+type fakeResolver struct {
+	addresses map[string]string
+	calls     []string
+}
+
+func (r *fakeResolver) ResolveCreator(chainName, txId string, vout uint32) (string, string, error) {
+	key := fmt.Sprintf("%s:%d", txId, vout)
+	r.calls = append(r.calls, chainName+"/"+key)
+	address, ok := r.addresses[key]
+	if !ok {
+		return "", "", errors.New("not found")
+	}
+	return address, "", nil
+}
+
+// recordingValidator records the body it was asked to validate
+type recordingValidator struct {
+	body []byte
+}
+
+func (v *recordingValidator) ValidatePin(pin *Pin) *ValidationResult {
+	v.body = pin.Body()
+	return &ValidationResult{Schema: pin.Path, Valid: true}
+}
+
+func gzipBytes(data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestProcessPins_NilConfig(t *testing.T) {
+	pins := []*Pin{{Path: "/info/name"}}
+	if result := ProcessPins(nil, pins); len(result) != 1 {
+		t.Errorf("Expected pins unchanged, got %v", result)
+	}
+}
+
+func TestProcessPins_ResolveCreator(t *testing.T) {
+	resolver := &fakeResolver{addresses: map[string]string{"aa:1": "creator"}}
+	config := DefaultConfig()
+	config.CreatorResolver = resolver
+
+	// Resolution is opt-in
+	if pins := ProcessPins(config, []*Pin{{ChainName: "btc", CreatorInputLocation: "aa:1"}}); pins[0].CreatorAddress != "" || len(resolver.calls) != 0 {
+		t.Fatalf("Expected no resolution without ResolveCreators, got %q and calls %v", pins[0].CreatorAddress, resolver.calls)
+	}
+	config.ResolveCreators = true
+
+	pins := ProcessPins(config, []*Pin{
+		{ChainName: "btc", CreatorInputLocation: "aa:1"},
+		{ChainName: "btc", CreatorInputLocation: "bb:2"},
+		{ChainName: "btc"},
+	})
+	if len(pins) != 3 {
+		t.Fatalf("Expected 3 pins, got %d", len(pins))
+	}
+	if pins[0].CreatorAddress != "creator" || pins[0].CreatorMetaId == "" {
+		t.Errorf("Expected the creator to be resolved, got %q / %q", pins[0].CreatorAddress, pins[0].CreatorMetaId)
+	}
+	if pins[1].CreatorAddress != "" || pins[2].CreatorAddress != "" {
+		t.Error("Expected unresolved creators to stay empty")
+	}
+	if len(resolver.calls) != 2 || resolver.calls[0] != "btc/aa:1" {
+		t.Errorf("Unexpected resolver calls %v", resolver.calls)
+	}
+}
+
+func TestProcessPins_DecodeContent(t *testing.T) {
+	body := []byte(`{"content":"compressed buzz"}`)
+	raw := gzipBytes(body)
+	validator := &recordingValidator{}
+
+	config := DefaultConfig()
+	config.DecodeContent = true
+	config.Validator = validator
+
+	pins := ProcessPins(config, []*Pin{{
+		Path:        "/protocols/simplebuzz",
+		ContentType: "application/json;gzip",
+		ContentBody: raw,
+	}})
+	pin := pins[0]
+	if pin.ContentEncoding != "gzip" {
+		t.Errorf("Expected content encoding 'gzip', got '%s'", pin.ContentEncoding)
+	}
+	if !bytes.Equal(pin.ContentBody, raw) {
+		t.Error("Raw content body was modified")
+	}
+	if !bytes.Equal(pin.DecodedContentBody, body) {
+		t.Errorf("Unexpected decoded body %q", pin.DecodedContentBody)
+	}
+	if !bytes.Equal(validator.body, body) {
+		t.Error("Validator did not receive the decoded body")
+	}
+}
+
+func TestProcessPins_DecodeContentLimit(t *testing.T) {
+	config := DefaultConfig()
+	config.DecodeContent = true
+	config.MaxDecodedSize = 16
+
+	pins := ProcessPins(config, []*Pin{{
+		ContentType: "text/plain;gzip",
+		ContentBody: gzipBytes(bytes.Repeat([]byte("x"), 1024)),
+	}})
+	if pins[0].DecodedContentBody != nil || pins[0].ContentDecodeError == "" {
+		t.Errorf("Expected decode error for oversized body, got %+v", pins[0])
+	}
+}
+
+func TestProcessPins_DecodeContentDisabled(t *testing.T) {
+	pins := ProcessPins(DefaultConfig(), []*Pin{{
+		ContentType: "application/json;gzip",
+		ContentBody: gzipBytes([]byte("{}")),
+	}})
+	if pins[0].ContentEncoding != "" || pins[0].DecodedContentBody != nil {
+		t.Errorf("Expected no decoding by default, got %+v", pins[0])
+	}
+}
+
+func TestProcessPins_SniffRecord(t *testing.T) {
+	config := DefaultConfig()
+	config.SniffContent = SniffRecord
+
+	pins := ProcessPins(config, []*Pin{
+		{Id: "png", ContentType: "text/plain", ContentBody: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")},
+		{Id: "html", ContentType: "image/png", ContentBody: []byte("<html><script>alert(1)</script></html>")},
+		{Id: "json", ContentType: "application/json", ContentBody: []byte(`{"a":1}`)},
+		{Id: "secret", Encryption: "1", ContentType: "text/plain", ContentBody: []byte("\x89PNG\r\n\x1a\n")},
+	})
+	if len(pins) != 4 {
+		t.Fatalf("Expected 4 pins with SniffRecord, got %d", len(pins))
+	}
+	if pins[0].DetectedContentType != "image/png" || !pins[0].ContentTypeMismatch {
+		t.Errorf("Expected PNG mismatch, got %+v", pins[0])
+	}
+	if pins[1].DetectedContentType != "text/html" || !pins[1].ContentTypeMismatch {
+		t.Errorf("Expected HTML mismatch, got %+v", pins[1])
+	}
+	if pins[2].DetectedContentType != "application/json" || pins[2].ContentTypeMismatch {
+		t.Errorf("Expected JSON without mismatch, got %+v", pins[2])
+	}
+	if pins[3].DetectedContentType != "" {
+		t.Errorf("Expected encrypted PIN not to be sniffed, got %+v", pins[3])
+	}
+}
+
+func TestProcessPins_SniffRejectDangerous(t *testing.T) {
+	config := DefaultConfig()
+	config.SniffContent = SniffRejectDangerous
+
+	pins := ProcessPins(config, []*Pin{
+		{Id: "png", ContentType: "text/plain", ContentBody: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")},
+		{Id: "html", ContentType: "image/png", ContentBody: []byte("<html><script>alert(1)</script></html>")},
+	})
+	if len(pins) != 1 || pins[0].Id != "png" {
+		t.Fatalf("Expected only the PNG pin to remain, got %v", pins)
+	}
+	if !pins[0].ContentTypeMismatch {
+		t.Error("Expected the harmless mismatch to be recorded")
+	}
+}
@@ -0,0 +1,74 @@
+package decoder
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownChain is returned when no parser is registered under a chain name
+var ErrUnknownChain = errors.New("unknown chain")
+
+// ChainFactory creates a chain parser from a configuration
+type ChainFactory func(config *ParserConfig) ChainParser
+
+var (
+	registryMu sync.RWMutex
+	factories  = make(map[string]ChainFactory) // chain name or alias -> factory
+	chainNames = make(map[string]string)       // chain name or alias -> chain name
+)
+
+// RegisterChain registers a parser factory under a chain name and optional aliases
+// Chain packages register themselves in init, so importing a chain package enables it:
+//
+//	import _ "github.com/metaid-developers/metaid-script-decoder/decoder/btc"
+//	parser, err := decoder.NewParser("btc", config)
+func RegisterChain(name string, factory ChainFactory, aliases ...string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, key := range append([]string{name}, aliases...) {
+		key = strings.ToLower(key)
+		if _, exists := factories[key]; exists {
+			panic(fmt.Sprintf("decoder: chain %q registered twice", key))
+		}
+		factories[key] = factory
+		chainNames[key] = name
+	}
+}
+
+// NewParser creates the parser registered under a chain name or alias
+func NewParser(name string, config *ParserConfig) (ChainParser, error) {
+	registryMu.RLock()
+	factory, ok := factories[strings.ToLower(name)]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownChain, name)
+	}
+	return factory(config), nil
+}
+
+// CanonicalChainName returns the chain name a name or alias is registered under
+func CanonicalChainName(name string) (string, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	canonical, ok := chainNames[strings.ToLower(name)]
+	return canonical, ok
+}
+
+// RegisteredChains returns the sorted names of the registered chains, without aliases
+func RegisteredChains() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	seen := make(map[string]bool)
+	var names []string
+	for _, name := range chainNames {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,68 @@
+package decoder
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeParser is a chain parser returning no PINs
+type fakeParser struct {
+	config *ParserConfig
+}
+
+func (p *fakeParser) ParseTransaction(txBytes []byte, chainParams interface{}) ([]*Pin, error) {
+	return nil, nil
+}
+
+func (p *fakeParser) GetChainName() string {
+	return "fake"
+}
+
+func TestRegistry(t *testing.T) {
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		for _, key := range []string{"fake", "fakecoin", "other"} {
+			delete(factories, key)
+			delete(chainNames, key)
+		}
+	})
+
+	RegisterChain("fake", func(config *ParserConfig) ChainParser {
+		return &fakeParser{config: config}
+	}, "FakeCoin")
+
+	config := DefaultConfig()
+	parser, err := NewParser("fakecoin", config)
+	if err != nil {
+		t.Fatalf("NewParser failed: %v", err)
+	}
+	if parser.GetChainName() != "fake" || parser.(*fakeParser).config != config {
+		t.Errorf("Unexpected parser %+v", parser)
+	}
+
+	if name, ok := CanonicalChainName("FAKECOIN"); !ok || name != "fake" {
+		t.Errorf("CanonicalChainName = %q, %v", name, ok)
+	}
+	found := false
+	for _, name := range RegisteredChains() {
+		if name == "fakecoin" {
+			t.Error("RegisteredChains should not list aliases")
+		}
+		found = found || name == "fake"
+	}
+	if !found {
+		t.Error("RegisteredChains did not list 'fake'")
+	}
+
+	if _, err := NewParser("nochain", nil); !errors.Is(err, ErrUnknownChain) {
+		t.Errorf("Expected ErrUnknownChain, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on duplicate registration")
+		}
+	}()
+	RegisterChain("other", func(config *ParserConfig) ChainParser { return nil }, "fake")
+}
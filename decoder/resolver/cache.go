@@ -0,0 +1,257 @@
+// Package resolver provides CreatorResolver implementations and decorators for indexers.
+package resolver
+
+import (
+	"container/list"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+// Default cache settings
+const (
+	DefaultCacheSize = 10000
+	DefaultCacheTTL  = 10 * time.Minute
+)
+
+// Stats are the counters of a CachingResolver
+type Stats struct {
+	Hits    uint64 // Lookups answered from the cache
+	Misses  uint64 // Lookups not answered from the cache, including those joining an identical lookup in flight
+	Entries int    // Outpoints currently cached
+}
+
+// CachingResolver wraps a CreatorResolver with an LRU cache and request coalescing
+// Concurrent lookups of the same outpoint share one call to the wrapped resolver.
+// Only resolved creators are cached; errors and unresolved outpoints are retried on the next lookup.
+// It implements decoder.BatchCreatorResolver, passing cache misses in one call when the wrapped
+// resolver is a BatchCreatorResolver too. Batches coalesce with lookups and batches in flight
+// outpoint by outpoint.
+type CachingResolver struct {
+	resolver decoder.CreatorResolver
+	size     int
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+	calls   map[string]*call
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// entry is a cached creator
+type entry struct {
+	key     string
+	creator decoder.Creator
+	expires time.Time
+}
+
+// call is a lookup in flight
+type call struct {
+	done    chan struct{}
+	creator decoder.Creator
+	err     error
+}
+
+// NewCachingResolver creates a caching resolver around resolver
+// If size is 0, DefaultCacheSize is used; if ttl is 0, DefaultCacheTTL is used
+func NewCachingResolver(resolver decoder.CreatorResolver, size int, ttl time.Duration) *CachingResolver {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &CachingResolver{
+		resolver: resolver,
+		size:     size,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		calls:    make(map[string]*call),
+	}
+}
+
+// ResolveCreator implements decoder.CreatorResolver
+func (r *CachingResolver) ResolveCreator(chainName, txId string, vout uint32) (string, string, error) {
+	creator, err := r.resolve(chainName, decoder.Outpoint{TxId: txId, Vout: vout})
+	return creator.Address, creator.MetaId, err
+}
+
+// ResolveCreators implements decoder.BatchCreatorResolver
+// Cached outpoints are answered from the cache, the others are resolved in one batch call
+// if the wrapped resolver supports it, or one by one otherwise
+func (r *CachingResolver) ResolveCreators(chainName string, outpoints []decoder.Outpoint) ([]decoder.Creator, error) {
+	creators := make([]decoder.Creator, len(outpoints))
+
+	batch, ok := r.resolver.(decoder.BatchCreatorResolver)
+	if !ok {
+		for i, outpoint := range outpoints {
+			// Unresolved outpoints are left empty
+			creators[i], _ = r.resolve(chainName, outpoint)
+		}
+		return creators, nil
+	}
+
+	// Collect the distinct outpoints missing from the cache, joining the lookups in flight
+	// and registering a call for each of the others
+	var missing []decoder.Outpoint
+	var started []*call
+	joined := make(map[string]*call)
+	positions := make(map[string][]int)
+	r.mu.Lock()
+	for i, outpoint := range outpoints {
+		key := cacheKey(chainName, outpoint)
+		if creator, ok := r.get(key); ok {
+			creators[i] = creator
+			r.hits.Add(1)
+			continue
+		}
+		r.misses.Add(1)
+		if _, seen := positions[key]; !seen {
+			if c, ok := r.calls[key]; ok {
+				joined[key] = c
+			} else {
+				c := &call{done: make(chan struct{})}
+				r.calls[key] = c
+				missing = append(missing, outpoint)
+				started = append(started, c)
+			}
+		}
+		positions[key] = append(positions[key], i)
+	}
+	r.mu.Unlock()
+
+	if len(missing) > 0 {
+		resolved, err := batch.ResolveCreators(chainName, missing)
+		if err == nil && len(resolved) != len(missing) {
+			err = fmt.Errorf("resolver returned %d creators for %d outpoints", len(resolved), len(missing))
+		}
+
+		r.mu.Lock()
+		for k, outpoint := range missing {
+			key := cacheKey(chainName, outpoint)
+			c := started[k]
+			delete(r.calls, key)
+			if c.err = err; err == nil {
+				c.creator = resolved[k]
+				r.put(key, c.creator)
+				for _, i := range positions[key] {
+					creators[i] = c.creator
+				}
+			}
+			close(c.done)
+		}
+		r.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Lookups started by other callers leave their outpoints empty if they failed
+	for key, c := range joined {
+		<-c.done
+		if c.err != nil {
+			continue
+		}
+		for _, i := range positions[key] {
+			creators[i] = c.creator
+		}
+	}
+	return creators, nil
+}
+
+// Stats returns the cache counters
+func (r *CachingResolver) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Stats{
+		Hits:    r.hits.Load(),
+		Misses:  r.misses.Load(),
+		Entries: r.order.Len(),
+	}
+}
+
+// resolve answers a lookup from the cache, or joins or starts a call to the wrapped resolver
+func (r *CachingResolver) resolve(chainName string, outpoint decoder.Outpoint) (decoder.Creator, error) {
+	key := cacheKey(chainName, outpoint)
+
+	r.mu.Lock()
+	if creator, ok := r.get(key); ok {
+		r.mu.Unlock()
+		r.hits.Add(1)
+		return creator, nil
+	}
+	r.misses.Add(1)
+	if c, ok := r.calls[key]; ok {
+		r.mu.Unlock()
+		<-c.done
+		return c.creator, c.err
+	}
+	c := &call{done: make(chan struct{})}
+	r.calls[key] = c
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.calls, key)
+		if c.err == nil {
+			r.put(key, c.creator)
+		}
+		r.mu.Unlock()
+		close(c.done)
+	}()
+
+	address, metaId, err := r.resolver.ResolveCreator(chainName, outpoint.TxId, outpoint.Vout)
+	c.creator, c.err = decoder.Creator{Address: address, MetaId: metaId}, err
+	return c.creator, c.err
+}
+
+// get returns a cached creator that has not expired, r.mu must be held
+func (r *CachingResolver) get(key string) (decoder.Creator, bool) {
+	elem, ok := r.entries[key]
+	if !ok {
+		return decoder.Creator{}, false
+	}
+	e := elem.Value.(*entry)
+	if !r.now().Before(e.expires) {
+		r.order.Remove(elem)
+		delete(r.entries, key)
+		return decoder.Creator{}, false
+	}
+	r.order.MoveToFront(elem)
+	return e.creator, true
+}
+
+// put caches a resolved creator and evicts the least recently used entries, r.mu must be held
+func (r *CachingResolver) put(key string, creator decoder.Creator) {
+	if creator.Address == "" {
+		return
+	}
+	expires := r.now().Add(r.ttl)
+	if elem, ok := r.entries[key]; ok {
+		e := elem.Value.(*entry)
+		e.creator, e.expires = creator, expires
+		r.order.MoveToFront(elem)
+		return
+	}
+	r.entries[key] = r.order.PushFront(&entry{key: key, creator: creator, expires: expires})
+	for r.order.Len() > r.size {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.entries, oldest.Value.(*entry).key)
+	}
+}
+
+// cacheKey identifies an outpoint of a chain
+func cacheKey(chainName string, outpoint decoder.Outpoint) string {
+	return chainName + "/" + outpoint.TxId + ":" + strconv.FormatUint(uint64(outpoint.Vout), 10)
+}
@@ -0,0 +1,245 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+// fakeResolver resolves every outpoint of txId "missing" to an error and others to an address
+type fakeResolver struct {
+	calls   atomic.Int32
+	release chan struct{} // if set, lookups wait until it is closed
+}
+
+func (f *fakeResolver) ResolveCreator(chainName, txId string, vout uint32) (string, string, error) {
+	f.calls.Add(1)
+	if f.release != nil {
+		<-f.release
+	}
+	if txId == "missing" {
+		return "", "", errors.New("not found")
+	}
+	return fmt.Sprintf("%s-%s-%d", chainName, txId, vout), "", nil
+}
+
+// fakeBatchResolver records the outpoints of each batch call
+type fakeBatchResolver struct {
+	fakeResolver
+	mu      sync.Mutex
+	batches [][]decoder.Outpoint
+}
+
+func (f *fakeBatchResolver) ResolveCreators(chainName string, outpoints []decoder.Outpoint) ([]decoder.Creator, error) {
+	f.mu.Lock()
+	f.batches = append(f.batches, outpoints)
+	f.mu.Unlock()
+	if f.release != nil {
+		<-f.release
+	}
+	creators := make([]decoder.Creator, len(outpoints))
+	for i, outpoint := range outpoints {
+		if outpoint.TxId != "missing" {
+			creators[i].Address = fmt.Sprintf("%s-%s-%d", chainName, outpoint.TxId, outpoint.Vout)
+		}
+	}
+	return creators, nil
+}
+
+func TestCachingResolver_HitsAndMisses(t *testing.T) {
+	inner := &fakeResolver{}
+	r := NewCachingResolver(inner, 0, 0)
+
+	for i := 0; i < 3; i++ {
+		address, _, err := r.ResolveCreator("btc", "aa", 1)
+		if err != nil || address != "btc-aa-1" {
+			t.Fatalf("Unexpected result %q, %v", address, err)
+		}
+	}
+	if _, _, err := r.ResolveCreator("mvc", "aa", 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if inner.calls.Load() != 2 {
+		t.Errorf("Expected 2 calls to the wrapped resolver, got %d", inner.calls.Load())
+	}
+	if stats := r.Stats(); stats.Hits != 2 || stats.Misses != 2 || stats.Entries != 2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// Errors are not cached
+	for i := 0; i < 2; i++ {
+		if _, _, err := r.ResolveCreator("btc", "missing", 0); err == nil {
+			t.Fatal("Expected an error")
+		}
+	}
+	if inner.calls.Load() != 4 {
+		t.Errorf("Expected failed lookups to be retried, got %d calls", inner.calls.Load())
+	}
+}
+
+func TestCachingResolver_TTL(t *testing.T) {
+	inner := &fakeResolver{}
+	now := time.Unix(1700000000, 0)
+	r := NewCachingResolver(inner, 10, time.Minute)
+	r.now = func() time.Time { return now }
+
+	r.ResolveCreator("btc", "aa", 0)
+	now = now.Add(30 * time.Second)
+	r.ResolveCreator("btc", "aa", 0)
+	if inner.calls.Load() != 1 {
+		t.Fatalf("Expected a cache hit within the TTL, got %d calls", inner.calls.Load())
+	}
+	now = now.Add(time.Minute)
+	r.ResolveCreator("btc", "aa", 0)
+	if inner.calls.Load() != 2 {
+		t.Errorf("Expected the entry to expire, got %d calls", inner.calls.Load())
+	}
+}
+
+func TestCachingResolver_LRU(t *testing.T) {
+	inner := &fakeResolver{}
+	r := NewCachingResolver(inner, 2, 0)
+
+	r.ResolveCreator("btc", "aa", 0)
+	r.ResolveCreator("btc", "bb", 0)
+	r.ResolveCreator("btc", "aa", 0) // aa becomes the most recently used
+	r.ResolveCreator("btc", "cc", 0) // evicts bb
+	if stats := r.Stats(); stats.Entries != 2 {
+		t.Fatalf("Expected 2 entries, got %d", stats.Entries)
+	}
+
+	calls := inner.calls.Load()
+	r.ResolveCreator("btc", "aa", 0)
+	if inner.calls.Load() != calls {
+		t.Error("Expected aa to stay cached")
+	}
+	r.ResolveCreator("btc", "bb", 0)
+	if inner.calls.Load() != calls+1 {
+		t.Error("Expected bb to be evicted")
+	}
+}
+
+func TestCachingResolver_Coalescing(t *testing.T) {
+	inner := &fakeResolver{release: make(chan struct{})}
+	r := NewCachingResolver(inner, 0, 0)
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([]string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _, _ = r.ResolveCreator("btc", "aa", 0)
+		}(i)
+	}
+	// Wait until every lookup has missed the cache
+	for r.Stats().Misses < n {
+		time.Sleep(time.Millisecond)
+	}
+	close(inner.release)
+	wg.Wait()
+
+	if inner.calls.Load() != 1 {
+		t.Errorf("Expected concurrent lookups to share one call, got %d", inner.calls.Load())
+	}
+	for i, address := range results {
+		if address != "btc-aa-0" {
+			t.Errorf("lookup %d: unexpected address %q", i, address)
+		}
+	}
+}
+
+func TestCachingResolver_Batch(t *testing.T) {
+	inner := &fakeBatchResolver{}
+	r := NewCachingResolver(inner, 0, 0)
+	r.ResolveCreator("btc", "aa", 0)
+
+	outpoints := []decoder.Outpoint{{TxId: "aa", Vout: 0}, {TxId: "bb", Vout: 1}, {TxId: "missing", Vout: 0}, {TxId: "bb", Vout: 1}}
+	creators, err := r.ResolveCreators("btc", outpoints)
+	if err != nil {
+		t.Fatalf("ResolveCreators failed: %v", err)
+	}
+	want := []string{"btc-aa-0", "btc-bb-1", "", "btc-bb-1"}
+	for i, creator := range creators {
+		if creator.Address != want[i] {
+			t.Errorf("outpoint %d: expected %q, got %q", i, want[i], creator.Address)
+		}
+	}
+
+	// Only the distinct misses reach the wrapped resolver, in one call
+	if len(inner.batches) != 1 || len(inner.batches[0]) != 2 {
+		t.Fatalf("Expected one batch of 2 outpoints, got %v", inner.batches)
+	}
+	if stats := r.Stats(); stats.Hits != 1 || stats.Misses != 4 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// The batch results are cached
+	if _, err := r.ResolveCreators("btc", outpoints[:2]); err != nil {
+		t.Fatalf("ResolveCreators failed: %v", err)
+	}
+	if len(inner.batches) != 1 {
+		t.Errorf("Expected cached outpoints not to be resolved again, got %d batches", len(inner.batches))
+	}
+}
+
+func TestCachingResolver_BatchCoalescing(t *testing.T) {
+	inner := &fakeBatchResolver{fakeResolver: fakeResolver{release: make(chan struct{})}}
+	r := NewCachingResolver(inner, 0, 0)
+	outpoints := []decoder.Outpoint{{TxId: "aa", Vout: 0}, {TxId: "bb", Vout: 1}}
+
+	// Concurrent blocks spending the same outpoints, as ProcessPins sends them
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([][]decoder.Creator, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = r.ResolveCreators("btc", outpoints)
+		}(i)
+	}
+	// Wait until every lookup has missed the cache
+	for r.Stats().Misses < 2*n {
+		time.Sleep(time.Millisecond)
+	}
+	close(inner.release)
+	wg.Wait()
+
+	if len(inner.batches) != 1 {
+		t.Errorf("Expected concurrent batches to share one upstream call, got %d", len(inner.batches))
+	}
+	for i, creators := range results {
+		if len(creators) != 2 || creators[0].Address != "btc-aa-0" || creators[1].Address != "btc-bb-1" {
+			t.Errorf("batch %d: unexpected creators %v", i, creators)
+		}
+	}
+}
+
+func TestCachingResolver_ProcessPins(t *testing.T) {
+	inner := &fakeBatchResolver{}
+	config := decoder.DefaultConfig()
+	config.CreatorResolver = NewCachingResolver(inner, 0, 0)
+	config.ResolveCreators = true
+
+	pins := decoder.ProcessPins(config, []*decoder.Pin{
+		{ChainName: "btc", CreatorInputLocation: "aa:0"},
+		{ChainName: "btc", CreatorInputLocation: "aa:1"},
+		{ChainName: "btc", CreatorInputLocation: "missing:0"},
+	})
+	if len(inner.batches) != 1 {
+		t.Fatalf("Expected the PINs to be resolved in one batch, got %d", len(inner.batches))
+	}
+	if pins[0].CreatorAddress != "btc-aa-0" || pins[1].CreatorAddress != "btc-aa-1" || pins[0].CreatorMetaId == "" {
+		t.Errorf("Unexpected creators %+v / %+v", pins[0], pins[1])
+	}
+	if pins[2].CreatorAddress != "" || pins[2].CreatorMetaId != "" {
+		t.Errorf("Expected an unresolved creator to stay empty, got %+v", pins[2])
+	}
+}
@@ -0,0 +1,81 @@
+package resolver
+
+import (
+	"bytes"
+	"fmt"
+
+	bsvchaincfg "github.com/bitcoinsv/bsvd/chaincfg"
+	bsvwire "github.com/bitcoinsv/bsvd/wire"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder/btc"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/doge"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/envelope"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/mvc"
+)
+
+// ChainDecoder deserializes a raw transaction of a chain
+// Returns the txid as computed by the chain and the outputs with their addresses
+type ChainDecoder func(rawTx []byte) (txId string, outputs []envelope.Output, err error)
+
+// BTCDecoder decodes BTC transactions, addresses use params
+func BTCDecoder(params *chaincfg.Params) ChainDecoder {
+	return func(rawTx []byte) (string, []envelope.Output, error) {
+		msgTx := wire.NewMsgTx(wire.TxVersion)
+		if err := msgTx.Deserialize(bytes.NewReader(rawTx)); err != nil {
+			return "", nil, fmt.Errorf("failed to deserialize transaction: %w", err)
+		}
+		return msgTx.TxHash().String(), btc.Outputs(msgTx, params), nil
+	}
+}
+
+// DOGEDecoder decodes DOGE transactions, addresses use params
+// DOGE shares the BTC wire format
+func DOGEDecoder(params *chaincfg.Params) ChainDecoder {
+	return BTCDecoder(params)
+}
+
+// MVCDecoder decodes MVC transactions, addresses use params
+// The txid of transactions with version >= 10 is computed with the MVC algorithm
+func MVCDecoder(params *bsvchaincfg.Params) ChainDecoder {
+	return func(rawTx []byte) (string, []envelope.Output, error) {
+		msgTx := bsvwire.NewMsgTx(2)
+		if err := msgTx.Deserialize(bytes.NewReader(rawTx)); err != nil {
+			return "", nil, fmt.Errorf("failed to deserialize transaction: %w", err)
+		}
+		txId, err := mvc.TxID(msgTx)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to calculate tx hash: %w", err)
+		}
+		return txId, mvc.Outputs(msgTx, params), nil
+	}
+}
+
+// DefaultChains returns the decoders of the chains supported by the offline resolver, on mainnet
+func DefaultChains() map[string]ChainDecoder {
+	return map[string]ChainDecoder{
+		"btc":  BTCDecoder(&chaincfg.MainNetParams),
+		"doge": DOGEDecoder(&doge.DogeMainNetParams),
+		"mvc":  MVCDecoder(&mvc.MVCMainNetParams),
+	}
+}
+
+// OfflineResolver is a CreatorResolver reading previous transactions from a RawTxSource
+// It lets tests and backfill jobs resolve creators without a node
+type OfflineResolver struct {
+	*SourcePrevouts
+}
+
+// NewOfflineResolver creates an offline resolver reading transactions from source
+// chains is passed to NewSourcePrevouts
+func NewOfflineResolver(source RawTxSource, chains map[string]ChainDecoder) *OfflineResolver {
+	return &OfflineResolver{
+		SourcePrevouts: NewSourcePrevouts(source, chains),
+	}
+}
+
+// ResolveCreator implements decoder.CreatorResolver, see resolveAddress
+func (r *OfflineResolver) ResolveCreator(chainName, txId string, vout uint32) (string, string, error) {
+	return resolveAddress(r, chainName, txId, vout)
+}
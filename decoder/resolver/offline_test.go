@@ -0,0 +1,165 @@
+package resolver
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bsvchainhash "github.com/bitcoinsv/bsvd/chaincfg/chainhash"
+	bsvwire "github.com/bitcoinsv/bsvd/wire"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/mvc"
+)
+
+// p2pkhScript builds a P2PKH script paying to a repeated byte hash
+func p2pkhScript(b byte) []byte {
+	return append([]byte{0x76, 0xa9, 0x14}, append(bytes.Repeat([]byte{b}, 20), 0x88, 0xac)...)
+}
+
+// btcTx builds a BTC wire transaction paying an OP_RETURN output and a P2PKH output
+func btcTx(t *testing.T, b byte) (string, []byte) {
+	t.Helper()
+	msgTx := wire.NewMsgTx(2)
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 0), nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(0, []byte{0x6a}))
+	msgTx.AddTxOut(wire.NewTxOut(1000, p2pkhScript(b)))
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+	return msgTx.TxHash().String(), buf.Bytes()
+}
+
+// mvcTx builds an MVC transaction of the given version paying to a P2PKH output
+func mvcTx(t *testing.T, version int32) (*bsvwire.MsgTx, []byte) {
+	t.Helper()
+	msgTx := bsvwire.NewMsgTx(version)
+	msgTx.AddTxIn(bsvwire.NewTxIn(bsvwire.NewOutPoint(&bsvchainhash.Hash{0x01}, 0), []byte{0x51}))
+	msgTx.AddTxOut(bsvwire.NewTxOut(1000, p2pkhScript(0x11)))
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+	return msgTx, buf.Bytes()
+}
+
+func TestOfflineResolver_Chains(t *testing.T) {
+	source := NewMemorySource()
+	btcId, btcRaw := btcTx(t, 0x00)
+	source.Put("btc", btcId, btcRaw)
+	dogeId, dogeRaw := btcTx(t, 0x11)
+	source.Put("doge", dogeId, dogeRaw)
+
+	msgTx, mvcRaw := mvcTx(t, 10)
+	mvcId, err := mvc.TxID(msgTx)
+	if err != nil {
+		t.Fatalf("TxID failed: %v", err)
+	}
+	if mvcId == msgTx.TxHash().String() {
+		t.Fatal("Expected a version 10 txid to differ from the standard hash")
+	}
+	source.Put("mvc", mvcId, mvcRaw)
+
+	r := NewOfflineResolver(source, nil)
+	tests := []struct {
+		chainName string
+		txId      string
+		vout      uint32
+		address   string
+	}{
+		{"btc", btcId, 1, "1111111111111111111114oLvT2"},
+		{"doge", dogeId, 1, "D6hLULEGDRbk86j58t5iWmeinqM6acA16V"},
+		{"mvc", mvcId, 0, "12ZEw5Hcv1hTb6YUQJ69y1V7uhcoDz92PH"},
+	}
+	for _, tt := range tests {
+		address, metaId, err := r.ResolveCreator(tt.chainName, tt.txId, tt.vout)
+		if err != nil {
+			t.Errorf("%s: ResolveCreator failed: %v", tt.chainName, err)
+			continue
+		}
+		if address != tt.address || metaId != "" {
+			t.Errorf("%s: expected %s, got %s / %s", tt.chainName, tt.address, address, metaId)
+		}
+	}
+
+	errorCases := []struct {
+		name      string
+		chainName string
+		txId      string
+		vout      uint32
+	}{
+		{"output without address", "btc", btcId, 0},
+		{"missing output", "btc", btcId, 2},
+		{"missing transaction", "btc", dogeId, 1},
+		{"unsupported chain", "ltc", btcId, 1},
+	}
+	for _, tt := range errorCases {
+		if _, _, err := r.ResolveCreator(tt.chainName, tt.txId, tt.vout); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+	if _, _, err := r.ResolveCreator("btc", dogeId, 1); !errors.Is(err, ErrTxNotFound) {
+		t.Errorf("Expected ErrTxNotFound, got %v", err)
+	}
+}
+
+func TestOfflineResolver_TxIDMismatch(t *testing.T) {
+	// A version 10 MVC transaction stored under its standard hash is rejected
+	msgTx, raw := mvcTx(t, 10)
+	source := NewMemorySource()
+	source.Put("mvc", msgTx.TxHash().String(), raw)
+
+	if _, _, err := NewOfflineResolver(source, nil).ResolveCreator("mvc", msgTx.TxHash().String(), 0); err == nil {
+		t.Error("Expected a txid mismatch error")
+	}
+}
+
+func TestDirSource(t *testing.T) {
+	dir := t.TempDir()
+	btcId, btcRaw := btcTx(t, 0x00)
+	if err := os.MkdirAll(filepath.Join(dir, "btc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "btc", btcId+".hex"), []byte(hex.EncodeToString(btcRaw)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	msgTx, mvcRaw := mvcTx(t, 2)
+	mvcId := msgTx.TxHash().String()
+	if err := os.WriteFile(filepath.Join(dir, mvcId+".hex"), []byte(hex.EncodeToString(mvcRaw)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	source := NewDirSource(dir)
+	if raw, err := source.RawTransaction("btc", btcId); err != nil || !bytes.Equal(raw, btcRaw) {
+		t.Errorf("Expected the chain directory file, got %v", err)
+	}
+	if raw, err := source.RawTransaction("mvc", mvcId); err != nil || !bytes.Equal(raw, mvcRaw) {
+		t.Errorf("Expected the top level file, got %v", err)
+	}
+	if _, err := source.RawTransaction("mvc", btcId); !errors.Is(err, ErrTxNotFound) {
+		t.Errorf("Expected ErrTxNotFound, got %v", err)
+	}
+	if _, err := source.RawTransaction("btc", "../btc/"+btcId); err == nil {
+		t.Error("Expected an invalid txid to be rejected")
+	}
+	for _, chainName := range []string{"..", "../btc", "btc/..", `..\btc`, "a/b"} {
+		if _, err := source.RawTransaction(chainName, btcId); err == nil || errors.Is(err, ErrTxNotFound) {
+			t.Errorf("Expected chain name %q to be rejected, got %v", chainName, err)
+		}
+	}
+
+	// Resolving through ProcessPins fills the creator
+	config := decoder.DefaultConfig()
+	config.CreatorResolver = NewOfflineResolver(source, nil)
+	config.ResolveCreators = true
+	pins := decoder.ProcessPins(config, []*decoder.Pin{{ChainName: "mvc", CreatorInputLocation: mvcId + ":0"}})
+	if pins[0].CreatorAddress != "12ZEw5Hcv1hTb6YUQJ69y1V7uhcoDz92PH" || pins[0].CreatorMetaId == "" {
+		t.Errorf("Unexpected creator %q / %q", pins[0].CreatorAddress, pins[0].CreatorMetaId)
+	}
+}
@@ -0,0 +1,69 @@
+package resolver
+
+import (
+	"fmt"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder/envelope"
+)
+
+// PrevoutProvider is the interface for looking up the outputs spent by transaction inputs
+type PrevoutProvider interface {
+	// Prevout returns output vout of transaction txId with its address and class
+	Prevout(chainName, txId string, vout uint32) (envelope.Output, error)
+}
+
+// SourcePrevouts is a PrevoutProvider reading transactions from a RawTxSource
+type SourcePrevouts struct {
+	source RawTxSource
+	chains map[string]ChainDecoder
+}
+
+// NewSourcePrevouts creates a prevout provider reading transactions from source
+// chains maps chain names to their decoders; if nil, DefaultChains is used
+func NewSourcePrevouts(source RawTxSource, chains map[string]ChainDecoder) *SourcePrevouts {
+	if chains == nil {
+		chains = DefaultChains()
+	}
+	return &SourcePrevouts{
+		source: source,
+		chains: chains,
+	}
+}
+
+// Prevout implements PrevoutProvider
+// The txid of the stored transaction is checked against txId
+func (p *SourcePrevouts) Prevout(chainName, txId string, vout uint32) (envelope.Output, error) {
+	decodeTx, ok := p.chains[chainName]
+	if !ok {
+		return envelope.Output{}, fmt.Errorf("unsupported chain %q", chainName)
+	}
+	rawTx, err := p.source.RawTransaction(chainName, txId)
+	if err != nil {
+		return envelope.Output{}, fmt.Errorf("failed to read %s transaction %s: %w", chainName, txId, err)
+	}
+
+	id, outputs, err := decodeTx(rawTx)
+	if err != nil {
+		return envelope.Output{}, err
+	}
+	if id != txId {
+		return envelope.Output{}, fmt.Errorf("stored transaction %s has txid %s", txId, id)
+	}
+	if int(vout) >= len(outputs) {
+		return envelope.Output{}, fmt.Errorf("transaction %s has no output %d", txId, vout)
+	}
+	return outputs[vout], nil
+}
+
+// resolveAddress returns the address of output vout of txId as the creator address
+// The MetaID is left empty for the caller to compute.
+func resolveAddress(provider PrevoutProvider, chainName, txId string, vout uint32) (string, string, error) {
+	output, err := provider.Prevout(chainName, txId, vout)
+	if err != nil {
+		return "", "", err
+	}
+	if output.Address == "" {
+		return "", "", fmt.Errorf("output %s:%d has no address", txId, vout)
+	}
+	return output.Address, "", nil
+}
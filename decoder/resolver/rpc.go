@@ -0,0 +1,204 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Default RPC settings
+const (
+	DefaultRPCTimeout    = 10 * time.Second
+	DefaultRPCRetryDelay = 500 * time.Millisecond
+)
+
+// rpcTxNotFound is the Bitcoin Core error code for an unknown transaction (RPC_INVALID_ADDRESS_OR_KEY)
+const rpcTxNotFound = -5
+
+// Endpoint is the JSON-RPC endpoint of a chain node
+type Endpoint struct {
+	URL      string // e.g. http://127.0.0.1:8332
+	User     string // RPC user, empty for no authentication
+	Password string // RPC password
+}
+
+// RPCConfig configures an RPCClient
+type RPCConfig struct {
+	// Endpoints maps chain names, such as "btc", "doge" or "mvc", to their node
+	Endpoints map[string]Endpoint
+	// Timeout bounds each request, 0 means DefaultRPCTimeout
+	Timeout time.Duration
+	// Retries is the number of retries after a failed request; RPC errors returned by the node are not retried
+	Retries int
+	// RetryDelay is the delay before the first retry, doubled on each retry, 0 means DefaultRPCRetryDelay
+	RetryDelay time.Duration
+	// HTTPClient is the client used for requests, nil means http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// RPCError is an error returned by a node
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements error
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// RPCClient is a Bitcoin Core compatible JSON-RPC client for bitcoind, dogecoind and mvc nodes
+// It implements RawTxSource with getrawtransaction
+type RPCClient struct {
+	config RPCConfig
+	nextId atomic.Uint64
+}
+
+// rpcRequest is a JSON-RPC 1.0 request
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Id      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// rpcResponse is a JSON-RPC 1.0 response
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// NewRPCClient creates a JSON-RPC client
+func NewRPCClient(config RPCConfig) *RPCClient {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultRPCTimeout
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = DefaultRPCRetryDelay
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &RPCClient{
+		config: config,
+	}
+}
+
+// RawTransaction implements RawTxSource with getrawtransaction
+// Returns an error wrapping ErrTxNotFound if the node does not know the transaction
+func (c *RPCClient) RawTransaction(chainName, txId string) ([]byte, error) {
+	var txHex string
+	// Numeric verbosity is understood by every Bitcoin Core fork, old and new
+	if err := c.Call(chainName, "getrawtransaction", []interface{}{txId, 0}, &txHex); err != nil {
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) && rpcErr.Code == rpcTxNotFound {
+			return nil, fmt.Errorf("%w: %w", ErrTxNotFound, err)
+		}
+		return nil, err
+	}
+	rawTx, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode transaction %s: %w", txId, err)
+	}
+	return rawTx, nil
+}
+
+// Call calls method on the node of a chain and unmarshals the result into result
+// Failed requests are retried; an error returned by the node is returned as *RPCError
+func (c *RPCClient) Call(chainName, method string, params []interface{}, result interface{}) error {
+	return c.CallContext(context.Background(), chainName, method, params, result)
+}
+
+// CallContext is Call with a context, canceling it stops the pending request or retry delay
+func (c *RPCClient) CallContext(ctx context.Context, chainName, method string, params []interface{}, result interface{}) error {
+	endpoint, ok := c.config.Endpoints[chainName]
+	if !ok {
+		return fmt.Errorf("no RPC endpoint for chain %q", chainName)
+	}
+	body, err := json.Marshal(rpcRequest{JSONRPC: "1.0", Id: c.nextId.Add(1), Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+
+	delay := c.config.RetryDelay
+	for attempt := 0; ; attempt++ {
+		resp, retry, err := c.post(ctx, endpoint, body)
+		if err == nil {
+			if resp.Error != nil {
+				return resp.Error
+			}
+			if result == nil {
+				return nil
+			}
+			return json.Unmarshal(resp.Result, result)
+		}
+		if !retry || attempt >= c.config.Retries {
+			return fmt.Errorf("%s %s: %w", chainName, method, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s %s: %w", chainName, method, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// post sends one request, reporting whether a failure may be retried
+func (c *RPCClient) post(ctx context.Context, endpoint Endpoint, body []byte) (*rpcResponse, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if endpoint.User != "" || endpoint.Password != "" {
+		req.SetBasicAuth(endpoint.User, endpoint.Password)
+	}
+
+	httpResp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer httpResp.Body.Close()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+
+	// Nodes answer RPC errors with an error status and a JSON-RPC body
+	var resp rpcResponse
+	if err := json.Unmarshal(data, &resp); err == nil && (resp.Error != nil || httpResp.StatusCode == http.StatusOK) {
+		return &resp, false, nil
+	}
+	retry := httpResp.StatusCode >= http.StatusInternalServerError || httpResp.StatusCode == http.StatusTooManyRequests
+	return nil, retry, fmt.Errorf("unexpected response, HTTP status %s", httpResp.Status)
+}
+
+// RPCResolver is a CreatorResolver querying chain nodes over JSON-RPC
+// It is also a PrevoutProvider
+type RPCResolver struct {
+	*SourcePrevouts
+}
+
+// NewRPCResolver creates a resolver reading previous transactions through client
+// chains is passed to NewSourcePrevouts
+func NewRPCResolver(client *RPCClient, chains map[string]ChainDecoder) *RPCResolver {
+	return &RPCResolver{
+		SourcePrevouts: NewSourcePrevouts(client, chains),
+	}
+}
+
+// ResolveCreator implements decoder.CreatorResolver, see resolveAddress
+func (r *RPCResolver) ResolveCreator(chainName, txId string, vout uint32) (string, string, error) {
+	return resolveAddress(r, chainName, txId, vout)
+}
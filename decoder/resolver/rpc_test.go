@@ -0,0 +1,164 @@
+package resolver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeNode is a stand-in JSON-RPC node serving canned raw transactions
+type fakeNode struct {
+	txs      map[string][]byte
+	failures atomic.Int32 // number of requests to answer with 503 first
+	requests atomic.Int32
+	delay    time.Duration
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.requests.Add(1)
+	if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "pass" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if n.failures.Add(-1) >= 0 {
+		http.Error(w, "Work queue depth exceeded", http.StatusServiceUnavailable)
+		return
+	}
+	time.Sleep(n.delay)
+
+	var req struct {
+		Id     uint64        `json:"id"`
+		Method string        `json:"method"`
+		Params []interface{} `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "getrawtransaction" || len(req.Params) != 2 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	resp := map[string]interface{}{"id": req.Id, "error": nil}
+	rawTx, ok := n.txs[req.Params[0].(string)]
+	if !ok {
+		// Bitcoin Core answers RPC errors with HTTP 500
+		w.WriteHeader(http.StatusInternalServerError)
+		resp["result"] = nil
+		resp["error"] = map[string]interface{}{"code": -5, "message": "No such mempool or blockchain transaction."}
+	} else {
+		resp["result"] = hex.EncodeToString(rawTx)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// newNode starts a fake node holding one BTC transaction paying to the zero hash
+func newNode(t *testing.T) (*fakeNode, *httptest.Server, string) {
+	t.Helper()
+	txId, raw := btcTx(t, 0x00)
+	node := &fakeNode{txs: map[string][]byte{txId: raw}}
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	return node, server, txId
+}
+
+func TestRPCResolver(t *testing.T) {
+	_, server, txId := newNode(t)
+	client := NewRPCClient(RPCConfig{
+		Endpoints: map[string]Endpoint{"btc": {URL: server.URL, User: "user", Password: "pass"}},
+	})
+	r := NewRPCResolver(client, nil)
+
+	address, _, err := r.ResolveCreator("btc", txId, 1)
+	if err != nil {
+		t.Fatalf("ResolveCreator failed: %v", err)
+	}
+	if address != "1111111111111111111114oLvT2" {
+		t.Errorf("Unexpected address %s", address)
+	}
+	output, err := r.Prevout("btc", txId, 1)
+	if err != nil || output.Value != 1000 || output.Class != "pubkeyhash" {
+		t.Errorf("Unexpected prevout %+v, %v", output, err)
+	}
+
+	_, err = client.RawTransaction("btc", "00"+txId[2:])
+	var rpcErr *RPCError
+	if !errors.Is(err, ErrTxNotFound) || !errors.As(err, &rpcErr) || rpcErr.Code != -5 {
+		t.Errorf("Expected ErrTxNotFound wrapping the RPC error, got %v", err)
+	}
+	if _, _, err := r.ResolveCreator("doge", txId, 1); err == nil {
+		t.Error("Expected an error for a chain without endpoint")
+	}
+}
+
+func TestRPCClient_Auth(t *testing.T) {
+	node, server, txId := newNode(t)
+	client := NewRPCClient(RPCConfig{
+		Endpoints: map[string]Endpoint{"btc": {URL: server.URL, User: "user", Password: "wrong"}},
+		Retries:   3,
+	})
+	if _, err := client.RawTransaction("btc", txId); err == nil {
+		t.Fatal("Expected an authentication error")
+	}
+	if node.requests.Load() != 1 {
+		t.Errorf("Expected authentication errors not to be retried, got %d requests", node.requests.Load())
+	}
+}
+
+func TestRPCClient_Retries(t *testing.T) {
+	node, server, txId := newNode(t)
+	node.failures.Store(2)
+	endpoints := map[string]Endpoint{"btc": {URL: server.URL, User: "user", Password: "pass"}}
+
+	client := NewRPCClient(RPCConfig{Endpoints: endpoints, Retries: 2, RetryDelay: time.Millisecond})
+	if _, err := client.RawTransaction("btc", txId); err != nil {
+		t.Fatalf("Expected the request to succeed after retries, got %v", err)
+	}
+	if node.requests.Load() != 3 {
+		t.Errorf("Expected 3 requests, got %d", node.requests.Load())
+	}
+
+	node.failures.Store(2)
+	client = NewRPCClient(RPCConfig{Endpoints: endpoints, Retries: 1, RetryDelay: time.Millisecond})
+	if _, err := client.RawTransaction("btc", txId); err == nil {
+		t.Error("Expected an error once retries are exhausted")
+	}
+}
+
+func TestRPCClient_CancelRetry(t *testing.T) {
+	node, server, txId := newNode(t)
+	node.failures.Store(10)
+	client := NewRPCClient(RPCConfig{
+		Endpoints:  map[string]Endpoint{"btc": {URL: server.URL, User: "user", Password: "pass"}},
+		Retries:    5,
+		RetryDelay: time.Hour,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := client.CallContext(ctx, "btc", "getrawtransaction", []interface{}{txId, 0}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the retry delay to end with the context, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second || node.requests.Load() != 1 {
+		t.Errorf("Expected one request and an early return, got %d requests in %s", node.requests.Load(), elapsed)
+	}
+}
+
+func TestRPCClient_Timeout(t *testing.T) {
+	node, server, txId := newNode(t)
+	node.delay = 200 * time.Millisecond
+	client := NewRPCClient(RPCConfig{
+		Endpoints: map[string]Endpoint{"btc": {URL: server.URL, User: "user", Password: "pass"}},
+		Timeout:   20 * time.Millisecond,
+	})
+	start := time.Now()
+	if _, err := client.RawTransaction("btc", txId); err == nil {
+		t.Fatal("Expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Expected the request to time out early, took %s", elapsed)
+	}
+}
@@ -0,0 +1,95 @@
+package resolver
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrTxNotFound is returned by a RawTxSource that does not hold a transaction
+var ErrTxNotFound = errors.New("transaction not found")
+
+// RawTxSource is the interface for raw transaction storage used by the offline resolver
+type RawTxSource interface {
+	// RawTransaction returns the serialized transaction txId of a chain
+	// Returns ErrTxNotFound if the source does not hold it
+	RawTransaction(chainName, txId string) ([]byte, error)
+}
+
+// MemorySource is an in-memory RawTxSource
+type MemorySource struct {
+	mu  sync.RWMutex
+	txs map[string][]byte // chain name + "/" + txId -> raw transaction
+}
+
+// NewMemorySource creates an in-memory raw transaction source
+func NewMemorySource() *MemorySource {
+	return &MemorySource{
+		txs: make(map[string][]byte),
+	}
+}
+
+// Put stores a raw transaction
+func (s *MemorySource) Put(chainName, txId string, rawTx []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txs[chainName+"/"+txId] = append([]byte(nil), rawTx...)
+}
+
+// RawTransaction implements RawTxSource
+func (s *MemorySource) RawTransaction(chainName, txId string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rawTx, ok := s.txs[chainName+"/"+txId]
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+	return rawTx, nil
+}
+
+// DirSource is a RawTxSource reading raw transaction hex files from a directory
+// A transaction is read from <dir>/<chainName>/<txId>.hex, or <dir>/<txId>.hex if the former is missing.
+// Chain names and txids that could point outside dir are rejected.
+type DirSource struct {
+	dir string
+}
+
+// NewDirSource creates a raw transaction source reading from dir
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{
+		dir: dir,
+	}
+}
+
+// RawTransaction implements RawTxSource
+func (s *DirSource) RawTransaction(chainName, txId string) ([]byte, error) {
+	// txId and chainName become path elements, so they must not escape dir
+	if _, err := hex.DecodeString(txId); err != nil || len(txId) != 64 {
+		return nil, fmt.Errorf("invalid txid %q", txId)
+	}
+	if strings.Contains(chainName, "..") || strings.ContainsAny(chainName, `/\`) {
+		return nil, fmt.Errorf("invalid chain name %q", chainName)
+	}
+	for _, path := range []string{
+		filepath.Join(s.dir, chainName, txId+".hex"),
+		filepath.Join(s.dir, txId+".hex"),
+	} {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rawTx, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		return rawTx, nil
+	}
+	return nil, ErrTxNotFound
+}
@@ -0,0 +1,411 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a compiled JSON Schema document
+// Only the validation keywords used by MetaID protocol schemas are supported:
+// type, enum, const, properties, required, additionalProperties, items,
+// minItems, maxItems, minLength, maxLength, pattern, minimum, maximum, anyOf
+type Schema struct {
+	ID string // Schema ID ($id), the MetaID path the schema applies to
+
+	types                []string
+	enum                 []interface{}
+	constValue           interface{}
+	hasConst             bool
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema
+	noAdditional         bool
+	items                *Schema
+	minItems             *int
+	maxItems             *int
+	minLength            *int
+	maxLength            *int
+	pattern              *regexp.Regexp
+	minimum              *float64
+	maximum              *float64
+	anyOf                []*Schema
+}
+
+// Compile parses a JSON Schema document
+func Compile(data []byte) (*Schema, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	return compile(doc, "")
+}
+
+// compile compiles a decoded schema node
+func compile(node interface{}, ptr string) (*Schema, error) {
+	// Boolean schemas: true accepts everything, false rejects everything
+	if b, ok := node.(bool); ok {
+		s := &Schema{}
+		if !b {
+			s.anyOf = []*Schema{}
+		}
+		return s, nil
+	}
+
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema%s: expected object or boolean", ptr)
+	}
+
+	s := &Schema{}
+	if id, ok := m["$id"].(string); ok {
+		s.ID = id
+	}
+
+	if v, ok := m["type"]; ok {
+		switch t := v.(type) {
+		case string:
+			s.types = []string{t}
+		case []interface{}:
+			for _, item := range t {
+				name, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("schema%s/type: expected string", ptr)
+				}
+				s.types = append(s.types, name)
+			}
+		default:
+			return nil, fmt.Errorf("schema%s/type: expected string or array", ptr)
+		}
+	}
+
+	if v, ok := m["enum"]; ok {
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("schema%s/enum: expected array", ptr)
+		}
+		s.enum = list
+	}
+
+	if v, ok := m["const"]; ok {
+		s.constValue = v
+		s.hasConst = true
+	}
+
+	if v, ok := m["properties"]; ok {
+		props, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("schema%s/properties: expected object", ptr)
+		}
+		s.properties = make(map[string]*Schema, len(props))
+		for name, sub := range props {
+			compiled, err := compile(sub, ptr+"/properties/"+name)
+			if err != nil {
+				return nil, err
+			}
+			s.properties[name] = compiled
+		}
+	}
+
+	if v, ok := m["required"]; ok {
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("schema%s/required: expected array", ptr)
+		}
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("schema%s/required: expected string", ptr)
+			}
+			s.required = append(s.required, name)
+		}
+	}
+
+	if v, ok := m["additionalProperties"]; ok {
+		if b, ok := v.(bool); ok {
+			s.noAdditional = !b
+		} else {
+			compiled, err := compile(v, ptr+"/additionalProperties")
+			if err != nil {
+				return nil, err
+			}
+			s.additionalProperties = compiled
+		}
+	}
+
+	if v, ok := m["items"]; ok {
+		compiled, err := compile(v, ptr+"/items")
+		if err != nil {
+			return nil, err
+		}
+		s.items = compiled
+	}
+
+	var err error
+	if s.minItems, err = intKeyword(m, "minItems", ptr); err != nil {
+		return nil, err
+	}
+	if s.maxItems, err = intKeyword(m, "maxItems", ptr); err != nil {
+		return nil, err
+	}
+	if s.minLength, err = intKeyword(m, "minLength", ptr); err != nil {
+		return nil, err
+	}
+	if s.maxLength, err = intKeyword(m, "maxLength", ptr); err != nil {
+		return nil, err
+	}
+	if s.minimum, err = numberKeyword(m, "minimum", ptr); err != nil {
+		return nil, err
+	}
+	if s.maximum, err = numberKeyword(m, "maximum", ptr); err != nil {
+		return nil, err
+	}
+
+	if v, ok := m["pattern"]; ok {
+		expr, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("schema%s/pattern: expected string", ptr)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("schema%s/pattern: %w", ptr, err)
+		}
+		s.pattern = re
+	}
+
+	if v, ok := m["anyOf"]; ok {
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("schema%s/anyOf: expected array", ptr)
+		}
+		s.anyOf = make([]*Schema, 0, len(list))
+		for i, sub := range list {
+			compiled, err := compile(sub, fmt.Sprintf("%s/anyOf/%d", ptr, i))
+			if err != nil {
+				return nil, err
+			}
+			s.anyOf = append(s.anyOf, compiled)
+		}
+	}
+
+	return s, nil
+}
+
+// intKeyword reads a non-negative integer keyword
+func intKeyword(m map[string]interface{}, name, ptr string) (*int, error) {
+	v, ok := m[name]
+	if !ok {
+		return nil, nil
+	}
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, fmt.Errorf("schema%s/%s: expected non-negative integer", ptr, name)
+	}
+	n := int(f)
+	return &n, nil
+}
+
+// numberKeyword reads a number keyword
+func numberKeyword(m map[string]interface{}, name, ptr string) (*float64, error) {
+	v, ok := m[name]
+	if !ok {
+		return nil, nil
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("schema%s/%s: expected number", ptr, name)
+	}
+	return &f, nil
+}
+
+// Validate validates a decoded JSON value against the schema
+// Returns the list of validation errors, empty if the value is valid
+func (s *Schema) Validate(value interface{}) []string {
+	var errs []string
+	s.validate(value, "", &errs)
+	return errs
+}
+
+// ValidateJSON validates a JSON document against the schema
+func (s *Schema) ValidateJSON(data []byte) []string {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []string{fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	return s.Validate(value)
+}
+
+// validate appends the errors of value at ptr to errs
+func (s *Schema) validate(value interface{}, ptr string, errs *[]string) {
+	at := ptr
+	if at == "" {
+		at = "/"
+	}
+
+	if len(s.types) > 0 && !matchesAnyType(value, s.types) {
+		*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", at, strings.Join(s.types, " or "), typeOf(value)))
+		return
+	}
+
+	if s.hasConst && !equal(value, s.constValue) {
+		*errs = append(*errs, fmt.Sprintf("%s: value does not match const", at))
+	}
+
+	if s.enum != nil {
+		found := false
+		for _, candidate := range s.enum {
+			if equal(value, candidate) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*errs = append(*errs, fmt.Sprintf("%s: value is not one of the allowed values", at))
+		}
+	}
+
+	if s.anyOf != nil {
+		matched := false
+		for _, sub := range s.anyOf {
+			if len(sub.Validate(value)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			*errs = append(*errs, fmt.Sprintf("%s: value does not match any allowed schema", at))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.minLength != nil && length < *s.minLength {
+			*errs = append(*errs, fmt.Sprintf("%s: string shorter than %d", at, *s.minLength))
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			*errs = append(*errs, fmt.Sprintf("%s: string longer than %d", at, *s.maxLength))
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			*errs = append(*errs, fmt.Sprintf("%s: string does not match pattern %q", at, s.pattern.String()))
+		}
+	case float64:
+		if s.minimum != nil && v < *s.minimum {
+			*errs = append(*errs, fmt.Sprintf("%s: number less than %v", at, *s.minimum))
+		}
+		if s.maximum != nil && v > *s.maximum {
+			*errs = append(*errs, fmt.Sprintf("%s: number greater than %v", at, *s.maximum))
+		}
+	case []interface{}:
+		if s.minItems != nil && len(v) < *s.minItems {
+			*errs = append(*errs, fmt.Sprintf("%s: array has fewer than %d items", at, *s.minItems))
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			*errs = append(*errs, fmt.Sprintf("%s: array has more than %d items", at, *s.maxItems))
+		}
+		if s.items != nil {
+			for i, item := range v {
+				s.items.validate(item, fmt.Sprintf("%s/%d", ptr, i), errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", at, name))
+			}
+		}
+		// Iterate in sorted order so error lists are deterministic
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			childPtr := ptr + "/" + escapePointer(name)
+			if sub, ok := s.properties[name]; ok {
+				sub.validate(v[name], childPtr, errs)
+				continue
+			}
+			if s.noAdditional {
+				*errs = append(*errs, fmt.Sprintf("%s: additional property %q is not allowed", at, name))
+			} else if s.additionalProperties != nil {
+				s.additionalProperties.validate(v[name], childPtr, errs)
+			}
+		}
+	}
+}
+
+// matchesAnyType checks whether value has one of the JSON types
+func matchesAnyType(value interface{}, types []string) bool {
+	actual := typeOf(value)
+	for _, t := range types {
+		if t == actual {
+			return true
+		}
+		if t == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON Schema type name of a decoded JSON value
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+// equal compares two decoded JSON values
+func equal(a, b interface{}) bool {
+	switch av := a.(type) {
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			if !equal(v, bv[k]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// escapePointer escapes a property name for use in a JSON pointer
+func escapePointer(name string) string {
+	name = strings.ReplaceAll(name, "~", "~0")
+	return strings.ReplaceAll(name, "/", "~1")
+}
@@ -0,0 +1,188 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+func TestCompile_InvalidSchema(t *testing.T) {
+	invalid := []string{
+		`not json`,
+		`"string"`,
+		`{"type": 1}`,
+		`{"required": "content"}`,
+		`{"minLength": -1}`,
+		`{"pattern": "("}`,
+	}
+	for _, doc := range invalid {
+		if _, err := Compile([]byte(doc)); err == nil {
+			t.Errorf("Compile(%s) expected error, got nil", doc)
+		}
+	}
+}
+
+func TestSchema_Validate(t *testing.T) {
+	s, err := Compile([]byte(`{
+		"type": "object",
+		"required": ["name"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "maxLength": 5},
+			"age": {"type": "integer", "minimum": 0},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}},
+			"kind": {"enum": ["a", "b"]}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	tests := []struct {
+		doc      string
+		errCount int
+	}{
+		{`{"name": "bob"}`, 0},
+		{`{"name": "bob", "age": 3, "tags": ["x"], "kind": "a"}`, 0},
+		{`{}`, 1},
+		{`{"name": ""}`, 1},
+		{`{"name": "toolong"}`, 1},
+		{`{"name": "bob", "age": 1.5}`, 1},
+		{`{"name": "bob", "age": -1}`, 1},
+		{`{"name": "bob", "tags": ["x", "y", "z"]}`, 1},
+		{`{"name": "bob", "tags": [1]}`, 1},
+		{`{"name": "bob", "kind": "c"}`, 1},
+		{`{"name": "bob", "extra": true}`, 1},
+		{`[]`, 1},
+		{`{"name": `, 1},
+	}
+
+	for _, test := range tests {
+		errs := s.ValidateJSON([]byte(test.doc))
+		if len(errs) != test.errCount {
+			t.Errorf("ValidateJSON(%s) returned %d errors %v, expected %d", test.doc, len(errs), errs, test.errCount)
+		}
+	}
+}
+
+func TestSchema_ErrorPointer(t *testing.T) {
+	s, err := Compile([]byte(`{"properties": {"list": {"items": {"type": "string"}}}}`))
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	errs := s.ValidateJSON([]byte(`{"list": ["ok", 2]}`))
+	if len(errs) != 1 || !strings.HasPrefix(errs[0], "/list/1:") {
+		t.Errorf("Expected error at /list/1, got %v", errs)
+	}
+}
+
+func TestNewDefaultValidator(t *testing.T) {
+	v, err := NewDefaultValidator()
+	if err != nil {
+		t.Fatalf("NewDefaultValidator failed: %v", err)
+	}
+	for _, p := range []string{"/protocols/simplebuzz", "/protocols/paylike", "/protocols/paycomment", "/protocols/simplegroupchat", "/protocols/simplemsg", "/follow"} {
+		if _, ok := v.Lookup(p); !ok {
+			t.Errorf("Expected built-in schema for %s", p)
+		}
+	}
+}
+
+func TestValidator_ValidatePin(t *testing.T) {
+	v, err := NewDefaultValidator()
+	if err != nil {
+		t.Fatalf("NewDefaultValidator failed: %v", err)
+	}
+
+	// Valid simplebuzz
+	pin := &decoder.Pin{
+		Operation:   "create",
+		Path:        "/protocols/simplebuzz",
+		ContentType: "application/json;utf-8",
+		ContentBody: []byte(`{"content": "hello", "contentType": "text/plain"}`),
+	}
+	result := v.ValidatePin(pin)
+	if result == nil || !result.Valid {
+		t.Fatalf("Expected valid result, got %+v", result)
+	}
+	if result.Schema != "/protocols/simplebuzz" {
+		t.Errorf("Expected schema '/protocols/simplebuzz', got '%s'", result.Schema)
+	}
+
+	// Malformed simplebuzz is reported, not dropped
+	pin.ContentBody = []byte(`{"content": 42}`)
+	result = v.ValidatePin(pin)
+	if result == nil || result.Valid || len(result.Errors) == 0 {
+		t.Errorf("Expected invalid result with errors, got %+v", result)
+	}
+
+	// Non-JSON body is reported as invalid
+	pin.ContentBody = []byte(`hello`)
+	result = v.ValidatePin(pin)
+	if result == nil || result.Valid {
+		t.Errorf("Expected invalid result for non-JSON body, got %+v", result)
+	}
+
+	// Ciphertexts and bodies that failed to decode are not validated
+	pin.Encryption = "1"
+	if result = v.ValidatePin(pin); result != nil {
+		t.Errorf("Expected nil result for an encrypted body, got %+v", result)
+	}
+	pin.Encryption = "0"
+	pin.ContentDecodeError = "gzip: invalid header"
+	if result = v.ValidatePin(pin); result != nil {
+		t.Errorf("Expected nil result for a body that failed to decode, got %+v", result)
+	}
+	pin.ContentDecodeError = ""
+
+	// +json content types are validated
+	pin.ContentType = "application/ld+json"
+	if result = v.ValidatePin(pin); result == nil {
+		t.Error("Expected a result for application/ld+json")
+	}
+
+	// Non-JSON content types are not validated
+	pin.ContentType = "text/plain"
+	if result = v.ValidatePin(pin); result != nil {
+		t.Errorf("Expected nil result for text/plain, got %+v", result)
+	}
+
+	// Paths without a schema are not validated
+	pin.ContentType = "application/json"
+	pin.Path = "/info/name"
+	if result = v.ValidatePin(pin); result != nil {
+		t.Errorf("Expected nil result for path without schema, got %+v", result)
+	}
+
+	// Revoked PINs are not validated
+	pin.Path = "/protocols/simplebuzz"
+	pin.Operation = "revoke"
+	if result = v.ValidatePin(pin); result != nil {
+		t.Errorf("Expected nil result for revoke, got %+v", result)
+	}
+}
+
+func TestProcessPins_AttachesValidation(t *testing.T) {
+	v, err := NewDefaultValidator()
+	if err != nil {
+		t.Fatalf("NewDefaultValidator failed: %v", err)
+	}
+	config := decoder.DefaultConfig()
+	config.Validator = v
+
+	pins := []*decoder.Pin{
+		{Operation: "create", Path: "/protocols/paylike", ContentType: "application/json", ContentBody: []byte(`{"isLike": "2"}`)},
+		{Operation: "create", Path: "/info/name", ContentType: "text/plain", ContentBody: []byte(`alice`)},
+	}
+	pins = decoder.ProcessPins(config, pins)
+	if len(pins) != 2 {
+		t.Fatalf("Expected 2 pins, got %d", len(pins))
+	}
+	if pins[0].Validation == nil || pins[0].Validation.Valid {
+		t.Errorf("Expected invalid validation on paylike pin, got %+v", pins[0].Validation)
+	}
+	if pins[1].Validation != nil {
+		t.Errorf("Expected no validation on text pin, got %+v", pins[1].Validation)
+	}
+}
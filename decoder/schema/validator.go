@@ -0,0 +1,127 @@
+package schema
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/common"
+)
+
+//go:embed schemas/*.json
+var builtinSchemas embed.FS
+
+// Validator validates application/json PIN bodies against the schema registered for their path
+// It implements decoder.PinValidator
+type Validator struct {
+	mu      sync.RWMutex
+	schemas map[string]*Schema // path -> schema
+}
+
+// NewValidator creates an empty validator
+func NewValidator() *Validator {
+	return &Validator{
+		schemas: make(map[string]*Schema),
+	}
+}
+
+// NewDefaultValidator creates a validator loaded with the built-in /protocols/* schemas
+func NewDefaultValidator() (*Validator, error) {
+	v := NewValidator()
+	if err := v.LoadBuiltin(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// LoadBuiltin registers the schemas shipped with this package
+// Each schema document is registered under its $id, which is the MetaID path it applies to
+func (v *Validator) LoadBuiltin() error {
+	entries, err := builtinSchemas.ReadDir("schemas")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		data, err := builtinSchemas.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			return err
+		}
+		s, err := Compile(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		if s.ID == "" {
+			return fmt.Errorf("%s: schema has no $id", entry.Name())
+		}
+		v.Register(s.ID, s)
+	}
+	return nil
+}
+
+// Register attaches a compiled schema to a MetaID path
+func (v *Validator) Register(pinPath string, s *Schema) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.schemas[common.NormalizePath(pinPath)] = s
+}
+
+// RegisterJSON compiles a JSON Schema document and attaches it to a MetaID path
+func (v *Validator) RegisterJSON(pinPath string, data []byte) error {
+	s, err := Compile(data)
+	if err != nil {
+		return err
+	}
+	v.Register(pinPath, s)
+	return nil
+}
+
+// Lookup returns the schema registered for a MetaID path
+func (v *Validator) Lookup(pinPath string) (*Schema, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	s, ok := v.schemas[common.NormalizePath(pinPath)]
+	return s, ok
+}
+
+// Paths returns the MetaID paths that have a schema
+func (v *Validator) Paths() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	paths := make([]string, 0, len(v.schemas))
+	for p := range v.schemas {
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// ValidatePin validates the PIN body against the schema registered for its path
+// Returns nil for revoked PINs, non-JSON content and paths without a schema, and for bodies
+// that cannot be read as JSON: encrypted bodies and bodies that failed to decode
+func (v *Validator) ValidatePin(pin *decoder.Pin) *decoder.ValidationResult {
+	if pin == nil || pin.Operation == "revoke" {
+		return nil
+	}
+	if (pin.Encryption != "" && pin.Encryption != "0") || pin.ContentDecodeError != "" {
+		return nil
+	}
+	if !common.IsJSONContentType(pin.ContentType) {
+		return nil
+	}
+	s, ok := v.Lookup(pin.Path)
+	if !ok {
+		return nil
+	}
+
+	schemaID := s.ID
+	if schemaID == "" {
+		schemaID = common.NormalizePath(pin.Path)
+	}
+	errs := s.ValidateJSON(pin.Body())
+	return &decoder.ValidationResult{
+		Schema: schemaID,
+		Valid:  len(errs) == 0,
+		Errors: errs,
+	}
+}
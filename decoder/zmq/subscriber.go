@@ -0,0 +1,223 @@
+package zmq
+
+import (
+	"context"
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/blockfile"
+)
+
+// Topics published by nodes
+const (
+	TopicRawTx    = "rawtx"
+	TopicRawBlock = "rawblock"
+)
+
+// Default reconnect delays, doubled after each failed attempt
+const (
+	DefaultReconnectDelay    = time.Second
+	DefaultMaxReconnectDelay = time.Minute
+)
+
+// Config is the subscriber configuration
+type Config struct {
+	// Endpoint is the ZMQ endpoint of the node, e.g. tcp://127.0.0.1:28332
+	Endpoint string
+	// Network selects the parser, chain params and block format of the node
+	// e.g. blockfile.BTCMainNet or blockfile.DOGEMainNet
+	Network blockfile.Network
+	// Topics to subscribe to, default is rawtx and rawblock
+	Topics []string
+	// Parser is the configuration of the chain parser, nil for decoder.DefaultConfig()
+	Parser *decoder.ParserConfig
+	// ReconnectDelay is the delay before the first reconnect attempt, 0 for DefaultReconnectDelay
+	ReconnectDelay time.Duration
+	// MaxReconnectDelay caps the reconnect delay, 0 for DefaultMaxReconnectDelay
+	MaxReconnectDelay time.Duration
+	// Dial opens the subscription, nil for Dial
+	// Tests and other transports can plug in their own Source here
+	Dial DialFunc
+}
+
+// Event is a PIN seen in a notification
+type Event struct {
+	Topic     string       // TopicRawTx for mempool transactions, TopicRawBlock for mined blocks
+	Sequence  uint32       // Sequence number of the notification on its topic
+	BlockHash string       // Hash of the block, rawblock only
+	TxIndex   int          // Position of the transaction in the block, rawblock only
+	Pin       *decoder.Pin // Pin.Timestamp is the time the transaction was seen, or the block time
+}
+
+// Stats are the counters of a subscriber
+type Stats struct {
+	Messages   uint64 // Notifications received
+	Pins       uint64 // PIN events published
+	Missed     uint64 // Notifications lost, from gaps in the sequence numbers
+	Errors     uint64 // Notifications that could not be decoded
+	Reconnects uint64 // Reconnections after a dial or receive error
+}
+
+// Subscriber turns the notifications of a node into PIN events
+type Subscriber struct {
+	config   Config
+	parser   decoder.ChainParser
+	dial     DialFunc
+	sequence map[string]uint32 // topic -> last sequence number
+
+	messages   atomic.Uint64
+	pins       atomic.Uint64
+	missed     atomic.Uint64
+	errors     atomic.Uint64
+	reconnects atomic.Uint64
+}
+
+// NewSubscriber creates a subscriber
+func NewSubscriber(config Config) (*Subscriber, error) {
+	parser, err := decoder.NewParser(config.Network.ChainName, config.Parser)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Topics) == 0 {
+		config.Topics = []string{TopicRawTx, TopicRawBlock}
+	}
+	if config.ReconnectDelay <= 0 {
+		config.ReconnectDelay = DefaultReconnectDelay
+	}
+	if config.MaxReconnectDelay <= 0 {
+		config.MaxReconnectDelay = DefaultMaxReconnectDelay
+	}
+	dial := config.Dial
+	if dial == nil {
+		dial = Dial
+	}
+	return &Subscriber{
+		config: config,
+		parser: parser,
+		dial:   dial,
+	}, nil
+}
+
+// Run receives notifications and sends their PINs to events until ctx is done
+// Sending blocks while events is full, so a slow consumer slows down reading;
+// the node then drops notifications past its high water mark, which shows up in Stats.Missed.
+// Connection errors are retried with exponential backoff. Run returns ctx.Err().
+func (s *Subscriber) Run(ctx context.Context, events chan<- Event) error {
+	delay := s.config.ReconnectDelay
+	for {
+		if source, err := s.dial(ctx, s.config.Endpoint, s.config.Topics); err == nil {
+			delay = s.config.ReconnectDelay
+			s.receive(ctx, source, events)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.reconnects.Add(1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > s.config.MaxReconnectDelay {
+			delay = s.config.MaxReconnectDelay
+		}
+	}
+}
+
+// receive handles the messages of one subscription until it fails or ctx is done
+func (s *Subscriber) receive(ctx context.Context, source Source, events chan<- Event) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		source.Close()
+	}()
+
+	// Sequence numbers restart with a new connection to a restarted node
+	s.sequence = make(map[string]uint32)
+	for {
+		frames, err := source.Receive()
+		if err != nil {
+			return
+		}
+		s.handle(ctx, frames, events)
+	}
+}
+
+// handle publishes the PINs of a <topic> <body> <sequence> message
+// Decoding errors are counted and the message is skipped
+func (s *Subscriber) handle(ctx context.Context, frames [][]byte, events chan<- Event) {
+	if len(frames) < 2 {
+		s.errors.Add(1)
+		return
+	}
+	s.messages.Add(1)
+	topic := string(frames[0])
+	var sequence uint32
+	if len(frames) > 2 && len(frames[2]) == 4 {
+		sequence = binary.LittleEndian.Uint32(frames[2])
+		if last, ok := s.sequence[topic]; ok && sequence-last > 1 {
+			s.missed.Add(uint64(sequence - last - 1))
+		}
+		s.sequence[topic] = sequence
+	}
+
+	var pinEvents []Event
+	switch topic {
+	case TopicRawTx:
+		pins, err := s.parser.ParseTransaction(frames[1], s.config.Network.Params)
+		if err != nil {
+			s.errors.Add(1)
+			return
+		}
+		for _, pin := range pins {
+			pin.Timestamp = time.Now().Unix()
+			pinEvents = append(pinEvents, Event{Topic: topic, Sequence: sequence, Pin: pin})
+		}
+	case TopicRawBlock:
+		block, err := blockfile.ParseBlock(frames[1], s.config.Network)
+		if err != nil {
+			s.errors.Add(1)
+			return
+		}
+		for _, tx := range block.Txs {
+			pins, err := s.parser.ParseTransaction(tx.Raw, s.config.Network.Params)
+			if err != nil {
+				s.errors.Add(1)
+				continue
+			}
+			for _, pin := range pins {
+				pin.Timestamp = block.Header.Timestamp.Unix()
+				pinEvents = append(pinEvents, Event{Topic: topic, Sequence: sequence, BlockHash: block.Hash, TxIndex: tx.Index, Pin: pin})
+			}
+		}
+	default:
+		return
+	}
+
+	for _, event := range pinEvents {
+		select {
+		case events <- event:
+			s.pins.Add(1)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stats returns the counters of the subscriber
+func (s *Subscriber) Stats() Stats {
+	return Stats{
+		Messages:   s.messages.Load(),
+		Pins:       s.pins.Load(),
+		Missed:     s.missed.Load(),
+		Errors:     s.errors.Load(),
+		Reconnects: s.reconnects.Load(),
+	}
+}
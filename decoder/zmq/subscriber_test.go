@@ -0,0 +1,214 @@
+package zmq
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder/blockfile"
+	"github.com/metaid-developers/metaid-script-decoder/internal/testtx"
+)
+
+// fakeSource delivers a fixed list of messages, then fails
+type fakeSource struct {
+	mu       sync.Mutex
+	messages [][][]byte
+	received int
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func newFakeSource(messages ...[][]byte) *fakeSource {
+	return &fakeSource{messages: messages, closed: make(chan struct{})}
+}
+
+func (s *fakeSource) Receive() ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.closed:
+		return nil, errors.New("closed")
+	default:
+	}
+	if s.received == len(s.messages) {
+		return nil, errors.New("connection reset")
+	}
+	s.received++
+	return s.messages[s.received-1], nil
+}
+
+func (s *fakeSource) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+// fakeDialer returns the sources in order, nil entries fail to dial
+// Once the sources are used up, dialing blocks until ctx is done
+func fakeDialer(sources ...*fakeSource) (DialFunc, *int) {
+	var mu sync.Mutex
+	dials := 0
+	return func(ctx context.Context, endpoint string, topics []string) (Source, error) {
+		mu.Lock()
+		i := dials
+		dials++
+		mu.Unlock()
+		if i >= len(sources) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		if sources[i] == nil {
+			return nil, errors.New("connection refused")
+		}
+		return sources[i], nil
+	}, &dials
+}
+
+// message builds a <topic> <body> <sequence> notification
+func message(topic string, body []byte, sequence uint32) [][]byte {
+	return [][]byte{[]byte(topic), body, binary.LittleEndian.AppendUint32(nil, sequence)}
+}
+
+// run starts a subscriber and returns its events and a stop function returning Run's error
+func run(t *testing.T, config Config, buffer int) (*Subscriber, chan Event, func() error) {
+	t.Helper()
+	config.Network = blockfile.BTCMainNet
+	sub, err := NewSubscriber(config)
+	if err != nil {
+		t.Fatalf("NewSubscriber failed: %v", err)
+	}
+	events := make(chan Event, buffer)
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- sub.Run(ctx, events) }()
+	return sub, events, func() error {
+		cancel()
+		select {
+		case err := <-errc:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run did not return after cancel")
+			return nil
+		}
+	}
+}
+
+// next waits for an event
+func next(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+		return Event{}
+	}
+}
+
+func TestSubscriber_RawTx(t *testing.T) {
+	dial, _ := fakeDialer(newFakeSource(
+		message(TopicRawTx, testtx.RevealTx(t, `{"content":"one"}`), 0),
+		message(TopicRawTx, testtx.CoinbaseTx(t, 0x01), 1),
+		message(TopicRawTx, []byte{0x01, 0x02}, 2),
+		message(TopicRawTx, testtx.RevealTx(t, `{"content":"two"}`), 6),
+		message("hashtx", make([]byte, 32), 0),
+	))
+	sub, events, stop := run(t, Config{Dial: dial}, 10)
+
+	first := next(t, events)
+	second := next(t, events)
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if first.Topic != TopicRawTx || string(first.Pin.ContentBody) != `{"content":"one"}` || first.Sequence != 0 {
+		t.Errorf("Unexpected first event %+v", first)
+	}
+	if string(second.Pin.ContentBody) != `{"content":"two"}` || second.Sequence != 6 {
+		t.Errorf("Unexpected second event %+v", second)
+	}
+	if first.Pin.Timestamp == 0 || first.BlockHash != "" {
+		t.Errorf("Expected a seen time and no block hash, got %d %q", first.Pin.Timestamp, first.BlockHash)
+	}
+	stats := sub.Stats()
+	if stats.Messages != 5 || stats.Pins != 2 || stats.Missed != 3 || stats.Errors != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestSubscriber_RawBlock(t *testing.T) {
+	data := testtx.Block(t, testtx.CoinbaseTx(t, 0x01), testtx.RevealTx(t, `{"content":"mined"}`))
+	dial, _ := fakeDialer(newFakeSource(message(TopicRawBlock, data, 3)))
+	_, events, stop := run(t, Config{Dial: dial}, 10)
+	event := next(t, events)
+	stop()
+
+	var header wire.BlockHeader
+	header.Deserialize(bytes.NewReader(data))
+	if event.Topic != TopicRawBlock || event.BlockHash != header.BlockHash().String() || event.TxIndex != 1 {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if event.Pin.Timestamp != testtx.BlockTime.Unix() {
+		t.Errorf("Expected the block time, got %d", event.Pin.Timestamp)
+	}
+}
+
+func TestSubscriber_Reconnect(t *testing.T) {
+	dial, dials := fakeDialer(
+		nil,
+		newFakeSource(message(TopicRawTx, testtx.RevealTx(t, `{"content":"one"}`), 10)),
+		nil,
+		newFakeSource(message(TopicRawTx, testtx.RevealTx(t, `{"content":"two"}`), 0)),
+	)
+	sub, events, stop := run(t, Config{Dial: dial, ReconnectDelay: time.Millisecond, MaxReconnectDelay: 2 * time.Millisecond}, 10)
+	first := next(t, events)
+	second := next(t, events)
+	stop()
+
+	if string(first.Pin.ContentBody) != `{"content":"one"}` || string(second.Pin.ContentBody) != `{"content":"two"}` {
+		t.Errorf("Unexpected events %q %q", first.Pin.ContentBody, second.Pin.ContentBody)
+	}
+	stats := sub.Stats()
+	if stats.Reconnects < 3 || *dials < 4 {
+		t.Errorf("Expected 3 reconnects and 4 dials, got %+v and %d", stats, *dials)
+	}
+	// The sequence restarts with the new connection
+	if stats.Missed != 0 {
+		t.Errorf("Expected no missed notifications, got %d", stats.Missed)
+	}
+}
+
+func TestSubscriber_Backpressure(t *testing.T) {
+	source := newFakeSource(
+		message(TopicRawTx, testtx.RevealTx(t, `{"n":1}`), 0),
+		message(TopicRawTx, testtx.RevealTx(t, `{"n":2}`), 1),
+		message(TopicRawTx, testtx.RevealTx(t, `{"n":3}`), 2),
+	)
+	dial, _ := fakeDialer(source)
+	_, events, stop := run(t, Config{Dial: dial}, 0)
+
+	// Nobody reads events, so the subscriber waits on the first PIN
+	time.Sleep(50 * time.Millisecond)
+	source.mu.Lock()
+	received := source.received
+	source.mu.Unlock()
+	if received != 1 {
+		t.Errorf("Expected reading to stop at the first message, %d received", received)
+	}
+	if event := next(t, events); string(event.Pin.ContentBody) != `{"n":1}` {
+		t.Errorf("Unexpected event %q", event.Pin.ContentBody)
+	}
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestNewSubscriber_UnknownChain(t *testing.T) {
+	if _, err := NewSubscriber(Config{Network: blockfile.Network{ChainName: "eth"}}); err == nil {
+		t.Error("Expected an error for an unknown chain")
+	}
+}
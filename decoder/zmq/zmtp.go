@@ -0,0 +1,248 @@
+// Package zmq subscribes to the rawtx and rawblock ZMQ notifications of a node
+// and publishes the PINs they carry on a channel, for live mempool indexing.
+//
+// The subscriber speaks ZMTP 3.0 with the NULL mechanism, as used by the
+// -zmqpubrawtx and -zmqpubrawblock options of Bitcoin Core and its forks,
+// so no ZMQ library is needed.
+package zmq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// DefaultMaxMessageSize caps the size of a received message in bytes
+const DefaultMaxMessageSize = 64 << 20 // 64 MiB
+
+// handshakeTimeout bounds the handshake when the dial context has no earlier deadline
+const handshakeTimeout = 30 * time.Second
+
+// Frame flags
+const (
+	flagMore    = 0x01
+	flagLong    = 0x02
+	flagCommand = 0x04
+)
+
+// ErrMessageTooLarge is returned when a message exceeds the size limit
+var ErrMessageTooLarge = errors.New("zmq: message too large")
+
+// Source is a subscription delivering multipart messages, such as a ZMQ SUB socket
+type Source interface {
+	// Receive returns the frames of the next message
+	Receive() ([][]byte, error)
+	// Close closes the subscription, unblocking Receive
+	Close() error
+}
+
+// DialFunc opens a subscription to topics at endpoint
+type DialFunc func(ctx context.Context, endpoint string, topics []string) (Source, error)
+
+// Conn is a ZMTP 3.0 SUB connection
+type Conn struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	maxSize int
+}
+
+// Dial connects a SUB socket to a tcp://host:port endpoint and subscribes to topics
+// It is the default DialFunc of a Subscriber.
+func Dial(ctx context.Context, endpoint string, topics []string) (Source, error) {
+	conn, err := DialSize(ctx, endpoint, topics, DefaultMaxMessageSize)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// DialSize is Dial with a message size limit, 0 for DefaultMaxMessageSize
+func DialSize(ctx context.Context, endpoint string, topics []string, maxSize int) (*Conn, error) {
+	addr, ok := strings.CutPrefix(endpoint, "tcp://")
+	if !ok {
+		return nil, fmt.Errorf("zmq: unsupported endpoint %q, expected tcp://host:port", endpoint)
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+	dialer := net.Dialer{KeepAlive: 30 * time.Second}
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("zmq: failed to connect to %s: %w", endpoint, err)
+	}
+	c := &Conn{conn: netConn, r: bufio.NewReader(netConn), maxSize: maxSize}
+	deadline := time.Now().Add(handshakeTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	netConn.SetDeadline(deadline)
+	if err := c.handshake(topics); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("zmq: handshake with %s failed: %w", endpoint, err)
+	}
+	netConn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+// greeting returns the ZMTP 3.0 greeting of a NULL mechanism client
+func greeting() []byte {
+	g := make([]byte, 64)
+	g[0] = 0xff
+	g[9] = 0x7f
+	g[10] = 3 // major version
+	g[11] = 0 // minor version
+	copy(g[12:32], "NULL")
+	return g
+}
+
+// handshake exchanges greetings and READY commands, then subscribes to topics
+func (c *Conn) handshake(topics []string) error {
+	if _, err := c.conn.Write(greeting()); err != nil {
+		return err
+	}
+	peer := make([]byte, 64)
+	if _, err := io.ReadFull(c.r, peer); err != nil {
+		return fmt.Errorf("failed to read greeting: %w", err)
+	}
+	if peer[0] != 0xff || peer[9] != 0x7f || peer[10] < 3 {
+		return errors.New("peer does not speak ZMTP 3")
+	}
+	if mechanism := string(bytes.TrimRight(peer[12:32], "\x00")); mechanism != "NULL" {
+		return fmt.Errorf("unsupported security mechanism %q", mechanism)
+	}
+
+	if err := c.writeFrame(flagCommand, readyCommand("SUB")); err != nil {
+		return err
+	}
+	flags, body, err := c.readFrame()
+	if err != nil {
+		return fmt.Errorf("failed to read READY: %w", err)
+	}
+	props, err := parseReady(body)
+	if flags&flagCommand == 0 || err != nil {
+		return fmt.Errorf("invalid READY command: %v", err)
+	}
+	if socketType := props["Socket-Type"]; socketType != "PUB" && socketType != "XPUB" {
+		return fmt.Errorf("peer socket type %q is not PUB", socketType)
+	}
+
+	// ZMTP 3.0 subscriptions are messages starting with 0x01
+	for _, topic := range topics {
+		if err := c.writeFrame(0, append([]byte{0x01}, topic...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readyCommand builds a READY command announcing a socket type
+func readyCommand(socketType string) []byte {
+	var b bytes.Buffer
+	b.WriteByte(5)
+	b.WriteString("READY")
+	b.WriteByte(byte(len("Socket-Type")))
+	b.WriteString("Socket-Type")
+	binary.Write(&b, binary.BigEndian, uint32(len(socketType)))
+	b.WriteString(socketType)
+	return b.Bytes()
+}
+
+// parseReady returns the properties of a READY command
+func parseReady(body []byte) (map[string]string, error) {
+	if len(body) < 6 || body[0] != 5 || string(body[1:6]) != "READY" {
+		return nil, errors.New("not a READY command")
+	}
+	props := make(map[string]string)
+	for rest := body[6:]; len(rest) > 0; {
+		n := int(rest[0])
+		if len(rest) < 1+n+4 {
+			return nil, errors.New("truncated property")
+		}
+		name := string(rest[1 : 1+n])
+		size := binary.BigEndian.Uint32(rest[1+n:])
+		rest = rest[1+n+4:]
+		if uint64(size) > uint64(len(rest)) {
+			return nil, errors.New("truncated property value")
+		}
+		props[name] = string(rest[:size])
+		rest = rest[size:]
+	}
+	return props, nil
+}
+
+// writeFrame writes one frame
+func (c *Conn) writeFrame(flags byte, body []byte) error {
+	var header []byte
+	if len(body) > 255 {
+		header = binary.BigEndian.AppendUint64([]byte{flags | flagLong}, uint64(len(body)))
+	} else {
+		header = []byte{flags, byte(len(body))}
+	}
+	_, err := c.conn.Write(append(header, body...))
+	return err
+}
+
+// readFrame reads one frame
+func (c *Conn) readFrame() (byte, []byte, error) {
+	flags, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var size uint64
+	if flags&flagLong != 0 {
+		var b [8]byte
+		if _, err := io.ReadFull(c.r, b[:]); err != nil {
+			return 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(b[:])
+	} else {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		size = uint64(b)
+	}
+	if size > uint64(c.maxSize) {
+		return 0, nil, fmt.Errorf("%w: frame of %d bytes", ErrMessageTooLarge, size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return flags, body, nil
+}
+
+// Receive returns the frames of the next message, skipping commands
+func (c *Conn) Receive() ([][]byte, error) {
+	var frames [][]byte
+	total := 0
+	for {
+		flags, body, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		if flags&flagCommand != 0 {
+			continue
+		}
+		total += len(body)
+		if total > c.maxSize {
+			return nil, fmt.Errorf("%w: more than %d bytes", ErrMessageTooLarge, c.maxSize)
+		}
+		frames = append(frames, body)
+		if flags&flagMore == 0 {
+			return frames, nil
+		}
+	}
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,126 @@
+package zmq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// publisher is a local stand-in for the ZMQ PUB socket of a node
+// It accepts one connection, checks the handshake and subscriptions and sends messages.
+func publisher(t *testing.T, socketType string, topics []string, messages ...[][]byte) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		netConn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer netConn.Close()
+		c := &Conn{conn: netConn, r: bufio.NewReader(netConn), maxSize: DefaultMaxMessageSize}
+
+		peer := make([]byte, 64)
+		if _, err := io.ReadFull(c.r, peer); err != nil || !bytes.Equal(peer, greeting()) {
+			t.Errorf("Unexpected greeting %x: %v", peer, err)
+			return
+		}
+		netConn.Write(greeting())
+		flags, body, err := c.readFrame()
+		if err != nil || flags != flagCommand || !bytes.Equal(body, readyCommand("SUB")) {
+			t.Errorf("Unexpected READY %x: %v", body, err)
+			return
+		}
+		c.writeFrame(flagCommand, readyCommand(socketType))
+		if socketType != "PUB" {
+			return
+		}
+		for _, topic := range topics {
+			_, body, err := c.readFrame()
+			if err != nil || string(body) != "\x01"+topic {
+				t.Errorf("Expected subscription to %s, got %q: %v", topic, body, err)
+				return
+			}
+		}
+		// A heartbeat command between messages is skipped
+		c.writeFrame(flagCommand, []byte("\x04PING"))
+		for _, message := range messages {
+			for i, frame := range message {
+				var flags byte
+				if i < len(message)-1 {
+					flags = flagMore
+				}
+				c.writeFrame(flags, frame)
+			}
+		}
+	}()
+	return "tcp://" + listener.Addr().String()
+}
+
+func TestDial(t *testing.T) {
+	body := bytes.Repeat([]byte{0xab}, 300) // needs a long frame
+	endpoint := publisher(t, "PUB", []string{TopicRawTx, TopicRawBlock},
+		[][]byte{[]byte(TopicRawTx), body, {1, 0, 0, 0}},
+		[][]byte{[]byte(TopicRawBlock), {0x01}, {7, 0, 0, 0}},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	source, err := Dial(ctx, endpoint, []string{TopicRawTx, TopicRawBlock})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer source.Close()
+
+	frames, err := source.Receive()
+	if err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	if len(frames) != 3 || string(frames[0]) != TopicRawTx || !bytes.Equal(frames[1], body) {
+		t.Errorf("Unexpected message %q", frames)
+	}
+	frames, err = source.Receive()
+	if err != nil || len(frames) != 3 || string(frames[0]) != TopicRawBlock || frames[2][0] != 7 {
+		t.Errorf("Unexpected message %q: %v", frames, err)
+	}
+	if _, err := source.Receive(); err == nil {
+		t.Error("Expected an error after the publisher closed")
+	}
+}
+
+func TestDial_NotPub(t *testing.T) {
+	endpoint := publisher(t, "REQ", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := Dial(ctx, endpoint, []string{TopicRawTx}); err == nil {
+		t.Error("Expected an error for a REQ peer")
+	}
+	if _, err := Dial(ctx, "ipc:///tmp/node.sock", nil); err == nil {
+		t.Error("Expected an error for an ipc endpoint")
+	}
+}
+
+func TestDial_MessageTooLarge(t *testing.T) {
+	endpoint := publisher(t, "PUB", []string{TopicRawTx},
+		[][]byte{[]byte(TopicRawTx), bytes.Repeat([]byte{0x01}, 100)},
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := DialSize(ctx, endpoint, []string{TopicRawTx}, 64)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Receive(); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("Expected ErrMessageTooLarge, got %v", err)
+	}
+}
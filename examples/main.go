@@ -85,7 +85,7 @@ func parseMVCTransaction() {
 	parser := mvc.NewMVCParser(nil)
 
 	// Parse transaction
-	pins, err := parser.ParseTransaction(txBytes, &chaincfg.MainNetParams)
+	pins, err := parser.ParseTransaction(txBytes, &mvc.MVCMainNetParams)
 	if err != nil {
 		log.Printf("Failed to parse transaction: %v", err)
 		return
@@ -5,54 +5,38 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/metaid-developers/metaid-script-decoder/decoder"
 	"github.com/metaid-developers/metaid-script-decoder/decoder/btc"
-	"github.com/metaid-developers/metaid-script-decoder/decoder/common"
+	"github.com/metaid-developers/metaid-script-decoder/decoder/resolver"
 
 	"github.com/btcsuite/btcd/chaincfg"
 )
 
 // Example: How to use CreatorResolver and MetaIdCalculator
 
-// SimpleCreatorResolver is a simple example implementation of creator resolver
-// In actual use, you need to connect to a real blockchain node
-type SimpleCreatorResolver struct {
-	// You can add node connection configuration here
-	// nodeClient *YourNodeClient
-}
-
-// ResolveCreator implements the CreatorResolver interface
-// Query creator address based on CreatorInputLocation (txId:vout format)
-func (r *SimpleCreatorResolver) ResolveCreator(chainName, txId string, vout uint32) (string, string, error) {
-	// Actual implementation needs to:
-	// 1. Use chainName to select the correct node
-	// 2. Query the blockchain node to get the transaction based on txId and vout
-	// 3. Get the address of the specified vout from the transaction output
-	// 4. Calculate MetaID
-
-	// Example code:
-	// address := r.queryAddressFromNode(chainName, txId, vout)
-	// metaId := common.CalculateMetaId(address)
-
-	// Return example values temporarily
-	exampleAddress := "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
-	exampleMetaId := common.CalculateMetaId(exampleAddress)
-
-	return exampleAddress, exampleMetaId, nil
-}
-
 func ExampleWithResolver() {
 	fmt.Println("=== Example: Using CreatorResolver and MetaIdCalculator ===")
 
-	// 1. Create custom CreatorResolver
-	resolver := &SimpleCreatorResolver{}
+	// 1. Create a CreatorResolver querying the chain nodes over JSON-RPC
+	client := resolver.NewRPCClient(resolver.RPCConfig{
+		Endpoints: map[string]resolver.Endpoint{
+			"btc":  {URL: "http://btc-node.example:8332", User: "rpcuser", Password: "rpcpassword"},
+			"doge": {URL: "http://doge-node.example:22555", User: "rpcuser", Password: "rpcpassword"},
+			"mvc":  {URL: "http://mvc-node.example:8332", User: "rpcuser", Password: "rpcpassword"},
+		},
+		Timeout: 5 * time.Second,
+		Retries: 2,
+	})
+	creatorResolver := resolver.NewCachingResolver(resolver.NewRPCResolver(client, nil), 0, 0)
 
 	// 2. Create configuration with resolver
 	config := decoder.NewConfigWithResolver(
-		"6d6574616964", // metaid protocol
-		resolver,       // creator resolver
+		"6d6574616964",  // metaid protocol
+		creatorResolver, // creator resolver
 	)
+	config.ResolveCreators = true // call the resolver for every PIN
 
 	// 3. Create parser
 	parser := btc.NewBTCParser(config)
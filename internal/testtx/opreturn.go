@@ -0,0 +1,45 @@
+package testtx
+
+import (
+	"testing"
+
+	"github.com/bitcoinsv/bsvd/chaincfg/chainhash"
+	"github.com/bitcoinsv/bsvd/txscript"
+	"github.com/bitcoinsv/bsvd/wire"
+)
+
+// OpReturnTx builds a transaction of the BSV family with a P2PKH owner output paying to the zero
+// hash, followed by an OP_FALSE OP_RETURN output carrying a /protocols/simplebuzz PIN
+func OpReturnTx(t testing.TB, content string) []byte {
+	t.Helper()
+	opReturn, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_RETURN).
+		AddData([]byte("metaid")).
+		AddData([]byte("create")).
+		AddData([]byte("/protocols/simplebuzz")).
+		AddData([]byte("0")).
+		AddData([]byte("1.0.0")).
+		AddData([]byte("application/json")).
+		AddData([]byte(content)).
+		Script()
+	if err != nil {
+		t.Fatalf("Failed to build script: %v", err)
+	}
+	p2pkh, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).
+		AddData(make([]byte, 20)).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		t.Fatalf("Failed to build script: %v", err)
+	}
+
+	msgTx := wire.NewMsgTx(1)
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 0), []byte{txscript.OP_TRUE}))
+	msgTx.AddTxOut(wire.NewTxOut(1, p2pkh))
+	msgTx.AddTxOut(wire.NewTxOut(0, opReturn))
+	return Serialize(t, msgTx)
+}
@@ -0,0 +1,104 @@
+// Package testtx builds the transactions and blocks shared by the tests of several packages:
+// BTC reveal transactions and blocks for the block readers, and OP_RETURN transactions for the
+// parsers of the BSV family.
+package testtx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/metaid-developers/metaid-script-decoder/decoder"
+)
+
+// Content is the PIN body of RevealTx in most tests
+const Content = `{"content":"hello"}`
+
+// BlockTime is the timestamp of the blocks built by Block
+var BlockTime = time.Unix(1700000000, 0)
+
+// Serialize serializes a wire message
+func Serialize(t testing.TB, msg interface{ Serialize(w io.Writer) error }) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := msg.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// RevealTx builds a BTC taproot reveal transaction carrying a /protocols/simplebuzz PIN
+// with content as its application/json body; the owner output is a P2WPKH output
+func RevealTx(t testing.TB, content string) []byte {
+	t.Helper()
+	script, err := txscript.NewScriptBuilder().
+		AddData(bytes.Repeat([]byte{0x02}, 32)).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_IF).
+		AddData([]byte("metaid")).
+		AddData([]byte("create")).
+		AddData([]byte("/protocols/simplebuzz")).
+		AddData([]byte("0")).
+		AddData([]byte("1.0.0")).
+		AddData([]byte("application/json")).
+		AddData([]byte(content)).
+		AddOp(txscript.OP_ENDIF).
+		Script()
+	if err != nil {
+		t.Fatalf("Failed to build script: %v", err)
+	}
+	msgTx := wire.NewMsgTx(2)
+	txIn := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 0), nil, nil)
+	txIn.Witness = wire.TxWitness{bytes.Repeat([]byte{0x30}, 64), script, append([]byte{0xc0}, bytes.Repeat([]byte{0x03}, 32)...)}
+	msgTx.AddTxIn(txIn)
+	msgTx.AddTxOut(wire.NewTxOut(546, append([]byte{txscript.OP_0, 0x14}, bytes.Repeat([]byte{0xab}, 20)...)))
+	return Serialize(t, msgTx)
+}
+
+// RejectTag is the CoinbaseTx tag rejected by RejectingParser
+const RejectTag = 0xee
+
+// CoinbaseTx builds a coinbase transaction without PIN
+// tag is pushed by the coinbase script, so that blocks and transactions can be told apart
+func CoinbaseTx(t testing.TB, tag byte) []byte {
+	t.Helper()
+	msgTx := wire.NewMsgTx(1)
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0xffffffff), []byte{0x01, tag}, nil))
+	msgTx.AddTxOut(wire.NewTxOut(50, []byte{txscript.OP_TRUE}))
+	return Serialize(t, msgTx)
+}
+
+// Block builds a block of txs with a BlockTime header
+func Block(t testing.TB, txs ...[]byte) []byte {
+	t.Helper()
+	header := wire.NewBlockHeader(1, &chainhash.Hash{}, &chainhash.Hash{}, 0x1d00ffff, 1)
+	header.Timestamp = BlockTime
+	var buf bytes.Buffer
+	buf.Write(Serialize(t, header))
+	wire.WriteVarInt(&buf, 0, uint64(len(txs)))
+	for _, tx := range txs {
+		buf.Write(tx)
+	}
+	return buf.Bytes()
+}
+
+// RejectingParser wraps a parser to reject the CoinbaseTx transactions tagged RejectTag,
+// for tests of the transactions a parser fails on
+type RejectingParser struct {
+	decoder.ChainParser
+}
+
+// ParseTransaction implements decoder.ChainParser
+func (p RejectingParser) ParseTransaction(txBytes []byte, chainParams interface{}) ([]*decoder.Pin, error) {
+	if bytes.Contains(txBytes, []byte{0x01, RejectTag}) {
+		return nil, fmt.Errorf("%w: tagged %#x", decoder.ErrInvalidTransaction, RejectTag)
+	}
+	return p.ChainParser.ParseTransaction(txBytes, chainParams)
+}